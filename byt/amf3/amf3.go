@@ -0,0 +1,153 @@
+/********************************************************/
+// AMF3（ActionScript Message Format 3）编码与解码
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:Golang-master/byt
+// Example		:
+//			data,err:=amf3.Marshal(map[string]interface{}{"name":"bob","hp":100})
+//			v,err:=amf3.Unmarshal(data)
+//
+//			enc:=amf3.NewEncoder(buf)		//在字节缓冲对象中写入多个AMF3值
+//			enc.Encode(v)
+//			dec:=amf3.NewDecoder(buf)
+//			v,err=dec.Decode()
+/********************************************************/
+
+// amf3实现了AMF3格式（Adobe AMF 3.0规范）的编码与解码，与Flash/AIR的ByteArray.writeObject、
+// Haxe的format.amf3兼容。字符串、对象及特征（traits）使用引用表，与writeObject/readObject相同，
+// 默认每次Encode/Decode使用新的引用表；调用ShareReferences(true)后同一Encoder/Decoder
+// 在调用Reset之前共享引用表（如多个值组成的AMF3流）。
+//
+// Go类型与AMF3类型的对应关系：
+//
+//	nil                          null
+//	Undefined                    undefined
+//	bool                         false / true
+//	int等整数（-2^28 ~ 2^28-1）      integer（超出范围时为double）
+//	float32、float64              double
+//	string                       string
+//	time.Time                    Date（毫秒精度，解码为UTC）
+//	[]byte                       ByteArray
+//	[]interface{}等切片              Array（只有密集部分）
+//	*Array                       Array（包含关联部分）
+//	map[string]interface{}等映射     匿名的动态Object（解码时也返回map[string]interface{}）
+//	*Object                      带有类名或密封成员的Object
+//	XML、XMLDocument              XML、XMLDocument
+package amf3
+
+import (
+	"errors"
+)
+
+/**
+ * AMF3类型标记
+ */
+const (
+	MarkerUndefined    byte = 0x00
+	MarkerNull         byte = 0x01
+	MarkerFalse        byte = 0x02
+	MarkerTrue         byte = 0x03
+	MarkerInteger      byte = 0x04
+	MarkerDouble       byte = 0x05
+	MarkerString       byte = 0x06
+	MarkerXMLDocument  byte = 0x07
+	MarkerDate         byte = 0x08
+	MarkerArray        byte = 0x09
+	MarkerObject       byte = 0x0A
+	MarkerXML          byte = 0x0B
+	MarkerByteArray    byte = 0x0C
+	MarkerVectorInt    byte = 0x0D
+	MarkerVectorUint   byte = 0x0E
+	MarkerVectorDouble byte = 0x0F
+	MarkerVectorObject byte = 0x10
+	MarkerDictionary   byte = 0x11
+)
+
+/**
+ * integer类型可以表示的范围（29位有符号整数）
+ */
+const (
+	MinInt int = -1 << 28
+	MaxInt int = 1<<28 - 1
+)
+
+var (
+	/**
+	 * 不支持的类型标记
+	 */
+	ErrBadMarker = errors.New("amf3: 不支持的类型标记")
+	/**
+	 * 引用下标超出引用表
+	 */
+	ErrBadReference = errors.New("amf3: 引用不存在")
+	/**
+	 * 无法解码IExternalizable对象（其内容格式由类自行定义）
+	 */
+	ErrExternalizable = errors.New("amf3: 不支持IExternalizable对象")
+	/**
+	 * 嵌套层数超出限制
+	 */
+	ErrTooDeep = errors.New("amf3: 嵌套层数超出限制")
+)
+
+/**
+ * 嵌套的数组、对象的最大深度
+ */
+const __maxdepth__ int = 256
+
+/**
+ * undefined值
+ */
+type Undefined struct{}
+
+/**
+ * XML值（E4X）
+ */
+type XML string
+
+/**
+ * XMLDocument值（flash.xml.XMLDocument）
+ */
+type XMLDocument string
+
+/**
+ * 包含关联部分的数组（只有密集部分的数组使用[]interface{}）
+ */
+type Array struct {
+	Dense []interface{}          //密集部分（下标0 ~ n-1）
+	Assoc map[string]interface{} //关联部分（字符串键，编码时按键排序）
+}
+
+/**
+ * 对象的特征（类名及成员）
+ */
+type Traits struct {
+	Class          string   //类名（匿名对象为空）
+	Members        []string //密封成员名称
+	Dynamic        bool     //是否为动态对象
+	Externalizable bool     //是否实现了IExternalizable
+}
+
+/**
+ * 对象
+ */
+type Object struct {
+	Traits  Traits
+	Sealed  []interface{}          //密封成员的值（与Traits.Members一一对应）
+	Dynamic map[string]interface{} //动态成员（编码时按键排序）
+}
+
+/**
+ * 获取成员的值（依次查找密封成员及动态成员）
+ * @param name 成员名称
+ * @return 值，是否存在
+ */
+func (o *Object) Get(name string) (interface{}, bool) {
+	for i, m := range o.Traits.Members {
+		if m == name && i < len(o.Sealed) {
+			return o.Sealed[i], true
+		}
+	}
+	v, ok := o.Dynamic[name]
+	return v, ok
+}
@@ -0,0 +1,231 @@
+package amf3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"Golang-master/byt"
+)
+
+func unhex(s string) []byte {
+	p, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+//编码及解码的测试向量（值解码后应与decoded相同，decoded为nil时与in相同）
+//前一部分按Adobe《AMF 3 Specification》（2013年1月）中各类型一节的格式构造：
+//U29的1~4字节编码（1.3.1节）、integer超出29位时改用double、字符串/特征/对象的引用
+//（低位为0时高位为引用下标）、traits的U29O-traits标志位、Date的U29D-value及毫秒数
+//后一部分与PyAMF（pyamf/tests/test_amf3.py）中integer、string及date的测试相同
+var vectors = []struct {
+	name    string
+	in      interface{}
+	hex     string
+	decoded interface{}
+}{
+	{"int 0", 0, "04 00", nil},
+	{"int 0x7f", 0x7f, "04 7f", nil},
+	{"int 0x80", 0x80, "04 81 00", nil},
+	{"int 0x3fff", 0x3fff, "04 ff 7f", nil},
+	{"int 0x4000", 0x4000, "04 81 80 00", nil},
+	{"int 0x1fffff", 0x1fffff, "04 ff ff 7f", nil},
+	{"int 0x200000", 0x200000, "04 80 c0 80 00", nil},
+	{"int max", MaxInt, "04 bf ff ff ff", nil},
+	{"int -1", -1, "04 ff ff ff ff", nil},
+	{"int min", MinInt, "04 c0 80 80 00", nil},
+	{"int max+1", MaxInt + 1, "05 41 b0 00 00 00 00 00 00", float64(MaxInt + 1)},
+	{"int min-1", MinInt - 1, "05 c1 b0 00 00 01 00 00 00", float64(MinInt - 1)},
+	{"uint8", uint8(200), "04 81 48", 200},
+	{"double", 0.5, "05 3f e0 00 00 00 00 00 00", nil},
+	{"null", nil, "01", nil},
+	{"undefined", Undefined{}, "00", nil},
+	{"true", true, "03", nil},
+	{"false", false, "02", nil},
+	{"empty string", "", "06 01", nil},
+	{"string", "hello", "06 0b 68 65 6c 6c 6f", nil},
+	{"string reference", []interface{}{"a", "a", "b", "a"},
+		"09 09 01 06 03 61 06 00 06 03 62 06 00", nil},
+	{"dynamic traits", map[string]interface{}{"a": 1},
+		"0a 0b 01 03 61 04 01 01", nil},
+	{"traits reference", []interface{}{map[string]interface{}{"a": 1}, map[string]interface{}{"a": 2}},
+		"09 05 01 0a 0b 01 03 61 04 01 01 0a 01 00 04 02 01", nil},
+	{"sealed traits", []interface{}{
+		&Object{Traits: Traits{Class: "Point", Members: []string{"x", "y"}}, Sealed: []interface{}{1, 2}},
+		&Object{Traits: Traits{Class: "Point", Members: []string{"x", "y"}}, Sealed: []interface{}{3, 4}},
+	}, "09 05 01 0a 23 0b 50 6f 69 6e 74 03 78 03 79 04 01 04 02 0a 01 04 03 04 04", nil},
+	{"sealed and dynamic", &Object{
+		Traits:  Traits{Class: "P", Members: []string{"x"}, Dynamic: true},
+		Sealed:  []interface{}{1},
+		Dynamic: map[string]interface{}{"z": "x"},
+	}, "0a 1b 03 50 03 78 04 01 03 7a 06 02 01", nil},
+	{"byte array", []byte{1, 2, 3}, "0c 07 01 02 03", nil},
+	{"date", time.UnixMilli(1234567890123).UTC(), "08 01 42 71 f7 1f b0 4c b0 00", nil},
+	{"date epoch", time.UnixMilli(0).UTC(), "08 01 00 00 00 00 00 00 00 00", nil},
+	{"assoc array", &Array{Dense: []interface{}{1}, Assoc: map[string]interface{}{"k": true}},
+		"09 03 03 6b 03 01 04 01", nil},
+	{"xml", XML("<a/>"), "0b 09 3c 61 2f 3e", nil},
+
+	{"pyamf int 0x35", 0x35, "04 35", nil},
+	{"pyamf int 0xd4", 0xd4, "04 81 54", nil},
+	{"pyamf int 0x1a53f", 0x1a53f, "04 86 ca 3f", nil},
+	{"pyamf int -0x2a", -0x2a, "04 ff ff ff d6", nil},
+	{"pyamf unicode", "ᚠᛇᚻ", "06 13 e1 9a a0 e1 9b 87 e1 9a bb", nil},
+	{"pyamf date", time.Date(2005, 3, 18, 1, 58, 31, 0, time.UTC), "08 01 42 70 2b 36 21 15 80 00", nil},
+}
+
+func TestVectors(t *testing.T) {
+	for _, v := range vectors {
+		want := unhex(v.hex)
+		got, err := Marshal(v.in)
+		if err != nil {
+			t.Errorf("%s: Marshal: %v", v.name, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: Marshal = % x; want % x", v.name, got, want)
+		}
+		dec, err := Unmarshal(want)
+		if err != nil {
+			t.Errorf("%s: Unmarshal: %v", v.name, err)
+			continue
+		}
+		_want := v.decoded
+		if _want == nil {
+			_want = v.in
+		}
+		if !reflect.DeepEqual(dec, _want) {
+			t.Errorf("%s: Unmarshal = %#v; want %#v", v.name, dec, _want)
+		}
+	}
+}
+
+//对象引用：同一个切片、映射或对象第二次出现时写入引用
+func TestObjectReference(t *testing.T) {
+	m := map[string]interface{}{"a": 1}
+	got, err := Marshal([]interface{}{m, m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := unhex("09 05 01 0a 0b 01 03 61 04 01 01 0a 02"); !bytes.Equal(got, want) {
+		t.Errorf("Marshal = % x; want % x", got, want)
+	}
+	v, err := Unmarshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := v.([]interface{})
+	if reflect.ValueOf(arr[0]).Pointer() != reflect.ValueOf(arr[1]).Pointer() {
+		t.Error("reference decoded to a different map")
+	}
+}
+
+//编码失败时丢弃本次加入的引用
+func TestEncoderRollback(t *testing.T) {
+	b := byt.NewBuffer()
+	e := NewEncoder(b)
+	e.ShareReferences(true)
+	_bad := []interface{}{"abc", map[string]interface{}{"k": 1}, []byte{1}, make(chan int)}
+	var ute *byt.UnsupportedTypeError
+	if err := e.Encode(_bad); !errors.As(err, &ute) || b.GetTop() != 0 {
+		t.Fatalf("Encode = %v, top %d", err, b.GetTop())
+	}
+	//之后的编码不能引用失败的编码中出现过的字符串、特征或对象
+	if err := e.Encode([]interface{}{"abc", map[string]interface{}{"k": 1}, _bad[2]}); err != nil {
+		t.Fatal(err)
+	}
+	want := unhex("09 07 01 06 07 61 62 63 0a 0b 01 03 6b 04 01 01 0c 03 01")
+	if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+		t.Errorf("after rollback = % x; want % x", got, want)
+	}
+	v, err := NewDecoder(byt.NewBufferWithByte(want)).Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, []interface{}{"abc", map[string]interface{}{"k": 1}, []byte{1}}) {
+		t.Errorf("decoded %#v", v)
+	}
+}
+
+//解码失败时丢弃本次加入的引用
+func TestDecoderRollback(t *testing.T) {
+	//数组中的字符串"abc"、匿名对象的特征及数组本身被加入引用表后遇到不合法的类型标记
+	data := unhex("09 07 01 06 07 61 62 63 0a 0b 01 01 ff" +
+		"06 00" + //字符串引用0
+		"0a 01 01" + //特征引用0
+		"09 00") //对象引用0
+	b := byt.NewBufferWithByte(data)
+	d := NewDecoder(b)
+	d.ShareReferences(true)
+	if _, err := d.Decode(); err != ErrBadMarker || b.GetOffset() != 0 {
+		t.Fatalf("Decode = %v, offset %d", err, b.GetOffset())
+	}
+	if len(d.strings) != 0 || len(d.traits) != 0 || len(d.objects) != 0 {
+		t.Fatalf("tables not truncated: %d strings, %d traits, %d objects", len(d.strings), len(d.traits), len(d.objects))
+	}
+	//跳过失败的值，之后的引用均不存在
+	b.SetOffet(13)
+	for _, n := range []int{2, 3, 2} {
+		_at := b.GetOffset()
+		if _, err := d.Decode(); err != ErrBadReference {
+			t.Errorf("offset %d: err = %v; want ErrBadReference", _at, err)
+		}
+		b.SetOffet(_at + n)
+	}
+}
+
+//ByteArray的引用与第一次出现时为同一个切片
+func TestByteArrayReference(t *testing.T) {
+	v, err := Unmarshal(unhex("09 05 01 0c 07 01 02 03 0c 02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arr := v.([]interface{})
+	p0, p1 := arr[0].([]byte), arr[1].([]byte)
+	if !bytes.Equal(p0, []byte{1, 2, 3}) || &p0[0] != &p1[0] {
+		t.Errorf("decoded % x and % x as different slices", p0, p1)
+	}
+}
+
+//默认每次Encode/Decode使用新的引用表，ShareReferences(true)后共享
+func TestShareReferences(t *testing.T) {
+	for _, share := range []bool{false, true} {
+		b := byt.NewBuffer()
+		e := NewEncoder(b)
+		e.ShareReferences(share)
+		for i := 0; i < 2; i++ {
+			if err := e.Encode("abc"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want := unhex("06 07 61 62 63 06 07 61 62 63")
+		if share {
+			want = unhex("06 07 61 62 63 06 00")
+		}
+		if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+			t.Errorf("share %v: Encode = % x; want % x", share, got, want)
+		}
+		d := NewDecoder(b)
+		d.ShareReferences(share)
+		for i := 0; i < 2; i++ {
+			if v, err := d.Decode(); err != nil || v != "abc" {
+				t.Errorf("share %v: Decode = %#v, %v", share, v, err)
+			}
+		}
+		//不共享引用表时，引用之前的值中出现过的字符串不合法
+		if !share {
+			d := NewDecoder(byt.NewBufferWithByte(unhex("06 07 61 62 63 06 00")))
+			d.Decode()
+			if _, err := d.Decode(); err != ErrBadReference {
+				t.Errorf("Decode of reference = %v; want ErrBadReference", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,404 @@
+/********************************************************/
+// AMF3解码
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:Golang-master/byt
+// Example		:
+//			dec:=amf3.NewDecoder(buf)
+//			v,err:=dec.Decode()
+/********************************************************/
+
+package amf3
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"Golang-master/byt"
+)
+
+/**
+ * 将AMF3字节数组解码为一个值
+ * @param data 字节数组
+ * @return 值，错误信息
+ */
+func Unmarshal(data []byte) (interface{}, error) {
+	if data == nil {
+		data = []byte{}
+	}
+	return NewDecoder(byt.NewBufferWithByte(data)).Decode()
+}
+
+/**
+ * AMF3解码对象
+ */
+type Decoder struct {
+	b       *byt.Buffer
+	strings []string
+	traits  []*Traits
+	objects []interface{}
+	shared  bool //多次Decode之间共享引用表
+}
+
+/**
+ * 创建一个AMF3解码对象（从b的当前偏移位置读取，字符串长度、数组及属性数量受b的读取长度限制约束）
+ * @param b 字节缓冲对象
+ * @return 解码对象
+ */
+func NewDecoder(b *byt.Buffer) *Decoder {
+	return &Decoder{b: b}
+}
+
+/**
+ * 清空引用表（共享引用表时，每条消息开始时调用）
+ */
+func (d *Decoder) Reset() {
+	d.strings = d.strings[:0]
+	d.traits = d.traits[:0]
+	d.objects = d.objects[:0]
+}
+
+/**
+ * 设置多次Decode之间是否共享引用表（默认不共享，与ByteArray.readObject相同，每次解码使用新的引用表）
+ * @param share 是否共享
+ */
+func (d *Decoder) ShareReferences(share bool) {
+	d.shared = share
+}
+
+/**
+ * 解码一个值（Go类型参见包说明，integer解码为int，double解码为float64）
+ * 出错时偏移位置及引用表恢复至读取前的状态
+ * @return 值，错误信息（byt.ErrUnderflow、byt.ErrBadString、ErrBadMarker、ErrBadReference、ErrExternalizable、ErrTooDeep）
+ */
+func (d *Decoder) Decode() (interface{}, error) {
+	if !d.shared {
+		d.Reset()
+	}
+	_start := d.b.GetOffset()
+	_ns, _nt, _no := len(d.strings), len(d.traits), len(d.objects)
+	v, err := d.decode(0)
+	if err != nil {
+		d.b.SetOffet(_start)
+		d.strings, d.traits, d.objects = d.strings[:_ns], d.traits[:_nt], d.objects[:_no]
+		return nil, err
+	}
+	return v, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+func (d *Decoder) decode(depth int) (interface{}, error) {
+	if depth > __maxdepth__ {
+		return nil, ErrTooDeep
+	}
+	marker, err := d.b.TryReadUnsignedByt()
+	if err != nil {
+		return nil, err
+	}
+
+	switch marker {
+	case MarkerUndefined:
+		return Undefined{}, nil
+	case MarkerNull:
+		return nil, nil
+	case MarkerFalse:
+		return false, nil
+	case MarkerTrue:
+		return true, nil
+
+	case MarkerInteger:
+		v, err := d.readU29()
+		if err != nil {
+			return nil, err
+		}
+		if v&0x10000000 != 0 {
+			return int(v) - 0x20000000, nil
+		}
+		return int(v), nil
+
+	case MarkerDouble:
+		return d.readDouble()
+
+	case MarkerString:
+		return d.readString()
+
+	case MarkerXML, MarkerXMLDocument:
+		ref, obj, err := d.readRef()
+		if err != nil || ref {
+			return obj, err
+		}
+		p, err := d.readBytes(obj.(int), byt.LimitString)
+		if err != nil {
+			return nil, err
+		}
+		s := string(p)
+		if marker == MarkerXML {
+			d.objects = append(d.objects, XML(s))
+			return XML(s), nil
+		}
+		d.objects = append(d.objects, XMLDocument(s))
+		return XMLDocument(s), nil
+
+	case MarkerByteArray:
+		ref, obj, err := d.readRef()
+		if err != nil || ref {
+			return obj, err
+		}
+		p, err := d.readBytes(obj.(int), byt.LimitData)
+		if err != nil {
+			return nil, err
+		}
+		d.objects = append(d.objects, p) //引用与第一次出现时为同一个切片
+		return p, nil
+
+	case MarkerDate:
+		ref, obj, err := d.readRef()
+		if err != nil || ref {
+			return obj, err
+		}
+		ms, err := d.readDouble()
+		if err != nil {
+			return nil, err
+		}
+		t := time.UnixMilli(int64(ms)).UTC()
+		d.objects = append(d.objects, t)
+		return t, nil
+
+	case MarkerArray:
+		ref, obj, err := d.readRef()
+		if err != nil || ref {
+			return obj, err
+		}
+		return d.decodeArray(obj.(int), depth)
+
+	case MarkerObject:
+		ref, obj, err := d.readRef()
+		if err != nil || ref {
+			return obj, err
+		}
+		return d.decodeObject(obj.(int), depth)
+	}
+	return nil, ErrBadMarker
+}
+
+//解码数组（n为密集部分的长度）
+func (d *Decoder) decodeArray(n int, depth int) (interface{}, error) {
+	if err := d.b.CheckLimit(byt.LimitCount, n); err != nil {
+		return nil, err
+	}
+	if n > d.b.Remaining() {
+		return nil, byt.ErrUnderflow
+	}
+	_index := len(d.objects)
+	d.objects = append(d.objects, nil)
+
+	var assoc map[string]interface{}
+	for {
+		key, err := d.readString()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			break
+		}
+		if assoc == nil {
+			assoc = make(map[string]interface{})
+			d.objects[_index] = &Array{Assoc: assoc}
+		}
+		if assoc[key], err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+
+	dense := make([]interface{}, n)
+	if assoc == nil {
+		d.objects[_index] = dense
+	} else {
+		d.objects[_index].(*Array).Dense = dense
+	}
+	for i := 0; i < n; i++ {
+		x, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		dense[i] = x
+	}
+	if assoc != nil {
+		return d.objects[_index], nil
+	}
+	return dense, nil
+}
+
+//解码对象（flag为U29O的值右移1位后的内容）
+func (d *Decoder) decodeObject(flag int, depth int) (interface{}, error) {
+	t, err := d.readTraits(flag)
+	if err != nil {
+		return nil, err
+	}
+	if t.Externalizable {
+		return nil, ErrExternalizable
+	}
+
+	//匿名的动态对象解码为映射
+	if t.Class == "" && len(t.Members) == 0 && t.Dynamic {
+		m := make(map[string]interface{})
+		d.objects = append(d.objects, m)
+		if err := d.decodeMembers(m, depth); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	o := &Object{Traits: *t, Sealed: make([]interface{}, len(t.Members))}
+	d.objects = append(d.objects, o)
+	for i := range t.Members {
+		if o.Sealed[i], err = d.decode(depth + 1); err != nil {
+			return nil, err
+		}
+	}
+	if t.Dynamic {
+		o.Dynamic = make(map[string]interface{})
+		if err := d.decodeMembers(o.Dynamic, depth); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+//读取名称/值对直至空字符串
+func (d *Decoder) decodeMembers(m map[string]interface{}, depth int) error {
+	for {
+		key, err := d.readString()
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return nil
+		}
+		if m[key], err = d.decode(depth + 1); err != nil {
+			return err
+		}
+	}
+}
+
+//读取特征（flag为U29O的值右移1位后的内容）
+func (d *Decoder) readTraits(flag int) (*Traits, error) {
+	if flag&1 == 0 {
+		i := flag >> 1
+		if i >= len(d.traits) {
+			return nil, ErrBadReference
+		}
+		return d.traits[i], nil
+	}
+
+	t := &Traits{
+		Externalizable: flag&2 != 0,
+		Dynamic:        flag&4 != 0,
+	}
+	_count := flag >> 3
+	if err := d.b.CheckLimit(byt.LimitCount, _count); err != nil {
+		return nil, err
+	}
+	if _count > d.b.Remaining() {
+		return nil, byt.ErrUnderflow
+	}
+	var err error
+	if t.Class, err = d.readString(); err != nil {
+		return nil, err
+	}
+	if !t.Externalizable {
+		t.Members = make([]string, _count)
+		for i := range t.Members {
+			if t.Members[i], err = d.readString(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	d.traits = append(d.traits, t)
+	return t, nil
+}
+
+//读取对象引用标记：引用时返回(true,被引用的对象)，否则返回(false,U29的值右移1位后的内容)
+func (d *Decoder) readRef() (bool, interface{}, error) {
+	v, err := d.readU29()
+	if err != nil {
+		return false, nil, err
+	}
+	if v&1 == 0 {
+		i := int(v >> 1)
+		if i >= len(d.objects) {
+			return false, nil, ErrBadReference
+		}
+		return true, d.objects[i], nil
+	}
+	return false, int(v >> 1), nil
+}
+
+//读取字符串（使用字符串引用表）
+func (d *Decoder) readString() (string, error) {
+	v, err := d.readU29()
+	if err != nil {
+		return "", err
+	}
+	if v&1 == 0 {
+		i := int(v >> 1)
+		if i >= len(d.strings) {
+			return "", ErrBadReference
+		}
+		return d.strings[i], nil
+	}
+	p, err := d.readBytes(int(v>>1), byt.LimitString)
+	if err != nil {
+		return "", err
+	}
+	s := string(p)
+	if s != "" {
+		d.strings = append(d.strings, s)
+	}
+	return s, nil
+}
+
+//读取n个字节（kind为适用的长度限制）
+func (d *Decoder) readBytes(n int, kind byt.LimitKind) ([]byte, error) {
+	if err := d.b.CheckLimit(kind, n); err != nil {
+		return nil, err
+	}
+	if n > d.b.Remaining() {
+		return nil, byt.ErrUnderflow
+	}
+	_b := make([]byte, n)
+	if err := d.b.TryReadBytes(_b, 0, n); err != nil {
+		return nil, err
+	}
+	return _b, nil
+}
+
+func (d *Decoder) readDouble() (float64, error) {
+	var _b_ [8]byte
+	if err := d.b.TryReadBytes(_b_[:], 0, 8); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(_b_[:])), nil
+}
+
+//读取U29变长整数
+func (d *Decoder) readU29() (uint32, error) {
+	var v uint32
+	for i := 0; i < 4; i++ {
+		c, err := d.b.TryReadUnsignedByt()
+		if err != nil {
+			return 0, err
+		}
+		if i == 3 {
+			return v<<8 | uint32(c), nil
+		}
+		v = v<<7 | uint32(c&0x7f)
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,422 @@
+/********************************************************/
+// AMF3编码
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:Golang-master/byt
+// Example		:
+//			enc:=amf3.NewEncoder(buf)
+//			enc.Encode(map[string]interface{}{"name":"bob"})
+/********************************************************/
+
+package amf3
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"Golang-master/byt"
+)
+
+/**
+ * 将v编码为AMF3字节数组
+ * @param v 值
+ * @return 字节数组，错误信息
+ */
+func Marshal(v interface{}) ([]byte, error) {
+	b := byt.NewBuffer()
+	if err := NewEncoder(b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.GetByte()[:b.GetTop()], nil
+}
+
+/**
+ * AMF3编码对象
+ */
+type Encoder struct {
+	b       *byt.Buffer
+	strings map[string]int
+	traits  map[string]int
+	objects map[objectKey]int
+	nobj    int  //已分配的对象引用下标数量
+	shared  bool //多次Encode之间共享引用表
+}
+
+/**
+ * 创建一个AMF3编码对象（写入至b，不受b的编码模式影响，AMF3始终为大端）
+ * @param b 字节缓冲对象
+ * @return 编码对象
+ */
+func NewEncoder(b *byt.Buffer) *Encoder {
+	e := &Encoder{b: b}
+	e.Reset()
+	return e
+}
+
+/**
+ * 清空引用表（共享引用表时，每条消息开始时调用）
+ */
+func (e *Encoder) Reset() {
+	if e.strings == nil {
+		e.strings = make(map[string]int)
+		e.traits = make(map[string]int)
+		e.objects = make(map[objectKey]int)
+	}
+	clear(e.strings)
+	clear(e.traits)
+	clear(e.objects)
+	e.nobj = 0
+}
+
+/**
+ * 设置多次Encode之间是否共享引用表（默认不共享，与ByteArray.writeObject相同，每次编码使用新的引用表）
+ * 共享时之后的值可以引用之前的值中出现过的字符串、特征及对象，解码时Decoder也需共享引用表
+ * @param share 是否共享
+ */
+func (e *Encoder) ShareReferences(share bool) {
+	e.shared = share
+}
+
+/**
+ * 编码一个值
+ * @param v 值
+ * @return 错误信息（*byt.UnsupportedTypeError、ErrTooDeep），出错时不写入任何内容，引用表恢复至编码前的状态
+ */
+func (e *Encoder) Encode(v interface{}) error {
+	if !e.shared {
+		e.Reset()
+	}
+	_top := e.b.GetTop()
+	_ns, _nt, _no := len(e.strings), len(e.traits), e.nobj
+	if err := e.encode(reflect.ValueOf(v), 0); err != nil {
+		e.b.SetTop(_top)
+		e.truncate(_ns, _nt, _no)
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//删除引用下标不小于ns、nt、no的字符串、特征及对象（丢弃编码失败时加入的引用）
+func (e *Encoder) truncate(ns, nt, no int) {
+	for k, i := range e.strings {
+		if i >= ns {
+			delete(e.strings, k)
+		}
+	}
+	for k, i := range e.traits {
+		if i >= nt {
+			delete(e.traits, k)
+		}
+	}
+	for k, i := range e.objects {
+		if i >= no {
+			delete(e.objects, k)
+		}
+	}
+	e.nobj = no
+}
+
+var (
+	typeTime        = reflect.TypeOf(time.Time{})
+	typeUndefined   = reflect.TypeOf(Undefined{})
+	typeXML         = reflect.TypeOf(XML(""))
+	typeXMLDocument = reflect.TypeOf(XMLDocument(""))
+	typeArray       = reflect.TypeOf(Array{})
+	typeObject      = reflect.TypeOf(Object{})
+)
+
+func (e *Encoder) encode(v reflect.Value, depth int) error {
+	if !v.IsValid() {
+		e.b.WriteUnsignedByt(MarkerNull)
+		return nil
+	}
+	if depth > __maxdepth__ {
+		return ErrTooDeep
+	}
+
+	switch v.Type() {
+	case typeUndefined:
+		e.b.WriteUnsignedByt(MarkerUndefined)
+		return nil
+	case typeTime:
+		e.b.WriteUnsignedByt(MarkerDate)
+		e.nobj++
+		e.writeU29(1)
+		e.writeDouble(float64(v.Interface().(time.Time).UnixMilli()))
+		return nil
+	case typeXML:
+		e.b.WriteUnsignedByt(MarkerXML)
+		e.nobj++
+		e.writeBytes(v.String())
+		return nil
+	case typeXMLDocument:
+		e.b.WriteUnsignedByt(MarkerXMLDocument)
+		e.nobj++
+		e.writeBytes(v.String())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			e.b.WriteUnsignedByt(MarkerNull)
+			return nil
+		}
+		return e.encode(v.Elem(), depth+1)
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.b.WriteUnsignedByt(MarkerNull)
+			return nil
+		}
+		switch v.Type().Elem() {
+		case typeArray:
+			if e.writeRef(MarkerArray, v) {
+				return nil
+			}
+			return e.encodeArray(v.Interface().(*Array), depth)
+		case typeObject:
+			if e.writeRef(MarkerObject, v) {
+				return nil
+			}
+			return e.encodeObject(v.Interface().(*Object), depth)
+		}
+		return e.encode(v.Elem(), depth+1)
+
+	case reflect.Bool:
+		if v.Bool() {
+			e.b.WriteUnsignedByt(MarkerTrue)
+		} else {
+			e.b.WriteUnsignedByt(MarkerFalse)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := v.Int()
+		if n < int64(MinInt) || n > int64(MaxInt) {
+			e.b.WriteUnsignedByt(MarkerDouble)
+			e.writeDouble(float64(n))
+			return nil
+		}
+		e.b.WriteUnsignedByt(MarkerInteger)
+		e.writeU29(uint32(n) & 0x1fffffff)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := v.Uint()
+		if n > uint64(MaxInt) {
+			e.b.WriteUnsignedByt(MarkerDouble)
+			e.writeDouble(float64(n))
+			return nil
+		}
+		e.b.WriteUnsignedByt(MarkerInteger)
+		e.writeU29(uint32(n))
+
+	case reflect.Float32, reflect.Float64:
+		e.b.WriteUnsignedByt(MarkerDouble)
+		e.writeDouble(v.Float())
+
+	case reflect.String:
+		e.b.WriteUnsignedByt(MarkerString)
+		e.writeString(v.String())
+
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if e.writeRef(MarkerByteArray, v) {
+				return nil
+			}
+			e.writeBytes(string(v.Bytes()))
+			return nil
+		}
+		if e.writeRef(MarkerArray, v) {
+			return nil
+		}
+		return e.encodeDense(v, depth)
+
+	case reflect.Array:
+		e.b.WriteUnsignedByt(MarkerArray)
+		e.nobj++
+		return e.encodeDense(v, depth)
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return &byt.UnsupportedTypeError{Type: v.Type()}
+		}
+		if e.writeRef(MarkerObject, v) {
+			return nil
+		}
+		e.writeTraits(&Traits{Dynamic: true})
+		return e.encodeMembers(v, depth)
+
+	default:
+		return &byt.UnsupportedTypeError{Type: v.Type()}
+	}
+	return nil
+}
+
+//写入只有密集部分的数组内容
+func (e *Encoder) encodeDense(v reflect.Value, depth int) error {
+	e.writeU29(uint32(v.Len())<<1 | 1)
+	e.writeString("")
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//写入包含关联部分的数组内容
+func (e *Encoder) encodeArray(a *Array, depth int) error {
+	e.writeU29(uint32(len(a.Dense))<<1 | 1)
+	if err := e.encodeMembers(reflect.ValueOf(a.Assoc), depth); err != nil {
+		return err
+	}
+	for _, x := range a.Dense {
+		if err := e.encode(reflect.ValueOf(x), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//写入对象内容
+func (e *Encoder) encodeObject(o *Object, depth int) error {
+	if o.Traits.Externalizable {
+		return ErrExternalizable
+	}
+	e.writeTraits(&o.Traits)
+	for i := range o.Traits.Members {
+		var x interface{}
+		if i < len(o.Sealed) {
+			x = o.Sealed[i]
+		}
+		if err := e.encode(reflect.ValueOf(x), depth+1); err != nil {
+			return err
+		}
+	}
+	if o.Traits.Dynamic {
+		return e.encodeMembers(reflect.ValueOf(o.Dynamic), depth)
+	}
+	return nil
+}
+
+//按键排序写入名称/值对，以空字符串结束
+func (e *Encoder) encodeMembers(m reflect.Value, depth int) error {
+	if m.IsValid() && m.Len() > 0 {
+		keys := m.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		for _, k := range keys {
+			if k.String() == "" {
+				continue //空字符串表示结束，无法作为成员名称
+			}
+			e.writeString(k.String())
+			if err := e.encode(m.MapIndex(k), depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	e.writeString("")
+	return nil
+}
+
+//写入特征（已写入过相同的特征时写入引用）
+func (e *Encoder) writeTraits(t *Traits) {
+	_key := t.Class + "\x00" + strings.Join(t.Members, "\x00")
+	if t.Dynamic {
+		_key += "\x01"
+	}
+	if i, ok := e.traits[_key]; ok {
+		e.writeU29(uint32(i)<<2 | 1)
+		return
+	}
+	e.traits[_key] = len(e.traits)
+
+	_flag := uint32(len(t.Members))<<4 | 0x03
+	if t.Dynamic {
+		_flag |= 0x08
+	}
+	e.writeU29(_flag)
+	e.writeString(t.Class)
+	for _, m := range t.Members {
+		e.writeString(m)
+	}
+}
+
+//对象引用表的键（切片的地址相同但长度或类型不同时不是同一个对象）
+type objectKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+//写入类型标记，已写入过的对象写入引用并返回true，否则为对象分配引用下标
+func (e *Encoder) writeRef(marker byte, v reflect.Value) bool {
+	e.b.WriteUnsignedByt(marker)
+	_key := objectKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		_key.len = v.Len()
+	}
+	if _key.ptr != 0 && (v.Kind() != reflect.Slice || _key.len > 0) {
+		if i, ok := e.objects[_key]; ok {
+			e.writeU29(uint32(i) << 1)
+			return true
+		}
+		e.objects[_key] = e.nobj
+	}
+	e.nobj++
+	return false
+}
+
+//写入字符串（非空字符串写入过时写入引用）
+func (e *Encoder) writeString(s string) {
+	if s != "" {
+		if i, ok := e.strings[s]; ok {
+			e.writeU29(uint32(i) << 1)
+			return
+		}
+		e.strings[s] = len(e.strings)
+	}
+	e.writeU29(uint32(len(s))<<1 | 1)
+	e.b.WriteBytes([]byte(s), 0, len(s))
+}
+
+//写入不使用引用表的字节内容（ByteArray、XML）
+func (e *Encoder) writeBytes(s string) {
+	e.writeU29(uint32(len(s))<<1 | 1)
+	e.b.WriteBytes([]byte(s), 0, len(s))
+}
+
+func (e *Encoder) writeDouble(f float64) {
+	var _b_ [8]byte
+	binary.BigEndian.PutUint64(_b_[:], math.Float64bits(f))
+	e.b.WriteBytes(_b_[:], 0, 8)
+}
+
+//写入U29变长整数（1~4个字节，前3个字节各7位，第4个字节8位）
+func (e *Encoder) writeU29(v uint32) {
+	v &= 0x1fffffff
+	switch {
+	case v < 0x80:
+		e.b.WriteUnsignedByt(byte(v))
+	case v < 0x4000:
+		e.b.WriteUnsignedByt(byte(v>>7) | 0x80)
+		e.b.WriteUnsignedByt(byte(v & 0x7f))
+	case v < 0x200000:
+		e.b.WriteUnsignedByt(byte(v>>14) | 0x80)
+		e.b.WriteUnsignedByt(byte(v>>7) | 0x80)
+		e.b.WriteUnsignedByt(byte(v & 0x7f))
+	default:
+		e.b.WriteUnsignedByt(byte(v>>22) | 0x80)
+		e.b.WriteUnsignedByt(byte(v>>15) | 0x80)
+		e.b.WriteUnsignedByt(byte(v>>8) | 0x80)
+		e.b.WriteUnsignedByt(byte(v))
+	}
+}
@@ -0,0 +1,272 @@
+/********************************************************/
+// 字节对象（按位读写）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.WriteBit(alive)					//1位
+//			buf.WriteBits(uint64(state), 3)			//3位
+//			buf.WriteQuantized(hp, 0, 100, 7)		//0 ~ 100的浮点数量化为7位
+//			buf.AlignByte()
+//			buf.WriteInt(id)
+//
+//			alive:=buf.ReadBit()
+//			state:=buf.ReadBits(3)
+//			hp:=buf.ReadQuantized(0, 100, 7)
+//			buf.AlignByte()
+//			id:=buf.ReadInt()
+/********************************************************/
+
+package byt
+
+import (
+	"fmt"
+	"math"
+)
+
+//位按从高到低的顺序写入每个字节（与网络协议中常见的位字段顺序一致）。
+//按字节读写的方法总是从下一个整字节开始，相当于先调用AlignByte。
+
+/**
+ * 将位游标对齐到字节边界
+ * 写入时当前字节剩余的位保持为0，读取时跳过当前字节剩余的位
+ */
+func (b *Buffer) AlignByte() {
+	b.wbits = 0
+	b.rbits = 0
+}
+
+/**
+ * 剩余可读取的位数（包括当前字节中未读取的位）
+ */
+func (b *Buffer) RemainingBits() int {
+	n := (b.top - b.offset) * 8
+	if b.rbits > 0 {
+		n += 8 - b.rbits
+	}
+	return n
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写入val的低n位
+ * @param val 值
+ * @param n 位数（1 ~ 64）
+ */
+func (b *Buffer) WriteBits(val uint64, n int) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteBits", true), val, nil)
+	}
+	if n < 1 || n > 64 {
+		fmt.Println("[ERR]: 位数必须在1 ~ 64之间.")
+		return
+	}
+	for n > 0 {
+		if b.wbits == 0 {
+			b.grow(1)[0] = 0
+		}
+		_free := 8 - b.wbits
+		_take := min(_free, n)
+		_bits := byte(val>>uint(n-_take)) & (1<<uint(_take) - 1)
+		*b.at(b.top - 1) |= _bits << uint(_free-_take)
+		b.wbits = (b.wbits + _take) & 7
+		n -= _take
+	}
+}
+
+/**
+ * 写入1位
+ * @param val true写入1，false写入0
+ */
+func (b *Buffer) WriteBit(val bool) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteBit", true), val, nil)
+	}
+	if val {
+		b.WriteBits(1, 1)
+	} else {
+		b.WriteBits(0, 1)
+	}
+}
+
+/**
+ * 以n位补码写入一个有符号整数（超出范围时只保留低n位）
+ * @param val 值（-2^(n-1) ~ 2^(n-1)-1）
+ * @param n 位数（1 ~ 64）
+ */
+func (b *Buffer) WriteSignedBits(val int64, n int) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteSignedBits", true), val, nil)
+	}
+	b.WriteBits(uint64(val), n)
+}
+
+/**
+ * 将[min, max]范围内的浮点数量化为n位整数后写入（超出范围时取边界值，NaN写入min）
+ * 精度为(max-min)/(2^n-1)
+ * @param val 值
+ * @param min 最小值
+ * @param max 最大值
+ * @param n 位数（1 ~ 32）
+ */
+func (b *Buffer) WriteQuantized(val, min, max float64, n int) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteQuantized", true), val, nil)
+	}
+	if n < 1 || n > 32 {
+		fmt.Println("[ERR]: 量化位数必须在1 ~ 32之间.")
+		return
+	}
+	if !(max > min) {
+		fmt.Println("[ERR]: 量化范围不合法.")
+		return
+	}
+	_steps := float64(uint64(1)<<uint(n) - 1)
+	_q := 0.0
+	if val >= max {
+		_q = _steps
+	} else if val > min {
+		_q = math.Round((val - min) / (max - min) * _steps)
+	}
+	b.WriteBits(uint64(_q), n)
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取n位（与WriteBits对应）
+ */
+func (b *Buffer) ReadBits(n int) uint64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadBits(n)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取1位（与WriteBit对应）
+ */
+func (b *Buffer) ReadBit() bool {
+	if b.err != nil {
+		return false
+	}
+	v, err := b.TryReadBit()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取n位补码表示的有符号整数（与WriteSignedBits对应）
+ */
+func (b *Buffer) ReadSignedBits(n int) int64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadSignedBits(n)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取n位量化的浮点数（与WriteQuantized对应）
+ */
+func (b *Buffer) ReadQuantized(min, max float64, n int) float64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadQuantized(min, max, n)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取n位
+ * @param n 位数（1 ~ 64）
+ * @return 值，错误信息（ErrBadLength、ErrUnderflow），出错时位游标保持不变
+ */
+func (b *Buffer) TryReadBits(n int) (v uint64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadBits", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	b.live()
+	if n < 1 || n > 64 {
+		return 0, ErrBadLength
+	}
+	if n > b.RemainingBits() {
+		return 0, ErrUnderflow
+	}
+	for n > 0 {
+		if b.rbits == 0 {
+			b.offset++
+		}
+		_left := 8 - b.rbits
+		_take := min(_left, n)
+		_bits := *b.at(b.offset - 1) >> uint(_left-_take) & (1<<uint(_take) - 1)
+		v = v<<uint(_take) | uint64(_bits)
+		b.rbits = (b.rbits + _take) & 7
+		n -= _take
+	}
+	return v, nil
+}
+
+/**
+ * 读取1位
+ * @return 值，错误信息（ErrUnderflow）
+ */
+func (b *Buffer) TryReadBit() (v bool, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadBit", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_u, err := b.TryReadBits(1)
+	return _u == 1, err
+}
+
+/**
+ * 读取n位补码表示的有符号整数
+ * @param n 位数（1 ~ 64）
+ * @return 值，错误信息（ErrBadLength、ErrUnderflow）
+ */
+func (b *Buffer) TryReadSignedBits(n int) (v int64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadSignedBits", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_u, err := b.TryReadBits(n)
+	if err != nil {
+		return 0, err
+	}
+	_shift := uint(64 - n)
+	return int64(_u<<_shift) >> _shift, nil
+}
+
+/**
+ * 读取n位量化的浮点数
+ * @param min 最小值
+ * @param max 最大值
+ * @param n 位数（1 ~ 32）
+ * @return 值，错误信息（ErrBadLength、ErrUnderflow）
+ */
+func (b *Buffer) TryReadQuantized(min, max float64, n int) (v float64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadQuantized", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	if n < 1 || n > 32 {
+		return 0, ErrBadLength
+	}
+	_u, err := b.TryReadBits(n)
+	if err != nil {
+		return 0, err
+	}
+	_steps := float64(uint64(1)<<uint(n) - 1)
+	return min + float64(_u)/_steps*(max-min), nil
+}
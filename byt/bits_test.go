@@ -0,0 +1,144 @@
+package byt
+
+import (
+	"errors"
+	"testing"
+)
+
+var errStop = errors.New("stop")
+
+//在读取了部分位之后执行各个复合读取：成功时位游标归零，失败时偏移位置及位游标均恢复
+func TestTryReadRestoresBitCursor(t *testing.T) {
+	aead, err := NewAESGCM(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	readInt := func(b *Buffer) error {
+		_, err := b.TryReadInt()
+		return err
+	}
+	cases := []struct {
+		name  string
+		write func(b *Buffer) //合法的内容
+		bad   func(b *Buffer) //读取到中途才会失败的内容
+		read  func(b *Buffer) error
+	}{
+		{"UTF8String",
+			func(b *Buffer) { b.WriteUTF8String("hi") },
+			func(b *Buffer) { b.WriteLength(10); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := b.TryReadUTF8String(); return err }},
+		{"Data",
+			func(b *Buffer) { b.WriteData([]byte{1, 2}) },
+			func(b *Buffer) { b.WriteLength(10); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := b.TryReadData(); return err }},
+		{"UTF",
+			func(b *Buffer) { b.WriteUTF("hi") },
+			func(b *Buffer) { b.WriteUnsignedShort(10); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := b.TryReadUTF(); return err }},
+		{"String",
+			func(b *Buffer) { b.WriteString("hi", ASCII) },
+			func(b *Buffer) { b.WriteLength(3); b.WriteUnsignedShort(0xffff) },
+			func(b *Buffer) error { _, err := b.TryReadString(ASCII); return err }},
+		{"FixedString",
+			func(b *Buffer) { b.WriteFixedString("hi", 4, ASCII) },
+			func(b *Buffer) { b.WriteInt(-1) },
+			func(b *Buffer) error { _, err := b.TryReadFixedString(4, ASCII); return err }},
+		{"Zigzag32",
+			func(b *Buffer) { b.WriteZigzag32(-5) },
+			func(b *Buffer) { b.WriteVarint(1 << 40) },
+			func(b *Buffer) error { _, err := b.TryReadZigzag32(); return err }},
+		{"Count",
+			func(b *Buffer) { b.WriteLength(4) },
+			func(b *Buffer) { b.WriteLength(100) },
+			func(b *Buffer) error {
+				b.SetLimits(Limits{MaxCount: 10})
+				_, err := b.TryReadCount()
+				return err
+			}},
+		{"Array",
+			func(b *Buffer) { b.WriteArray(2, func(i int) { b.WriteInt(int32(i)) }) },
+			func(b *Buffer) { b.WriteLength(3); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := b.TryReadArray(func(int) error { return readInt(b) }); return err }},
+		{"Optional",
+			func(b *Buffer) { b.WriteOptional(true, func() { b.WriteInt(1) }) },
+			func(b *Buffer) { b.WriteBoolean(true); b.WriteShort(0) },
+			func(b *Buffer) error { _, err := b.TryReadOptional(func() error { return readInt(b) }); return err }},
+		{"Slice",
+			func(b *Buffer) { WriteSlice(b, []int32{1, 2}) },
+			func(b *Buffer) { b.WriteLength(3); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := TryReadSlice[int32](b); return err }},
+		{"MapOf",
+			func(b *Buffer) { WriteMapOf(b, map[int32]int32{1: 2}) },
+			func(b *Buffer) { b.WriteLength(2); b.WriteInt(0) },
+			func(b *Buffer) error { _, err := TryReadMapOf[int32, int32](b); return err }},
+		{"OptionalOf",
+			func(b *Buffer) { v := int32(3); WriteOptionalOf(b, &v) },
+			func(b *Buffer) { b.WriteBoolean(true); b.WriteShort(0) },
+			func(b *Buffer) error { _, err := TryReadOptionalOf[int32](b); return err }},
+		{"Compressed",
+			func(b *Buffer) { b.WriteCompressed(func(inner *Buffer) { inner.WriteInt(1) }, Gzip) },
+			func(b *Buffer) { b.WriteUnsignedByt(byte(Gzip)); b.WriteData([]byte{1, 2, 3}) },
+			func(b *Buffer) error { _, err := b.TryReadCompressed(); return err }},
+		{"Sealed",
+			func(b *Buffer) { b.WriteSealed(aead, RandomNonce, func(inner *Buffer) { inner.WriteInt(1) }) },
+			func(b *Buffer) {
+				b.WriteSealed(aead, RandomNonce, func(inner *Buffer) { inner.WriteInt(1) })
+				*b.at(b.GetTop() - 1) ^= 0xff
+			},
+			func(b *Buffer) error { _, err := b.TryReadSealed(aead); return err }},
+		{"Object",
+			func(b *Buffer) { b.WriteInt(1); b.WriteUTF8String("hi") },
+			func(b *Buffer) { b.WriteInt(1); b.WriteLength(10) },
+			func(b *Buffer) error {
+				var v struct {
+					A int32
+					B string
+				}
+				return b.ReadObject(&v)
+			}},
+		{"Value",
+			func(b *Buffer) { b.WriteValue([]interface{}{int32(1), "a"}) },
+			func(b *Buffer) {
+				b.WriteUnsignedByt(byte(TagArray))
+				b.WriteLength(3)
+				b.WriteUnsignedByt(byte(TagNull))
+			},
+			func(b *Buffer) error { _, err := b.ReadValue(); return err }},
+		{"Message",
+			func(b *Buffer) { b.WriteField(1, WireVarint, func() { b.WriteVarint(5) }); b.WriteMessageEnd() },
+			func(b *Buffer) { b.WriteFieldKey(2, WireBytes); b.WriteUvarint(100) },
+			func(b *Buffer) error {
+				return b.TryReadMessage(func(int, Wire, *Buffer) error { return nil })
+			}},
+		{"EndVerify",
+			func(b *Buffer) { b.EndChecksum(b.BeginChecksum(), XXHash64) },
+			func(b *Buffer) { b.WriteLong(0) },
+			func(b *Buffer) error { return b.EndVerify(b.BeginVerify(), XXHash64) }},
+	}
+	for _, c := range cases {
+		for _, ok := range []bool{true, false} {
+			b := NewBuffer()
+			b.WriteBits(5, 3)
+			if ok {
+				c.write(b)
+			} else {
+				c.bad(b)
+			}
+			if v := b.ReadBits(3); v != 5 {
+				t.Fatalf("%s: ReadBits = %d", c.name, v)
+			}
+			_off, _bits := b.offset, b.rbits
+			err := c.read(b)
+			switch {
+			case ok && err != nil:
+				t.Errorf("%s: %v", c.name, err)
+			case ok && (b.rbits != 0 || b.Remaining() != 0):
+				t.Errorf("%s: after success rbits = %d, remaining %d", c.name, b.rbits, b.Remaining())
+			case !ok && err == nil:
+				t.Errorf("%s: bad input accepted", c.name)
+			case !ok && (b.offset != _off || b.rbits != _bits):
+				t.Errorf("%s: after failure offset %d rbits %d; want %d %d", c.name, b.offset, b.rbits, _off, _bits)
+			}
+		}
+	}
+}
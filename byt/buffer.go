@@ -11,11 +11,12 @@
 package byt
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
-	"reflect"
-	"strconv"
+	"math"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 var (
@@ -24,53 +25,91 @@ var (
 	 */
 	__capacity__ int = 32
 	/**
-	 * 最大数据长度（400*1024字节）
+	 * 最大数据长度（400*1024字节，未设置读取长度限制时的默认值）
 	 */
 	__maxlength__ int = 400 * 1024
 	/**
-	 * 编码模式
+	 * 默认编码模式（新建字节缓冲对象时使用）
 	 */
-	endian string = "big_endian"
+	endian atomic.Value
 )
 
+//atomic.Value要求每次存入相同的具体类型
+type __order__ struct {
+	order binary.ByteOrder
+}
+
+func init() {
+	endian.Store(__order__{binary.BigEndian})
+}
+
 /**
- * 设置编码模式
+ * 设置默认编码模式
+ * 只影响之后创建的字节缓冲对象，已创建的对象使用各自的编码模式（参见SetOrder）
  * @param 编码模式（big_endian以及lit_endian）
  */
 func SetEndian(s string) {
-	if s != "big_endian" && s != "lit_endian" {
+	if s == "big_endian" {
+		SetDefaultOrder(binary.BigEndian)
+
+	} else if s == "lit_endian" {
+		SetDefaultOrder(binary.LittleEndian)
+	}
+}
+
+/**
+ * 设置默认编码模式
+ * 只影响之后创建的字节缓冲对象，已创建的对象使用各自的编码模式（参见SetOrder）
+ * @param order 编码模式（binary.BigEndian、binary.LittleEndian或其他binary.ByteOrder实现）
+ */
+func SetDefaultOrder(order binary.ByteOrder) {
+	if order == nil {
 		return
 	}
-	endian = s
+	endian.Store(__order__{order})
 }
 
 /**
- * 获取编码模式结构体（内部使用）
+ * 获取默认编码模式结构体（内部使用）
  */
 func getEndian() binary.ByteOrder {
-	if endian == "big_endian" {
-		return binary.BigEndian
-
-	} else if endian == "lit_endian" {
-		return binary.LittleEndian
-	}
-	return nil
+	return endian.Load().(__order__).order
 }
 
 /**
  * 字节缓冲对象
+ * 实现了io.Reader、io.Writer、io.ByteReader、io.ByteWriter、io.ReaderFrom及io.WriterTo接口
  */
-type __buffer__ struct {
-	byt    []byte //字节对象
+type Buffer struct {
+	byt    []byte   //字节对象
+	chunks *chunked //分块模式的内容（参见NewChunkedBuffer），不为nil时不使用byt
 	top    int
 	offset int
+	mark   int              //标记的偏移位置（参见Mark、Reset）
+	err    error            //首个读取错误（粘滞错误）
+	order  binary.ByteOrder //编码模式
+
+	//位读写相关（参见WriteBits、ReadBits）
+	wbits    int //最后一个字节已写入的位数（0表示没有未写满的字节）
+	rbits    int //偏移位置前一个字节已读取的位数（0表示没有未读完的字节）
+	markbits int //标记时已读取的位数
+
+	//跟踪相关（参见StartTrace）
+	trace *tracer
+	tbase int //记录的偏移位置的基准（消息字段的内容对象中为字段内容在原对象中的位置）
+
+	limits Limits //读取长度限制（参见SetLimits）
+
+	//对象池相关
+	pooled   bool
+	released bool
 }
 
 /**
  * 创建一个字节缓冲对象（默认容量为CAPACITY=32）
  * @return 返回创建完毕的字节缓冲对象
  */
-func NewBuffer() *__buffer__ {
+func NewBuffer() *Buffer {
 	return NewBufferWithLen(__capacity__)
 }
 
@@ -79,46 +118,87 @@ func NewBuffer() *__buffer__ {
  * @param capacity 容量值
  * @return 返回创建完毕的字节缓冲对象
  */
-func NewBufferWithLen(capacity int) *__buffer__ {
+func NewBufferWithLen(capacity int) *Buffer {
 	if capacity < 1 {
 		fmt.Println("[ERR]: 参数 < 1.")
 		return nil
 	}
 	val := make([]byte, capacity)
-	return &__buffer__{
+	return &Buffer{
 		top:    0,
 		offset: 0,
 		byt:    val,
+		order:  getEndian(),
 	}
 }
 
+/**
+ * 创建一个指定编码模式的字节缓冲对象（默认容量为CAPACITY=32）
+ * @param order 编码模式（binary.BigEndian、binary.LittleEndian或其他binary.ByteOrder实现）
+ * @return 返回创建完毕的字节缓冲对象
+ */
+func NewBufferWithOrder(order binary.ByteOrder) *Buffer {
+	b := NewBuffer()
+	b.SetOrder(order)
+	return b
+}
+
 /**
  * 创建一个字节缓冲对象
  * @param b 字节数组
  * @return 返回创建完毕的字节缓冲对象
  */
-func NewBufferWithByte(b []byte) *__buffer__ {
+func NewBufferWithByte(b []byte) *Buffer {
 	if b == nil {
 		return nil
 	}
 	l := len(b)
-	return &__buffer__{
+	return &Buffer{
 		byt:    b,
 		top:    l,
 		offset: 0,
+		order:  getEndian(),
 	}
 }
 
+/**
+ * 获取字节缓冲对象的编码模式
+ * @return 编码模式
+ */
+func (b *Buffer) Order() binary.ByteOrder {
+	if b.order == nil {
+		return getEndian()
+	}
+	return b.order
+}
+
+/**
+ * 设置字节缓冲对象的编码模式（只影响该对象）
+ * @param order 编码模式（binary.BigEndian、binary.LittleEndian或其他binary.ByteOrder实现）
+ */
+func (b *Buffer) SetOrder(order binary.ByteOrder) {
+	if order == nil {
+		fmt.Println("[ERR]: 编码模式不能为nil.")
+		return
+	}
+	b.order = order
+}
+
 /**
  * 设置容量
  * @param capa 容量值
  */
-func (b *__buffer__) SetCapacity(capa int) {
-	l := len(b.byt)
+func (b *Buffer) SetCapacity(capa int) {
+	b.live()
+	l := b.capacity()
 	if capa < l {
 		fmt.Println("[ERR]: 参数长度不能小于当前字节容量")
 		return
 	}
+	if b.chunks != nil {
+		b.chunks.reserve(capa) //分块模式下只追加新的块
+		return
+	}
 	for ; l < capa; l = (l << 1) + 1 {
 	}
 
@@ -132,7 +212,7 @@ func (b *__buffer__) SetCapacity(capa int) {
  * 获取字节缓冲对象的top值
  * @return top值
  */
-func (b *__buffer__) GetTop() int {
+func (b *Buffer) GetTop() int {
 	return b.top
 }
 
@@ -140,22 +220,23 @@ func (b *__buffer__) GetTop() int {
  * 设置字节缓冲对象的top值
  * @param top值
  */
-func (b *__buffer__) SetTop(t int) {
+func (b *Buffer) SetTop(t int) {
 	if t < b.offset {
 		fmt.Println("[ERR]: 参数不能小于当前的字节缓冲偏移量")
 		return
 	}
-	if t > len(b.byt) {
+	if t > b.capacity() {
 		b.SetCapacity(t)
 	}
 	b.top = t
+	b.wbits = 0
 }
 
 /**
  * 获取当前偏移位置
  * @return 偏移位置
  */
-func (b *__buffer__) GetOffset() int {
+func (b *Buffer) GetOffset() int {
 	return b.offset
 }
 
@@ -163,18 +244,19 @@ func (b *__buffer__) GetOffset() int {
  * 设置偏移位置
  * @param offs 偏移位置
  */
-func (b *__buffer__) SetOffet(offs int) {
+func (b *Buffer) SetOffet(offs int) {
 	if offs < 0 || offs > b.top {
 		fmt.Println("[ERR]: 设置偏移位置不合法.")
 		return
 	}
 	b.offset = offs
+	b.rbits = 0
 }
 
 /**
  * 剩余可读取的内容长度
  */
-func (b *__buffer__) Remaining() int {
+func (b *Buffer) Remaining() int {
 	return b.top - b.offset
 }
 
@@ -182,22 +264,27 @@ func (b *__buffer__) Remaining() int {
 * 剩余可读取的内容长度是否大于0
 * @return true：大于0；false：小于0
  */
-func (b *__buffer__) HasRemaining() bool {
+func (b *Buffer) HasRemaining() bool {
 	return b.Remaining() > 0
 }
 
 /**
  * 字节缓冲对象的数据长度
  */
-func (b *__buffer__) Length() int {
-	return len(b.byt)
+func (b *Buffer) Length() int {
+	return b.capacity()
 }
 
 /**
 * 字节数组对象
+* 分块模式下返回内容（0至top）的副本，需要避免拷贝时使用Buffers
 * @return 一个byte[]类型对象
  */
-func (b *__buffer__) GetByte() []byte {
+func (b *Buffer) GetByte() []byte {
+	b.live()
+	if b.chunks != nil {
+		return append([]byte(nil), b.span(0, b.top)...)
+	}
 	return b.byt
 }
 
@@ -205,9 +292,10 @@ func (b *__buffer__) GetByte() []byte {
 * 获取字节有效数据总长度与当前偏移量的差值长度字节对象
 * @return 字节对象
  */
-func (b *__buffer__) GetRemainingByte() []byte {
+func (b *Buffer) GetRemainingByte() []byte {
+	b.live()
 	data := make([]byte, b.Remaining())
-	copy(data[0:], b.byt[b.offset:])
+	copy(data[0:], b.span(b.offset, b.top))
 	return data
 }
 
@@ -215,25 +303,47 @@ func (b *__buffer__) GetRemainingByte() []byte {
  * 检测参数对象是否是byt.Buffer类型
  * @return true:是；false：否
  */
-func (b *__buffer__) Check(val interface{}) bool {
-	return reflect.TypeOf(val).String() == reflect.TypeOf(b).String()
+func (b *Buffer) Check(val interface{}) bool {
+	_, ok := val.(*Buffer)
+	return ok
 }
 
 /**
- * 将偏移指针及top值归0
+ * 将偏移指针、标记及top值归0，并清除记录的读取错误
  */
-func (b *__buffer__) Zero() {
+func (b *Buffer) Zero() {
 	b.top = 0
 	b.offset = 0
+	b.mark = 0
+	b.err = nil
+	b.wbits, b.rbits, b.markbits = 0, 0, 0
+}
+
+/**
+ * 获取首个读取错误（粘滞错误）
+ * 不返回错误的ReadXxx方法在读取失败时返回零值并记录错误，此后的ReadXxx调用均直接返回零值，
+ * 因此可以连续读取多个值后只检查一次Err()
+ * @return 错误信息，没有错误时返回nil
+ */
+func (b *Buffer) Err() error {
+	return b.err
+}
+
+/**
+ * 清除记录的读取错误
+ */
+func (b *Buffer) ClearErr() {
+	b.err = nil
 }
 
 /**
  * Hash值
  */
-func (b *__buffer__) Hash() int {
+func (b *Buffer) Hash() int {
 	h := 17
+	_data := b.span(0, b.top)
 	for i := b.top - 1; i >= 0; i-- {
-		h = 65537*h + int(b.byt[i])
+		h = 65537*h + int(_data[i])
 	}
 	return h
 }
@@ -242,19 +352,20 @@ func (b *__buffer__) Hash() int {
  * 相等判断（相当于“==”，并不是“===”）
  * @return true：相等；false：不相等
  */
-func (b *__buffer__) Equal(val interface{}) bool {
+func (b *Buffer) Equal(val interface{}) bool {
 	if !b.Check(val) {
 		return false
 	}
-	_val_ := val.(__buffer__)
+	_val_ := val.(*Buffer)
 	if _val_.top != b.top {
 		return false
 	}
 	if _val_.offset != b.offset {
 		return false
 	}
+	_a, _b := b.span(0, b.top), _val_.span(0, _val_.top)
 	for i := b.top - 1; i >= 0; i-- {
-		if _val_.byt[i] != b.byt[i] {
+		if _b[i] != _a[i] {
 			return false
 		}
 	}
@@ -262,13 +373,22 @@ func (b *__buffer__) Equal(val interface{}) bool {
 }
 
 /**
- * 释放
+ * 释放（由Acquire获取的对象应使用Release放回对象池）
  */
-func (b *__buffer__) Kill() {
+func (b *Buffer) Kill() {
 	b.Zero()
 	if b.byt != nil {
 		b.byt = nil
 	}
+	b.chunks = nil
+}
+
+//重置为新建时的状态（保留字节数组）
+func (b *Buffer) reset() {
+	b.Zero()
+	b.trace = nil
+	b.limits = Limits{}
+	b.order = getEndian()
 }
 
 ////////////////////////////////////////////////////
@@ -276,190 +396,367 @@ func (b *__buffer__) Kill() {
 ////////////////////////////////////////////////////
 
 /**
- * 读取
+ * 读取字节
  * @param bt 目标字节数组
  * @param pos 读取的内容至目标字节数组中的插入位置
  * @param l 从源数据中读取的长度
  */
-func (b *__buffer__) Read(bt []byte, pos int, l int) {
-	_pos_ := b.offset
-	copy(bt[pos:], b.byt[_pos_:_pos_+l])
-	b.offset += l
+func (b *Buffer) ReadBytes(bt []byte, pos int, l int) {
+	if b.err != nil {
+		return
+	}
+	b.fail(b.TryReadBytes(bt, pos, l))
 }
 
 /**
  * 读一个boolean布尔值
  */
-func (b *__buffer__) ReadBoolean() bool {
-	bol := (b.byt[b.offset] != 0)
-	b.offset++
-	return bol
+func (b *Buffer) ReadBoolean() bool {
+	if b.err != nil {
+		return false
+	}
+	v, err := b.TryReadBoolean()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个无符号的byte值（uint8）
  */
-func (b *__buffer__) ReadUnsignedByt() byte {
-	_byt_ := b.byt[b.offset]
-	b.offset++
-	return _byt_
+func (b *Buffer) ReadUnsignedByt() byte {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadUnsignedByt()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个byte值（int8）
  */
-func (b *__buffer__) ReadByt() int8 {
-	var _val_ int8
-	binary.Read(readValue(b, 1), getEndian(), &_val_)
-	return _val_
+func (b *Buffer) ReadByt() int8 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadByt()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个Short值（int16）
  */
-func (b *__buffer__) ReadShort() int16 {
-	var _val_ int16
-	binary.Read(readValue(b, 2), getEndian(), &_val_)
-	return _val_
+func (b *Buffer) ReadShort() int16 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadShort()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个int值（int32）
  */
-func (b *__buffer__) ReadInt() int32 {
-	var _val_ int32
-	binary.Read(readValue(b, 4), getEndian(), &_val_)
-	return _val_
+func (b *Buffer) ReadInt() int32 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadInt()
+	b.fail(err)
+	return v
 }
 
 /**
 * 读取一个long值（int64）
 *
  */
-func (b *__buffer__) ReadLong() int64 {
-	var _val_ int64
-	binary.Read(readValue(b, 8), getEndian(), &_val_)
-	return _val_
+func (b *Buffer) ReadLong() int64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadLong()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个float值（float64）
  */
-func (b *__buffer__) ReadFloat() float64 {
-	var _val_ float64
-	binary.Read(readValue(b, 8), getEndian(), &_val_)
-	return _val_
+func (b *Buffer) ReadFloat() float64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadFloat()
+	b.fail(err)
+	return v
 }
 
 /**
  * 读取一个长度值
+ * @return 长度值，发生错误时返回-1
+ */
+func (b *Buffer) ReadLength() int {
+	if b.err != nil {
+		return -1
+	}
+	v, err := b.TryReadLength()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个utf8字符串
+ */
+func (b *Buffer) ReadUTF8String() string {
+	if b.err != nil {
+		return ""
+	}
+	v, err := b.TryReadUTF8String()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个字节数组
  */
-func (b *__buffer__) ReadLength() int {
-	var n uint8 = b.byt[b.offset] & 0xff
+func (b *Buffer) ReadData() []byte {
+	if b.err != nil {
+		return nil
+	}
+	v, err := b.TryReadData()
+	b.fail(err)
+	return v
+}
+
+////////////////////////////////////////////////////
+//					读（返回错误）					  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取字节
+ * @param bt 目标字节数组
+ * @param pos 读取的内容至目标字节数组中的插入位置
+ * @param l 从源数据中读取的长度
+ * @return 错误信息（ErrBadLength、ErrUnderflow）
+ */
+func (b *Buffer) TryReadBytes(bt []byte, pos int, l int) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadBytes", true)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	if pos < 0 || l < 0 || pos+l > len(bt) {
+		return ErrBadLength
+	}
+	data, err := b.next(l)
+	if err != nil {
+		return err
+	}
+	copy(bt[pos:], data)
+	return nil
+}
+
+/**
+ * 读一个boolean布尔值
+ */
+func (b *Buffer) TryReadBoolean() (v bool, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadBoolean", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	data, err := b.next(1)
+	if err != nil {
+		return false, err
+	}
+	return data[0] != 0, nil
+}
+
+/**
+ * 读取一个无符号的byte值（uint8）
+ */
+func (b *Buffer) TryReadUnsignedByt() (v byte, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUnsignedByt", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	data, err := b.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+/**
+ * 读取一个byte值（int8）
+ */
+func (b *Buffer) TryReadByt() (v int8, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadByt", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 1, b.Order())
+	return int8(_val_), err
+}
+
+/**
+ * 读取一个Short值（int16）
+ */
+func (b *Buffer) TryReadShort() (v int16, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadShort", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 2, b.Order())
+	return int16(_val_), err
+}
+
+/**
+ * 读取一个int值（int32）
+ */
+func (b *Buffer) TryReadInt() (v int32, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadInt", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 4, b.Order())
+	return int32(_val_), err
+}
+
+/**
+ * 读取一个long值（int64）
+ */
+func (b *Buffer) TryReadLong() (v int64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadLong", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 8, b.Order())
+	return int64(_val_), err
+}
+
+/**
+ * 读取一个float值（float64）
+ */
+func (b *Buffer) TryReadFloat() (v float64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadFloat", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 8, b.Order())
+	return math.Float64frombits(_val_), err
+}
+
+/**
+ * 读取一个长度值
+ * 长度值始终以大端编码，不受编码模式影响
+ * @return 长度值，错误信息（ErrUnderflow、ErrBadLength）
+ */
+func (b *Buffer) TryReadLength() (v int, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadLength", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	b.live()
+	if b.offset >= b.top {
+		return -1, ErrUnderflow
+	}
+	var n uint8 = *b.at(b.offset) & 0xff
 	if n >= 0x80 {
-		b.offset++
-		return int(n - 0x80)
+		b.next(1)
+		return int(n - 0x80), nil
 
 	} else if n >= 0x40 {
-		return int(b.ReadShort() - 0x4000)
+		v, err := readValue(b, 2, binary.BigEndian)
+		if err != nil {
+			return -1, err
+		}
+		return int(v - 0x4000), nil
 
 	} else if n >= 0x20 {
-		return int(b.ReadInt() - 0x20000000)
+		v, err := readValue(b, 4, binary.BigEndian)
+		if err != nil {
+			return -1, err
+		}
+		return int(v - 0x20000000), nil
 	}
-	fmt.Println("[ERR]: ReadLength 错误.")
-
-	return -1
+	return -1, ErrBadLength
 }
 
 /**
  * 读取一个utf8字符串
+ * @return 字符串，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrBadString）
  */
-func (b *__buffer__) ReadUTF8String() string {
-	_len := b.ReadLength() - 1
-	if _len < 0 {
-		return ""
+func (b *Buffer) TryReadUTF8String() (v string, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUTF8String", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	l, err := b.TryReadLength()
+	if err != nil {
+		return "", err
+	}
+	_len := l - 1
+	if _len <= 0 {
+		return "", nil
 	}
 
-	if _len == 0 {
-		return ""
+	if err := b.CheckLimit(LimitString, _len); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	if _len > b.Remaining() {
+		b.offset, b.rbits = _start, _bits
+		return "", ErrUnderflow
 	}
 
-	if _len > __maxlength__ {
-		fmt.Println("[ERR]: 读取错误.")
-		return ""
+	data, _ := b.next(_len)
+	if utf8.Valid(data) {
+		return string(data), nil
 	}
 
-	var (
-		i   int
-		c   int
-		cc  int
-		ccc int
-	)
-
-	_news := ""
-	_pos := b.offset
-	_end := _pos + _len
-	for _pos < _end {
-		c = int(b.byt[_pos] & 0xff)
-		i = c >> 4
-		if i < 8 {
-			// 0xxx xxxx
-			_pos++
-			_news += string(c)
-
-		} else if i == 12 || i == 13 {
-			// 110x xxxx 10xx xxxx
-			_pos += 2
-			if _pos > _end {
-				break
-			}
-			cc = int(b.byt[_pos-1])
-			if (cc & 0xC0) != 0x80 {
-				break
-			}
-			_news += string((((c & 0x1f) << 6) | (cc & 0x3f)))
-
-		} else if i == 14 {
-			// 1110 xxxx 10xx xxxx 10xx
-			// xxxx
-			_pos += 3
-			if _pos > _end {
-				break
-			}
-			cc = int(b.byt[_pos-2])
-			ccc = int(b.byt[_pos-1])
-			if ((cc & 0xC0) != 0x80) || ((ccc & 0xC0) != 0x80) {
-				break
-			}
-			_news += string((((c & 0x0f) << 12) | ((cc & 0x3f) << 6) | (ccc & 0x3f)))
-
-		} else {
-			// 10xx xxxx 1111 xxxx
-			break
-		}
+	//兼容旧版本写入的内容（NUL写为0xC0 0x80，以及代理对形式的补充平面字符）
+	_news, err := decodeModifiedUTF8(data)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
 	}
-	b.offset += _len
-	return _news
+	return _news, nil
 }
 
 /**
  * 读取一个字节数组
+ * @return 字节数组，错误信息（ErrUnderflow、ErrBadLength、*LimitError）
  */
-func (b *__buffer__) ReadData() []byte {
-	_len := b.ReadLength() - 1
+func (b *Buffer) TryReadData() (v []byte, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadData", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	l, err := b.TryReadLength()
+	if err != nil {
+		return nil, err
+	}
+	_len := l - 1
 	if _len < 0 {
-		fmt.Println("[ERR]: ReadData发生错误. len < 0.")
-		return nil
+		b.offset, b.rbits = _start, _bits
+		return nil, ErrBadLength
 	}
-	if _len > __maxlength__ {
-		fmt.Println("[ERR]: ReadData发生错误. len = " + strconv.Itoa(_len))
-		return nil
+	if err := b.CheckLimit(LimitData, _len); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	if _len > b.Remaining() {
+		b.offset, b.rbits = _start, _bits
+		return nil, ErrUnderflow
 	}
 
 	_b := make([]byte, _len)
-	b.Read(_b, 0, _len)
+	b.TryReadBytes(_b, 0, _len)
 
-	return _b
+	return _b, nil
 }
 
 ////////////////////////////////////////////////////
@@ -467,108 +764,122 @@ func (b *__buffer__) ReadData() []byte {
 ////////////////////////////////////////////////////
 
 /**
- * 写入
+ * 写入字节
  * @param data 要被写入的[]byte字节数组
  * @param pos 源位置
  * @param l 源长度
  */
-func (b *__buffer__) Write(data []byte, pos int, l int) {
-	_l := b.top + l
-	if len(b.byt) < _l {
-		b.SetCapacity(_l)
+func (b *Buffer) WriteBytes(data []byte, pos int, l int) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteBytes", true), nil, nil)
 	}
 	//拷贝
-	copy(b.byt[b.top:], data[pos:l])
-	b.top += l
+	copy(b.grow(l), data[pos:pos+l])
 }
 
 /**
  * 写一个Boolean值
  * @param val 布尔值
  */
-func (b *__buffer__) WriteBoolean(val bool) {
-	if len(b.byt) < b.top+1 {
-		b.SetCapacity(b.top + __capacity__)
+func (b *Buffer) WriteBoolean(val bool) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteBoolean", true), val, nil)
 	}
-
 	_val_ := 0
 	if val {
 		_val_ = 1
 	}
 
-	b.byt[b.top] = byte(_val_)
-	b.top++
+	b.grow(1)[0] = byte(_val_)
 }
 
 /**
  * 写一个无符号的Byte值（uint8）
  * @param val byte值
  */
-func (b *__buffer__) WriteUnsignedByt(val byte) {
-	if len(b.byt) < b.top+1 {
-		b.SetCapacity(b.top + __capacity__)
+func (b *Buffer) WriteUnsignedByt(val byte) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUnsignedByt", true), val, nil)
 	}
-	b.byt[b.top] = val
-	b.top++
+	b.grow(1)[0] = val
 }
 
 /**
  * 写一个byte值（int8）
  * @param val 值
  */
-func (b *__buffer__) WriteByt(val int8) {
-	writeValue(b, 1, val)
+func (b *Buffer) WriteByt(val int8) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteByt", true), val, nil)
+	}
+	writeValue(b, 1, b.Order(), uint64(val))
 }
 
 /**
  * 写一个short值（int16）
  * @param val short值
  */
-func (b *__buffer__) WriteShort(val int16) {
-	writeValue(b, 2, val)
+func (b *Buffer) WriteShort(val int16) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteShort", true), val, nil)
+	}
+	writeValue(b, 2, b.Order(), uint64(val))
 }
 
 /**
  * 写一个int值（int32）
  * @param int32类型的值
  */
-func (b *__buffer__) WriteInt(val int32) {
-	writeValue(b, 4, val)
+func (b *Buffer) WriteInt(val int32) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteInt", true), val, nil)
+	}
+	writeValue(b, 4, b.Order(), uint64(val))
 }
 
 /**
 * 写一个long值（int64）
 * @param val long值
  */
-func (b *__buffer__) WriteLong(val int64) {
-	writeValue(b, 8, val)
+func (b *Buffer) WriteLong(val int64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteLong", true), val, nil)
+	}
+	writeValue(b, 8, b.Order(), uint64(val))
 }
 
 /**
  * 写一个float值（float64）
  * @param val 浮点数
  */
-func (b *__buffer__) WriteFloat(val float64) {
-	writeValue(b, 8, val)
+func (b *Buffer) WriteFloat(val float64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteFloat", true), val, nil)
+	}
+	writeValue(b, 8, b.Order(), math.Float64bits(val))
 }
 
 /**
  * 写一个长度值
+ * 长度值始终以大端编码，不受编码模式影响（首字节用于区分长度值所占的字节数）
  * @param val 长度值
  */
-func (b *__buffer__) WriteLength(val int) {
+func (b *Buffer) WriteLength(val int) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteLength", true), val, nil)
+	}
 	if val >= 0x20000000 || val < 0 {
 		fmt.Println("[ERR]: WriteLength 长度错误.")
 		return
 	}
 	if val >= 0x4000 { //0100.0000.0000.0000 16位int值
-		b.WriteInt(int32(val + 0x20000000)) //0010.0000.0000.0000.0000.0000.0000.0000 32位int值
+		writeValue(b, 4, binary.BigEndian, uint64(val+0x20000000)) //0010.0000.0000.0000.0000.0000.0000.0000 32位int值
 
 	} else if val >= 0x80 { //1000.0000 //8位int值
-		b.WriteShort(int16(val + 0x4000))
+		writeValue(b, 2, binary.BigEndian, uint64(val+0x4000))
 
 	} else {
-		b.WriteByt(int8(val + 0x80))
+		b.WriteUnsignedByt(byte(val + 0x80))
 	}
 }
 
@@ -576,89 +887,123 @@ func (b *__buffer__) WriteLength(val int) {
  * 写一个utf8字符串
  * @param s 字符串值
  */
-func (b *__buffer__) WriteUTF8String(s string) {
-	// fmt.Println(s, len(s))
+func (b *Buffer) WriteUTF8String(s string) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUTF8String", true), s, nil)
+	}
+	//非法的utf8字节序列替换为U+FFFD，保证写入的内容始终是合法的utf8
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, string(utf8.RuneError))
+	}
 
 	//写入字符串长度
 	_len := len(s)
 	b.WriteLength(_len + 1)
 
-	//根据长度及当前偏移位置进行扩容判断
-	pos := b.top
-	if len(b.byt) < pos+_len {
-		b.SetCapacity(pos + _len)
-	}
-
-	//写入字符
-	for _, c := range s {
-		if (c >= 0x0001) && (c <= 0x007f) {
-			b.byt[pos] = byte(c)
-			pos++
-
-		} else if c > 0x07ff {
-			b.byt[pos] = byte(0xe0 | ((c >> 12) & 0x0f))
-			pos++
-			b.byt[pos] = byte(0x80 | ((c >> 6) & 0x3f))
-			pos++
-			b.byt[pos] = byte(0x80 | (c & 0x3f))
-			pos++
-
-		} else {
-			b.byt[pos] = byte(0xc0 | ((c >> 6) & 0x1f))
-			pos++
-			b.byt[pos] = byte(0x80 | (c & 0x3f))
-			pos++
-		}
-	}
-	b.top += _len
+	//写入字符（go字符串本身即为utf8编码）
+	copy(b.grow(_len), s)
 }
 
 /**
  * 写一个字节数组
  * @param bt 字节数组
  */
-func (b *__buffer__) WriteData(bt []byte) {
+func (b *Buffer) WriteData(bt []byte) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteData", true), bt, nil)
+	}
 	_len := len(bt)
 	b.WriteLength(_len + 1)
-	b.Write(bt, 0, _len)
+	b.WriteBytes(bt, 0, _len)
 }
 
-////////////////////////////////////////////////////////////////////////
-//内部函数
-
-func getStringByteLength(s string) int {
-	var _len_ int = 0
-	for _, c := range s {
-		if (c >= 0x0001) && (c <= 0x007f) {
-			_len_++
-		} else if c > 0x07ff {
-			_len_ += 3
-		} else {
-			_len_ += 2
-		}
+/**
+ * 以字节数组的形式写一个字符串（与WriteData([]byte(s))写入的字节相同，不复制字符串）
+ * @param s 字符串值
+ */
+func (b *Buffer) WriteDataString(s string) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteData", true), s, nil)
 	}
-	return _len_
+	_len := len(s)
+	b.WriteLength(_len + 1)
+	copy(b.grow(_len), s)
 }
 
-func writeValue(b *__buffer__, n int, val interface{}) {
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//以指定的编码模式写入n个字节（n为1、2、4、8）的整数值
+func writeValue(b *Buffer, n int, order binary.ByteOrder, val uint64) {
+	_b_ := b.grow(n)
+	switch n {
+	case 1:
+		_b_[0] = byte(val)
+	case 2:
+		order.PutUint16(_b_, uint16(val))
+	case 4:
+		order.PutUint32(_b_, uint32(val))
+	case 8:
+		order.PutUint64(_b_, val)
+	}
+}
+
+//以指定的编码模式读取n个字节（n为1、2、4、8）的整数值
+func readValue(b *Buffer, n int, order binary.ByteOrder) (uint64, error) {
+	_b_, err := b.next(n)
+	if err != nil {
+		return 0, err
+	}
+	switch n {
+	case 1:
+		return uint64(_b_[0]), nil
+	case 2:
+		return uint64(order.Uint16(_b_)), nil
+	case 4:
+		return uint64(order.Uint32(_b_)), nil
+	}
+	return order.Uint64(_b_), nil
+}
+
+//在尾部预留n个字节的写入空间（容量不足时按倍数扩容）并移动top值，返回预留的区域
+//分块模式下跨越块边界时返回临时区，调用者须在下一次访问对象之前写入返回的区域
+func (b *Buffer) grow(n int) []byte {
 	_pos_ := b.top
+	if b.chunks != nil {
+		b.top += n
+		b.wbits = 0
+		return b.chunks.grow(_pos_, n)
+	}
 	if len(b.byt) < _pos_+n {
-		b.SetCapacity(_pos_ + __capacity__)
+		b.SetCapacity(_pos_ + n)
 	}
-	_b_ := bytes.NewBuffer([]byte{})
-	binary.Write(_b_, getEndian(), val)
-	_bb_ := _b_.Bytes()
-	copy(b.byt[_pos_:], _bb_[0:])
 	b.top += n
+	b.wbits = 0
+	return b.byt[_pos_:b.top]
 }
 
-func readValue(b *__buffer__, blen int) *bytes.Buffer {
+//取出接下来的n个可读字节并移动偏移位置，剩余内容不足时偏移位置保持不变
+//分块模式下跨越块边界时返回拼接后的副本
+func (b *Buffer) next(n int) ([]byte, error) {
+	b.live()
+	if n < 0 {
+		return nil, ErrBadLength
+	}
+	if b.offset+n > b.top {
+		return nil, ErrUnderflow
+	}
 	_pos_ := b.offset
+	b.offset += n
+	b.rbits = 0
+	if b.chunks != nil {
+		return b.chunks.span(_pos_, b.offset), nil
+	}
+	return b.byt[_pos_:b.offset], nil
+}
 
-	bt := make([]byte, blen)
-	copy(bt[0:], b.byt[_pos_:_pos_+blen])
-
-	b.offset += blen
-
-	return bytes.NewBuffer(bt)
+//记录首个读取错误（粘滞错误）
+func (b *Buffer) fail(err error) {
+	if err != nil && b.err == nil {
+		b.err = err
+	}
 }
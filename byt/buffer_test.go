@@ -0,0 +1,202 @@
+package byt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+//定长数值的读写不分配内存
+func TestFixedWidthAllocs(t *testing.T) {
+	b := NewBufferWithLen(64)
+	writes := []struct {
+		name string
+		fn   func()
+	}{
+		{"WriteShort", func() { b.WriteShort(-2) }},
+		{"WriteInt", func() { b.WriteInt(-123456) }},
+		{"WriteLong", func() { b.WriteLong(-1234567890123) }},
+		{"WriteFloat", func() { b.WriteFloat(3.25) }},
+		{"WriteFloat32", func() { b.WriteFloat32(-1.5) }},
+	}
+	for _, w := range writes {
+		if n := testing.AllocsPerRun(100, func() { b.Zero(); w.fn() }); n != 0 {
+			t.Errorf("%s allocs = %v; want 0", w.name, n)
+		}
+	}
+
+	b.Zero()
+	b.WriteShort(-2)
+	b.WriteInt(-123456)
+	b.WriteLong(-1234567890123)
+	b.WriteFloat(3.25)
+	b.WriteFloat32(-1.5)
+	var (
+		s int16
+		i int32
+		l int64
+		f float64
+		g float32
+	)
+	if n := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		s, i, l, f, g = b.ReadShort(), b.ReadInt(), b.ReadLong(), b.ReadFloat(), b.ReadFloat32()
+	}); n != 0 {
+		t.Errorf("Read allocs = %v; want 0", n)
+	}
+	if s != -2 || i != -123456 || l != -1234567890123 || f != 3.25 || g != -1.5 || b.Err() != nil {
+		t.Errorf("read %v %v %v %v %v, %v", s, i, l, f, g, b.Err())
+	}
+}
+
+func BenchmarkWriteInt(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	bm.ReportAllocs()
+	for n := 0; n < bm.N; n++ {
+		if b.GetTop() >= 4096 {
+			b.Zero()
+		}
+		b.WriteInt(int32(n))
+	}
+}
+
+func BenchmarkWriteLong(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	bm.ReportAllocs()
+	for n := 0; n < bm.N; n++ {
+		if b.GetTop() >= 4096 {
+			b.Zero()
+		}
+		b.WriteLong(int64(n))
+	}
+}
+
+func BenchmarkWriteFloat(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	bm.ReportAllocs()
+	for n := 0; n < bm.N; n++ {
+		if b.GetTop() >= 4096 {
+			b.Zero()
+		}
+		b.WriteFloat(float64(n))
+	}
+}
+
+func BenchmarkReadInt(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	for b.GetTop() < 4096 {
+		b.WriteInt(1)
+	}
+	bm.ReportAllocs()
+	bm.ResetTimer()
+	for n := 0; n < bm.N; n++ {
+		if b.Remaining() < 4 {
+			b.Reset()
+		}
+		b.ReadInt()
+	}
+}
+
+func BenchmarkReadLong(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	for b.GetTop() < 4096 {
+		b.WriteLong(1)
+	}
+	bm.ReportAllocs()
+	bm.ResetTimer()
+	for n := 0; n < bm.N; n++ {
+		if b.Remaining() < 8 {
+			b.Reset()
+		}
+		b.ReadLong()
+	}
+}
+
+func BenchmarkReadFloat(bm *testing.B) {
+	b := NewBufferWithLen(4096)
+	for b.GetTop() < 4096 {
+		b.WriteFloat(1)
+	}
+	bm.ReportAllocs()
+	bm.ResetTimer()
+	for n := 0; n < bm.N; n++ {
+		if b.Remaining() < 8 {
+			b.Reset()
+		}
+		b.ReadFloat()
+	}
+}
+
+//不同编码模式的对象各自独立编码，SetEndian只影响之后创建的对象（以-race运行）
+func TestOrderIndependent(t *testing.T) {
+	defer SetDefaultOrder(getEndian())
+	SetEndian("big_endian")
+	big := NewBuffer()
+	lit := NewBufferWithOrder(binary.LittleEndian)
+
+	var wg, _toggle sync.WaitGroup
+	_stop := make(chan struct{})
+	_toggle.Add(1)
+	go func() { //同时修改默认编码模式
+		defer _toggle.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-_stop:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				SetEndian("lit_endian")
+			} else {
+				SetEndian("big_endian")
+			}
+		}
+	}()
+	_errs := make(chan string, 2)
+	for _, c := range []struct {
+		b     *Buffer
+		order binary.ByteOrder
+	}{{big, binary.BigEndian}, {lit, binary.LittleEndian}} {
+		wg.Add(1)
+		go func(b *Buffer, order binary.ByteOrder) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				b.Zero()
+				b.WriteInt(0x01020304)
+				b.WriteUnsignedShort(0x0506)
+				_o := order.(binary.AppendByteOrder)
+				want := _o.AppendUint16(_o.AppendUint32(nil, 0x01020304), 0x0506)
+				if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+					_errs <- fmt.Sprintf("%v: wrote % x; want % x", order, got, want)
+					return
+				}
+				if b.ReadInt() != 0x01020304 || b.ReadUnsignedShort() != 0x0506 {
+					_errs <- order.String() + ": read back"
+					return
+				}
+			}
+		}(c.b, c.order)
+	}
+	wg.Wait() //读写结束后停止修改
+	close(_stop)
+	_toggle.Wait()
+	close(_errs)
+	for e := range _errs {
+		t.Error(e)
+	}
+
+	//SetEndian只改变之后创建的对象的默认编码模式
+	SetEndian("lit_endian")
+	if NewBuffer().Order() != binary.LittleEndian || NewBufferWithByte([]byte{}).Order() != binary.LittleEndian {
+		t.Error("new buffer does not use the new default order")
+	}
+	if big.Order() != binary.BigEndian || lit.Order() != binary.LittleEndian {
+		t.Errorf("existing buffers changed: %v, %v", big.Order(), lit.Order())
+	}
+	SetEndian("unknown") //未知的名称不改变默认编码模式
+	if getEndian() != binary.LittleEndian {
+		t.Errorf("default order = %v after unknown name", getEndian())
+	}
+}
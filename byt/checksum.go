@@ -0,0 +1,274 @@
+/********************************************************/
+// 字节对象（校验和尾部）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			err:=buf.Seal(byt.CRC32)		//对全部内容追加CRC32尾部
+//			err=buf.Verify(byt.CRC32)		//校验并去掉尾部
+//
+//			start:=buf.BeginChecksum()	//只校验其中一段内容
+//			buf.WriteUTF8String("HelloWorld!")
+//			buf.EndChecksum(start,byt.XXHash64)
+//			...
+//			start=buf.BeginVerify()
+//			s:=buf.ReadUTF8String()
+//			err=buf.EndVerify(start,byt.XXHash64)
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/binary"
+	"hash/adler32"
+	"hash/crc32"
+	"math/bits"
+	"strconv"
+)
+
+/**
+ * 校验和算法
+ */
+type Checksum int
+
+const (
+	CRC32    Checksum = iota //CRC-32（IEEE），4字节
+	CRC32C                   //CRC-32C（Castagnoli），4字节
+	Adler32                  //Adler-32，4字节
+	XXHash64                 //xxHash64（seed为0），8字节
+)
+
+var __castagnoli__ = crc32.MakeTable(crc32.Castagnoli)
+
+/**
+ * 校验和尾部的字节数
+ */
+func (c Checksum) Size() int {
+	if c == XXHash64 {
+		return 8
+	}
+	return 4
+}
+
+/**
+ * 计算校验和
+ * @param p 数据
+ * @return 校验和，错误信息（ErrBadAlgorithm）
+ */
+func (c Checksum) Sum(p []byte) (uint64, error) {
+	switch c {
+	case CRC32:
+		return uint64(crc32.ChecksumIEEE(p)), nil
+	case CRC32C:
+		return uint64(crc32.Checksum(p, __castagnoli__)), nil
+	case Adler32:
+		return uint64(adler32.Checksum(p)), nil
+	case XXHash64:
+		return xxhash64(p), nil
+	}
+	return 0, ErrBadAlgorithm
+}
+
+/**
+ * 算法名称
+ */
+func (c Checksum) String() string {
+	switch c {
+	case CRC32:
+		return "crc32"
+	case CRC32C:
+		return "crc32c"
+	case Adler32:
+		return "adler32"
+	case XXHash64:
+		return "xxhash64"
+	}
+	return "Checksum(" + strconv.Itoa(int(c)) + ")"
+}
+
+//是否为已知的算法
+func (c Checksum) valid() bool {
+	return c >= CRC32 && c <= XXHash64
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 对已写入的全部内容（0至top）追加校验和尾部
+ * @param algo 校验和算法
+ * @return 错误信息（ErrBadAlgorithm），出错时不写入任何内容
+ */
+func (b *Buffer) Seal(algo Checksum) error {
+	return b.EndChecksum(0, algo)
+}
+
+/**
+ * 开始一段需要校验的内容
+ * @return 起始位置（传给EndChecksum）
+ */
+func (b *Buffer) BeginChecksum() int {
+	return b.top
+}
+
+/**
+ * 对start至top之间的内容追加校验和尾部（按字节缓冲对象的编码模式写入）
+ * @param start BeginChecksum返回的起始位置
+ * @param algo 校验和算法
+ * @return 错误信息（ErrBadLength、ErrBadAlgorithm），出错时不写入任何内容
+ */
+func (b *Buffer) EndChecksum(start int, algo Checksum) error {
+	b.live()
+	if start < 0 || start > b.top {
+		return ErrBadLength
+	}
+	_sum, err := algo.Sum(b.span(start, b.top))
+	if err != nil {
+		return err
+	}
+	writeValue(b, algo.Size(), b.Order(), _sum)
+	return nil
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 校验全部内容（0至top）的校验和尾部，校验通过后去掉尾部（top值减去尾部长度）
+ * @param algo 校验和算法
+ * @return 错误信息（ErrBadAlgorithm、ErrUnderflow、ErrChecksum）
+ */
+func (b *Buffer) Verify(algo Checksum) error {
+	b.live()
+	if !algo.valid() {
+		return ErrBadAlgorithm
+	}
+	_end := b.top - algo.Size()
+	if _end < b.offset {
+		return ErrUnderflow
+	}
+	_want := getValue(b.span(_end, b.top), b.Order())
+	if _sum, _ := algo.Sum(b.span(0, _end)); _sum != _want {
+		return ErrChecksum
+	}
+	b.top = _end
+	return nil
+}
+
+/**
+ * 开始一段需要校验的内容
+ * @return 起始位置（传给EndVerify）
+ */
+func (b *Buffer) BeginVerify() int {
+	return b.offset
+}
+
+/**
+ * 读取校验和尾部并校验start至当前偏移位置之间的内容
+ * 校验失败时偏移位置不变
+ * @param start BeginVerify返回的起始位置
+ * @param algo 校验和算法
+ * @return 错误信息（ErrBadLength、ErrBadAlgorithm、ErrUnderflow、ErrChecksum）
+ */
+func (b *Buffer) EndVerify(start int, algo Checksum) error {
+	b.live()
+	if start < 0 || start > b.offset {
+		return ErrBadLength
+	}
+	if !algo.valid() {
+		return ErrBadAlgorithm
+	}
+	_end, _bits := b.offset, b.rbits
+	_want, err := readValue(b, algo.Size(), b.Order())
+	if err != nil {
+		return err
+	}
+	if _sum, _ := algo.Sum(b.span(start, _end)); _sum != _want {
+		b.offset, b.rbits = _end, _bits
+		return ErrChecksum
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//按编码模式解码4或8字节的值
+func getValue(p []byte, order binary.ByteOrder) uint64 {
+	if len(p) == 8 {
+		return order.Uint64(p)
+	}
+	return uint64(order.Uint32(p))
+}
+
+//xxHash64的素数（使用变量以允许按uint64回绕计算）
+var (
+	__xxprime1__ uint64 = 11400714785074694791
+	__xxprime2__ uint64 = 14029467366897019727
+	__xxprime3__ uint64 = 1609587929392839161
+	__xxprime4__ uint64 = 9650029242287828579
+	__xxprime5__ uint64 = 2870177450012600261
+)
+
+//xxHash64（seed为0）
+func xxhash64(p []byte) uint64 {
+	var h uint64
+	_n := len(p)
+
+	if _n >= 32 {
+		v1 := __xxprime1__ + __xxprime2__
+		v2 := __xxprime2__
+		v3 := uint64(0)
+		v4 := -__xxprime1__
+		for len(p) >= 32 {
+			v1 = xxround(v1, binary.LittleEndian.Uint64(p[0:8]))
+			v2 = xxround(v2, binary.LittleEndian.Uint64(p[8:16]))
+			v3 = xxround(v3, binary.LittleEndian.Uint64(p[16:24]))
+			v4 = xxround(v4, binary.LittleEndian.Uint64(p[24:32]))
+			p = p[32:]
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxmerge(h, v1)
+		h = xxmerge(h, v2)
+		h = xxmerge(h, v3)
+		h = xxmerge(h, v4)
+	} else {
+		h = __xxprime5__
+	}
+	h += uint64(_n)
+
+	for ; len(p) >= 8; p = p[8:] {
+		h ^= xxround(0, binary.LittleEndian.Uint64(p[:8]))
+		h = bits.RotateLeft64(h, 27)*__xxprime1__ + __xxprime4__
+	}
+	if len(p) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(p[:4])) * __xxprime1__
+		h = bits.RotateLeft64(h, 23)*__xxprime2__ + __xxprime3__
+		p = p[4:]
+	}
+	for _, c := range p {
+		h ^= uint64(c) * __xxprime5__
+		h = bits.RotateLeft64(h, 11) * __xxprime1__
+	}
+
+	h ^= h >> 33
+	h *= __xxprime2__
+	h ^= h >> 29
+	h *= __xxprime3__
+	h ^= h >> 32
+	return h
+}
+
+func xxround(acc, input uint64) uint64 {
+	acc += input * __xxprime2__
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * __xxprime1__
+}
+
+func xxmerge(acc, val uint64) uint64 {
+	val = xxround(0, val)
+	acc ^= val
+	return acc*__xxprime1__ + __xxprime4__
+}
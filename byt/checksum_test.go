@@ -0,0 +1,90 @@
+package byt
+
+import (
+	"testing"
+)
+
+func TestChecksumVectors(t *testing.T) {
+	cases := []struct {
+		algo Checksum
+		in   string
+		want uint64
+	}{
+		{XXHash64, "", 0xEF46DB3751D8E999},
+		{XXHash64, "abc", 0x44BC2CF5AD770999},
+		{CRC32, "123456789", 0xCBF43926},
+		{CRC32C, "123456789", 0xE3069283},
+		{Adler32, "Wikipedia", 0x11E60398},
+	}
+	for _, c := range cases {
+		if got, err := c.algo.Sum([]byte(c.in)); err != nil || got != c.want {
+			t.Errorf("%v(%q) = %#x, %v; want %#x", c.algo, c.in, got, err, c.want)
+		}
+	}
+	if _, err := Checksum(9).Sum(nil); err != ErrBadAlgorithm {
+		t.Errorf("unknown algorithm err = %v; want ErrBadAlgorithm", err)
+	}
+}
+
+func TestSealVerify(t *testing.T) {
+	for _, algo := range []Checksum{CRC32, CRC32C, Adler32, XXHash64} {
+		b := NewBuffer()
+		b.WriteUTF8String("HelloWorld!")
+		b.WriteLong(42)
+		_top := b.GetTop()
+		if err := b.Seal(algo); err != nil {
+			t.Fatalf("%v: Seal: %v", algo, err)
+		}
+		if b.GetTop() != _top+algo.Size() {
+			t.Errorf("%v: trailer is %d bytes", algo, b.GetTop()-_top)
+		}
+		_bad := NewBufferWithByte(append([]byte(nil), b.GetByte()[:b.GetTop()]...))
+		_bad.GetByte()[1] ^= 0xff
+		if err := _bad.Verify(algo); err != ErrChecksum {
+			t.Errorf("%v: Verify(tampered) = %v; want ErrChecksum", algo, err)
+		}
+		if err := b.Verify(algo); err != nil || b.GetTop() != _top {
+			t.Errorf("%v: Verify = %v, top %d", algo, err, b.GetTop())
+		}
+	}
+}
+
+func TestChecksumRegion(t *testing.T) {
+	b := NewBuffer()
+	b.WriteShort(7)
+	start := b.BeginChecksum()
+	b.WriteUTF8String("HelloWorld!")
+	if err := b.EndChecksum(start, XXHash64); err != nil {
+		t.Fatal(err)
+	}
+	b.ReadShort()
+	vs := b.BeginVerify()
+	if s := b.ReadUTF8String(); s != "HelloWorld!" {
+		t.Fatalf("ReadUTF8String = %q", s)
+	}
+	if err := b.EndVerify(vs, XXHash64); err != nil || b.Remaining() != 0 {
+		t.Errorf("EndVerify = %v, remaining %d", err, b.Remaining())
+	}
+}
+
+//未知的算法返回错误而不是panic，且不写入任何内容
+func TestChecksumUnknownAlgorithm(t *testing.T) {
+	const bad = Checksum(99)
+	b := NewBuffer()
+	b.WriteInt(1)
+	if err := b.Seal(bad); err != ErrBadAlgorithm || b.GetTop() != 4 {
+		t.Errorf("Seal = %v, top %d", err, b.GetTop())
+	}
+	if err := b.EndChecksum(b.BeginChecksum(), bad); err != ErrBadAlgorithm || b.GetTop() != 4 {
+		t.Errorf("EndChecksum = %v, top %d", err, b.GetTop())
+	}
+	if err := b.EndChecksum(5, CRC32); err != ErrBadLength {
+		t.Errorf("EndChecksum(start > top) = %v; want ErrBadLength", err)
+	}
+	if err := b.Verify(bad); err != ErrBadAlgorithm || b.GetTop() != 4 {
+		t.Errorf("Verify = %v, top %d", err, b.GetTop())
+	}
+	if err := b.EndVerify(b.BeginVerify(), bad); err != ErrBadAlgorithm || b.GetOffset() != 0 {
+		t.Errorf("EndVerify = %v, offset %d", err, b.GetOffset())
+	}
+}
@@ -0,0 +1,209 @@
+/********************************************************/
+// 字节对象（分块模式）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.NewChunkedBuffer(64*1024)		//按64KB分块，扩容时不拷贝已写入的内容
+//			for _,e:=range entities {
+//				buf.WriteObject(e)
+//			}
+//			bufs:=buf.Buffers()					//各块的内容（net.Buffers）
+//			bufs.WriteTo(conn)					//通过一次writev写入
+//
+//			fw.WriteFrame(buf)					//FrameWriter同样按块写入
+/********************************************************/
+
+package byt
+
+import (
+	"net"
+)
+
+/**
+ * 分块模式默认的块长度（64KB）
+ */
+const __chunksize__ int = 64 * 1024
+
+//分块模式下内容保存在长度相同的若干块中，位置p位于第p/size块的p%size处。
+//扩容时只追加新的块，已写入的内容不会被拷贝；读写的API与普通模式相同，
+//跨越块边界的读取返回拼接后的副本，跨越块边界的写入先写入临时区，在下一次访问对象时写回。
+
+/**
+ * 创建一个分块模式的字节缓冲对象
+ * 适合构建较大的内容（如快照），扩容时按块追加而不拷贝已写入的内容
+ * 注意：GetByte返回内容的副本；Slice跨越块边界时子对象不与原对象共享内容
+ * @param chunkSize 块长度（<=0时使用默认值64KB）
+ * @return 字节缓冲对象
+ */
+func NewChunkedBuffer(chunkSize int) *Buffer {
+	if chunkSize <= 0 {
+		chunkSize = __chunksize__
+	}
+	return &Buffer{
+		chunks: &chunked{size: chunkSize},
+		order:  getEndian(),
+	}
+}
+
+/**
+ * 是否为分块模式（参见NewChunkedBuffer）
+ */
+func (b *Buffer) IsChunked() bool {
+	return b.chunks != nil
+}
+
+/**
+ * 获取全部内容（0至top），与字节缓冲对象共享内存
+ * 分块模式下每块一项，普通模式下只有一项；可以直接用于writev（bufs.WriteTo(conn)）
+ * @return 内容
+ */
+func (b *Buffer) Buffers() net.Buffers {
+	b.live()
+	return b.buffers(0, b.top)
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+type chunked struct {
+	size    int      //块长度
+	list    [][]byte //各块（长度均为size）
+	pend    []byte   //跨越块边界的写入的临时区
+	pendAt  int      //临时区对应的位置
+	scratch []byte   //临时区使用的字节数组
+}
+
+//当前容量
+func (c *chunked) capacity() int {
+	return len(c.list) * c.size
+}
+
+//追加新的块直至容量不小于capa
+func (c *chunked) reserve(capa int) {
+	for c.capacity() < capa {
+		c.list = append(c.list, make([]byte, c.size))
+	}
+}
+
+//将临时区的内容写回
+func (c *chunked) flush() {
+	if c.pend != nil {
+		_p := c.pend
+		c.pend = nil
+		c.put(c.pendAt, _p)
+	}
+}
+
+//从位置pos开始写入p（容量必须足够）
+func (c *chunked) put(pos int, p []byte) {
+	for len(p) > 0 {
+		n := copy(c.list[pos/c.size][pos%c.size:], p)
+		pos += n
+		p = p[n:]
+	}
+}
+
+//写入时获取n个字节（位于同一块中时直接返回块的一部分，否则返回临时区）
+func (c *chunked) grow(pos, n int) []byte {
+	c.flush()
+	c.reserve(pos + n)
+	i, o := pos/c.size, pos%c.size
+	if o+n <= c.size {
+		return c.list[i][o : o+n]
+	}
+	if cap(c.scratch) < n {
+		c.scratch = make([]byte, n)
+	}
+	c.pend, c.pendAt = c.scratch[:n], pos
+	return c.pend
+}
+
+//获取start至end之间的内容（位于同一块中时直接返回块的一部分，否则返回拼接后的副本）
+func (c *chunked) span(start, end int) []byte {
+	c.flush()
+	if start == end {
+		return []byte{}
+	}
+	i, o := start/c.size, start%c.size
+	if o+end-start <= c.size {
+		return c.list[i][o : o+end-start]
+	}
+	_b := make([]byte, 0, end-start)
+	for start < end {
+		i, o = start/c.size, start%c.size
+		_n := min(c.size-o, end-start)
+		_b = append(_b, c.list[i][o:o+_n]...)
+		start += _n
+	}
+	return _b
+}
+
+//复制（共享已有的块，之后追加的块各自独立）
+func (c *chunked) clone() *chunked {
+	c.flush()
+	return &chunked{size: c.size, list: append([][]byte(nil), c.list...)}
+}
+
+//字节缓冲对象的容量
+func (b *Buffer) capacity() int {
+	if b.chunks != nil {
+		return b.chunks.capacity()
+	}
+	return len(b.byt)
+}
+
+//位置pos处的字节
+func (b *Buffer) at(pos int) *byte {
+	if c := b.chunks; c != nil {
+		c.flush()
+		return &c.list[pos/c.size][pos%c.size]
+	}
+	return &b.byt[pos]
+}
+
+//start至end之间的内容（分块模式下跨越块边界时为副本，只能用于读取）
+func (b *Buffer) span(start, end int) []byte {
+	if b.chunks != nil {
+		return b.chunks.span(start, end)
+	}
+	return b.byt[start:end]
+}
+
+//从位置pos开始写入p（不改变top值）
+func (b *Buffer) put(pos int, p []byte) {
+	if c := b.chunks; c != nil {
+		c.flush()
+		c.put(pos, p)
+		return
+	}
+	copy(b.byt[pos:], p)
+}
+
+//从位置pos开始可以连续访问的字节数（至容量的末尾或所在块的末尾）
+func (b *Buffer) run(pos int) int {
+	if c := b.chunks; c != nil {
+		if pos >= c.capacity() {
+			return 0
+		}
+		return c.size - pos%c.size
+	}
+	return len(b.byt) - pos
+}
+
+//start至end之间的内容，按块分为多项（与字节缓冲对象共享内存）
+func (b *Buffer) buffers(start, end int) net.Buffers {
+	c := b.chunks
+	if c == nil {
+		return net.Buffers{b.byt[start:end]}
+	}
+	c.flush()
+	var bufs net.Buffers
+	for start < end {
+		i, o := start/c.size, start%c.size
+		_n := min(c.size-o, end-start)
+		bufs = append(bufs, c.list[i][o:o+_n])
+		start += _n
+	}
+	return bufs
+}
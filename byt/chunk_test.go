@@ -0,0 +1,239 @@
+package byt
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//固定的nonce来源（使加密的内容可以比较）
+func testNonce() NonceSource {
+	var _n byte
+	return func(nonce []byte) error {
+		_n++
+		for i := range nonce {
+			nonce[i] = _n
+		}
+		return nil
+	}
+}
+
+type chunkRecord struct {
+	Id   int32
+	Name string
+	Tags []string
+}
+
+//写入各种跨越块边界的内容（位、定长数值、变长整数、字符串、字节数组、字段、压缩及加密区域、校验值）
+func writeChunkScript(t *testing.T, b *Buffer) {
+	aead, err := NewAESGCM(make([]byte, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := testNonce()
+	_long := strings.Repeat("跨块", 20)
+	for round := 0; round < 3; round++ {
+		b.WriteBits(5, 3)
+		b.WriteSignedBits(-9, 11)
+		b.WriteBit(true)
+		b.WriteQuantized(0.25, -1, 1, 13)
+		b.WriteUnsignedByt(0xab)
+		b.WriteShort(-2)
+		b.WriteInt(-123456)
+		b.WriteLong(-1234567890123)
+		b.WriteFloat(3.25)
+		b.WriteUint128(Uint128{Hi: 1, Lo: 2})
+		b.WriteVarint(-1 << 40)
+		b.WriteUvarint(300)
+		b.WriteZigzag32(-77)
+		b.WriteUTF8String(_long)
+		b.WriteUTF("utf")
+		b.WriteString("中文", GBK)
+		b.WriteFixedString("固定", 9, GB18030)
+		b.WriteCString("cstr", ASCII)
+		b.WriteData(bytes.Repeat([]byte{byte(round), 0xee}, 11))
+		b.Write([]byte("io.Writer"))
+
+		//字段内容的长度在EndField时插入，内容整体后移
+		_start := b.BeginField(1)
+		b.WriteUTF8String(_long)
+		b.EndField(_start)
+		if err := b.WriteField(2, WireFixed64, func() { b.WriteLong(int64(round)) }); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.WriteField(3, WireVarint, func() { b.WriteVarint(1 << 50) }); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.WriteField(4, WireFixed32, func() { b.WriteShort(1) }); err != ErrBadWire {
+			t.Fatalf("WriteField err = %v", err)
+		}
+		b.WriteMessageEnd()
+
+		if err := b.WriteCompressed(func(inner *Buffer) {
+			inner.WriteUTF8String(strings.Repeat(_long, 3))
+			inner.WriteInt(int32(round))
+		}, Gzip); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.WriteSealed(aead, nonce, func(inner *Buffer) {
+			inner.WriteUTF8String(_long)
+			inner.WriteLong(int64(round))
+		}); err != nil {
+			t.Fatal(err)
+		}
+		b.WriteObject(&chunkRecord{Id: int32(round), Name: _long, Tags: []string{"a", "b"}})
+		b.WriteValue(map[string]interface{}{"k": []interface{}{int32(1), "v"}})
+
+		_cs := b.BeginChecksum()
+		b.WriteUTF8String(_long)
+		if err := b.EndChecksum(_cs, CRC32); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Seal(XXHash64); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//读取writeChunkScript写入的内容
+func readChunkScript(t *testing.T, name string, b *Buffer) {
+	aead, _ := NewAESGCM(make([]byte, 16))
+	fail := func(what string, got interface{}) {
+		t.Helper()
+		t.Fatalf("%s: %s = %v", name, what, got)
+	}
+	if err := b.Verify(XXHash64); err != nil {
+		fail("Verify", err)
+	}
+	_long := strings.Repeat("跨块", 20)
+	for round := 0; round < 3; round++ {
+		if v := b.ReadBits(3); v != 5 {
+			fail("ReadBits", v)
+		}
+		if v := b.ReadSignedBits(11); v != -9 {
+			fail("ReadSignedBits", v)
+		}
+		if !b.ReadBit() {
+			fail("ReadBit", false)
+		}
+		if v := b.ReadQuantized(-1, 1, 13); v < 0.2499 || v > 0.2501 {
+			fail("ReadQuantized", v)
+		}
+		if v := b.ReadUnsignedByt(); v != 0xab {
+			fail("ReadUnsignedByt", v)
+		}
+		if s, i, l, f := b.ReadShort(), b.ReadInt(), b.ReadLong(), b.ReadFloat(); s != -2 || i != -123456 || l != -1234567890123 || f != 3.25 {
+			fail("fixed", []interface{}{s, i, l, f})
+		}
+		if v := b.ReadUint128(); v != (Uint128{Hi: 1, Lo: 2}) {
+			fail("ReadUint128", v)
+		}
+		if v := b.ReadVarint(); v != -1<<40 {
+			fail("ReadVarint", v)
+		}
+		if v := b.ReadUvarint(); v != 300 {
+			fail("ReadUvarint", v)
+		}
+		if v := b.ReadZigzag32(); v != -77 {
+			fail("ReadZigzag32", v)
+		}
+		if v := b.ReadUTF8String(); v != _long {
+			fail("ReadUTF8String", v)
+		}
+		if v := b.ReadUTF(); v != "utf" {
+			fail("ReadUTF", v)
+		}
+		if v, err := b.TryReadString(GBK); v != "中文" || err != nil {
+			fail("TryReadString", v)
+		}
+		if v, err := b.TryReadFixedString(9, GB18030); v != "固定" || err != nil {
+			fail("TryReadFixedString", v)
+		}
+		if v, err := b.TryReadCString(ASCII); v != "cstr" || err != nil {
+			fail("TryReadCString", v)
+		}
+		if v := b.ReadData(); !bytes.Equal(v, bytes.Repeat([]byte{byte(round), 0xee}, 11)) {
+			fail("ReadData", v)
+		}
+		_io := make([]byte, 9)
+		if b.ReadBytes(_io, 0, 9); string(_io) != "io.Writer" {
+			fail("ReadBytes", _io)
+		}
+
+		var _tags []int
+		err := b.TryReadMessage(func(tag int, wire Wire, field *Buffer) error {
+			_tags = append(_tags, tag)
+			switch tag {
+			case 1:
+				if v := field.ReadUTF8String(); v != _long {
+					fail("field 1", v)
+				}
+			case 2:
+				if v := field.ReadLong(); v != int64(round) {
+					fail("field 2", v)
+				}
+			case 3:
+				if v := field.ReadVarint(); v != 1<<50 {
+					fail("field 3", v)
+				}
+			}
+			return nil
+		})
+		if err != nil || len(_tags) != 3 {
+			fail("TryReadMessage", []interface{}{err, _tags})
+		}
+
+		inner, err := b.TryReadCompressed()
+		if err != nil || inner.ReadUTF8String() != strings.Repeat(_long, 3) || inner.ReadInt() != int32(round) {
+			fail("TryReadCompressed", err)
+		}
+		inner, err = b.TryReadSealed(aead)
+		if err != nil || inner.ReadUTF8String() != _long || inner.ReadLong() != int64(round) {
+			fail("TryReadSealed", err)
+		}
+		var rec chunkRecord
+		if err := b.ReadObject(&rec); err != nil || rec.Id != int32(round) || rec.Name != _long || len(rec.Tags) != 2 {
+			fail("ReadObject", rec)
+		}
+		if v, err := b.ReadValue(); err != nil || v.(map[string]interface{})["k"].([]interface{})[1] != "v" {
+			fail("ReadValue", v)
+		}
+
+		_cs := b.BeginVerify()
+		if v := b.ReadUTF8String(); v != _long {
+			fail("checksum content", v)
+		}
+		if err := b.EndVerify(_cs, CRC32); err != nil {
+			fail("EndVerify", err)
+		}
+	}
+	if b.Remaining() != 0 {
+		fail("Remaining", b.Remaining())
+	}
+}
+
+//块长度较小时各种写入频繁跨越块边界，写入的内容与普通模式相同，且可以读取
+func TestChunkedMatchesFlat(t *testing.T) {
+	flat := NewBuffer()
+	writeChunkScript(t, flat)
+	want := append([]byte(nil), flat.GetByte()[:flat.GetTop()]...)
+	readChunkScript(t, "flat", flat)
+
+	for _, size := range []int{1, 2, 3, 5, 7, 8, 13, 16, 64, 1000} {
+		b := NewChunkedBuffer(size)
+		writeChunkScript(t, b)
+		if got := bytes.Join(b.Buffers(), nil); !bytes.Equal(got, want) {
+			i := 0
+			for i < len(got) && i < len(want) && got[i] == want[i] {
+				i++
+			}
+			t.Errorf("chunk %d: len %d, want %d; first difference at %d", size, len(got), len(want), i)
+			continue
+		}
+		if got := b.GetByte(); !bytes.Equal(got, want) {
+			t.Errorf("chunk %d: GetByte differs", size)
+		}
+		readChunkScript(t, "chunk "+strconv.Itoa(size), b)
+	}
+}
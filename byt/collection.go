@@ -0,0 +1,497 @@
+/********************************************************/
+// 字节对象（数组、映射及可选值）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			byt.WriteSlice(buf, []int32{1, 2, 3})
+//			byt.WriteMapOf(buf, map[string]int32{"hp": 100})
+//			byt.WriteOptionalOf(buf, nickname)	//*string，nil时只写入false
+//
+//			ids:=byt.ReadSlice[int32](buf)
+//			attrs:=byt.ReadMapOf[string, int32](buf)
+//			nickname=byt.ReadOptionalOf[string](buf)
+//
+//			buf.WriteArray(len(items), func(i int) {	//任意元素类型
+//				items[i].Write(buf)
+//			})
+/********************************************************/
+
+package byt
+
+import (
+	"math"
+	"reflect"
+)
+
+/**
+ * 泛型集合支持的元素类型
+ * int、uint按64位写入，string按utf8字符串写入（与Marshal的默认编码方式一致）
+ */
+type Scalar interface {
+	~bool | ~int8 | ~int16 | ~int32 | ~int64 | ~int |
+		~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uint |
+		~float32 | ~float64 | ~string
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个数组（WriteLength(元素数量+1)，0表示nil，之后依次写入各元素）
+ * @param n 元素数量（<0时写入nil）
+ * @param fn 写入第i个元素的函数
+ */
+func (b *Buffer) WriteArray(n int, fn func(i int)) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteArray", false), n, nil)
+	}
+	if n < 0 {
+		b.WriteLength(0)
+		return
+	}
+	b.WriteLength(n + 1)
+	for i := 0; i < n; i++ {
+		fn(i)
+	}
+}
+
+/**
+ * 写一个映射（格式与WriteArray相同，每个元素为键+值）
+ * 键的写入顺序由调用者决定，需要与Marshal结果一致时可使用SortKeys排序
+ * @param n 键值对数量（<0时写入nil）
+ * @param fn 写入第i个键值对的函数
+ */
+func (b *Buffer) WriteMap(n int, fn func(i int)) {
+	b.WriteArray(n, fn)
+}
+
+/**
+ * 写一个可选值（boolean是否存在 + 值）
+ * @param present 值是否存在
+ * @param fn 写入值的函数（present为false时不调用）
+ */
+func (b *Buffer) WriteOptional(present bool, fn func()) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteOptional", false), present, nil)
+	}
+	b.WriteBoolean(present)
+	if present {
+		fn()
+	}
+}
+
+/**
+ * 写一个切片（与Marshal写入切片的结果一致，[]byte与WriteData一致）
+ * @param b 字节缓冲对象
+ * @param s 切片（nil与空切片可以区分；字节切片的nil与空切片相同）
+ */
+func WriteSlice[T Scalar](b *Buffer, s []T) {
+	if isByteElem[T]() {
+		b.WriteData(bytesOf(s))
+		return
+	}
+	if s == nil {
+		b.WriteLength(0)
+		return
+	}
+	write := scalarWriter[T]()
+	b.WriteLength(len(s) + 1)
+	for _, v := range s {
+		write(b, v)
+	}
+}
+
+/**
+ * 写一个映射（按键的编码结果排序，与Marshal写入映射的结果一致）
+ * @param b 字节缓冲对象
+ * @param m 映射
+ */
+func WriteMapOf[K, V Scalar](b *Buffer, m map[K]V) {
+	if m == nil {
+		b.WriteLength(0)
+		return
+	}
+	writeKey, writeVal := scalarWriter[K](), scalarWriter[V]()
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	index := SortKeys(b.Order(), len(keys), func(kb *Buffer, i int) {
+		writeKey(kb, keys[i])
+	})
+	b.WriteLength(len(keys) + 1)
+	for _, i := range index {
+		writeKey(b, keys[i])
+		writeVal(b, m[keys[i]])
+	}
+}
+
+/**
+ * 写一个可选值（与Marshal写入指针的结果一致）
+ * @param b 字节缓冲对象
+ * @param v 值的指针（nil表示不存在）
+ */
+func WriteOptionalOf[T Scalar](b *Buffer, v *T) {
+	b.WriteBoolean(v != nil)
+	if v != nil {
+		scalarWriter[T]()(b, *v)
+	}
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个数组
+ * @param fn 读取第i个元素的函数（记录错误后不再调用）
+ * @return 元素数量（nil时为-1）
+ */
+func (b *Buffer) ReadArray(fn func(i int)) (v int) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadArray", false)
+		defer func() { b.traceEnd(_t_, v, nil) }()
+	}
+	if b.err != nil {
+		return 0
+	}
+	n, err := b.TryReadCount()
+	if err != nil {
+		b.fail(err)
+		return 0
+	}
+	for i := 0; i < n && b.err == nil; i++ {
+		fn(i)
+	}
+	return n
+}
+
+/**
+ * 读取一个数组
+ * 元素数量不能超过数量限制（参见SetLimits），出错时偏移位置恢复至读取前的位置
+ * @param fn 读取第i个元素的函数
+ * @return 元素数量（nil时为-1），错误信息（ErrUnderflow、ErrBadLength、*LimitError或fn返回的错误）
+ */
+func (b *Buffer) TryReadArray(fn func(i int) error) (v int, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadArray", false)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	n, err := b.TryReadCount()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < n; i++ {
+		if err := fn(i); err != nil {
+			b.offset, b.rbits = _start, _bits
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+/**
+ * 读取一个映射
+ * @param fn 读取第i个键值对的函数（记录错误后不再调用）
+ * @return 键值对数量（nil时为-1）
+ */
+func (b *Buffer) ReadMap(fn func(i int)) int {
+	return b.ReadArray(fn)
+}
+
+/**
+ * 读取一个映射
+ * @param fn 读取第i个键值对的函数
+ * @return 键值对数量（nil时为-1），错误信息（ErrUnderflow、ErrBadLength、*LimitError或fn返回的错误）
+ */
+func (b *Buffer) TryReadMap(fn func(i int) error) (int, error) {
+	return b.TryReadArray(fn)
+}
+
+/**
+ * 读取一个可选值
+ * @param fn 读取值的函数（值不存在时不调用）
+ * @return 值是否存在
+ */
+func (b *Buffer) ReadOptional(fn func()) (v bool) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadOptional", false)
+		defer func() { b.traceEnd(_t_, v, nil) }()
+	}
+	if b.ReadBoolean() && b.err == nil {
+		fn()
+		return true
+	}
+	return false
+}
+
+/**
+ * 读取一个可选值
+ * @param fn 读取值的函数（值不存在时不调用）
+ * @return 值是否存在，错误信息（ErrUnderflow或fn返回的错误）
+ */
+func (b *Buffer) TryReadOptional(fn func() error) (v bool, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadOptional", false)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	ok, err := b.TryReadBoolean()
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := fn(); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return false, err
+	}
+	return true, nil
+}
+
+/**
+ * 读取一个切片（读取失败时记录错误并返回nil）
+ */
+func ReadSlice[T Scalar](b *Buffer) []T {
+	if b.err != nil {
+		return nil
+	}
+	s, err := TryReadSlice[T](b)
+	b.fail(err)
+	return s
+}
+
+/**
+ * 读取一个切片
+ * 元素数量不能超过数量限制（参见SetLimits），预分配的容量不超过剩余可读取的字节数；
+ * 字节切片与TryReadData相同（按字节数组的长度限制）
+ * @return 切片，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrOverflow）
+ */
+func TryReadSlice[T Scalar](b *Buffer) ([]T, error) {
+	if isByteElem[T]() {
+		return readByteSlice[T](b)
+	}
+	_start, _bits := b.offset, b.rbits
+	n, err := b.TryReadCount()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	read := scalarReader[T]()
+	s := make([]T, 0, min(n, b.Remaining()))
+	for i := 0; i < n; i++ {
+		v, err := read(b)
+		if err != nil {
+			b.offset, b.rbits = _start, _bits
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	return s, nil
+}
+
+/**
+ * 读取一个映射（读取失败时记录错误并返回nil）
+ */
+func ReadMapOf[K, V Scalar](b *Buffer) map[K]V {
+	if b.err != nil {
+		return nil
+	}
+	m, err := TryReadMapOf[K, V](b)
+	b.fail(err)
+	return m
+}
+
+/**
+ * 读取一个映射
+ * 键值对数量不能超过数量限制（参见SetLimits），预分配的容量不超过剩余可读取的字节数
+ * @return 映射，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrOverflow）
+ */
+func TryReadMapOf[K, V Scalar](b *Buffer) (map[K]V, error) {
+	_start, _bits := b.offset, b.rbits
+	n, err := b.TryReadCount()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	readKey, readVal := scalarReader[K](), scalarReader[V]()
+	m := make(map[K]V, min(n, b.Remaining()))
+	for i := 0; i < n; i++ {
+		k, err := readKey(b)
+		if err != nil {
+			b.offset, b.rbits = _start, _bits
+			return nil, err
+		}
+		v, err := readVal(b)
+		if err != nil {
+			b.offset, b.rbits = _start, _bits
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+/**
+ * 读取一个可选值（读取失败时记录错误并返回nil）
+ */
+func ReadOptionalOf[T Scalar](b *Buffer) *T {
+	if b.err != nil {
+		return nil
+	}
+	v, err := TryReadOptionalOf[T](b)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个可选值
+ * @return 值的指针（不存在时为nil），错误信息
+ */
+func TryReadOptionalOf[T Scalar](b *Buffer) (*T, error) {
+	_start, _bits := b.offset, b.rbits
+	ok, err := b.TryReadBoolean()
+	if err != nil || !ok {
+		return nil, err
+	}
+	v, err := scalarReader[T]()(b)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	return &v, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//元素是否为字节（与Marshal的[]byte相同，按WriteData写入）
+func isByteElem[T Scalar]() bool {
+	return reflect.TypeOf(*new(T)).Kind() == reflect.Uint8
+}
+
+//字节切片的内容（与s共享内存）
+func bytesOf[T Scalar](s []T) []byte {
+	if p, ok := interface{}(s).([]byte); ok {
+		return p
+	}
+	return reflect.ValueOf(s).Bytes()
+}
+
+//读取一个字节切片（长度值0为之前的版本写入的nil）
+func readByteSlice[T Scalar](b *Buffer) ([]T, error) {
+	n, err := b.PeekLength()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		b.TryReadLength()
+		return nil, nil
+	}
+	data, err := b.TryReadData()
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := interface{}(data).([]T); ok {
+		return s, nil
+	}
+	s := make([]T, len(data))
+	_v := reflect.ValueOf(s)
+	for i, c := range data {
+		_v.Index(i).SetUint(uint64(c))
+	}
+	return s, nil
+}
+
+//元素的写入函数（自定义类型使用与Marshal相同的反射实现）
+func scalarWriter[T Scalar]() func(*Buffer, T) {
+	var w interface{}
+	switch interface{}(*new(T)).(type) {
+	case bool:
+		w = (*Buffer).WriteBoolean
+	case int8:
+		w = (*Buffer).WriteByt
+	case int16:
+		w = (*Buffer).WriteShort
+	case int32:
+		w = (*Buffer).WriteInt
+	case int64:
+		w = (*Buffer).WriteLong
+	case int:
+		w = func(b *Buffer, v int) { b.WriteLong(int64(v)) }
+	case uint8:
+		w = (*Buffer).WriteUnsignedByt
+	case uint16:
+		w = (*Buffer).WriteUnsignedShort
+	case uint32:
+		w = (*Buffer).WriteUnsignedInt
+	case uint64:
+		w = (*Buffer).WriteUnsignedLong
+	case uint:
+		w = func(b *Buffer, v uint) { b.WriteUnsignedLong(uint64(v)) }
+	case float32:
+		w = (*Buffer).WriteFloat32
+	case float64:
+		w = (*Buffer).WriteFloat
+	case string:
+		w = (*Buffer).WriteUTF8String
+	default:
+		return func(b *Buffer, v T) {
+			encodeLeaf(b, reflect.ValueOf(v), "")
+		}
+	}
+	return w.(func(*Buffer, T))
+}
+
+//元素的读取函数（自定义类型使用与Unmarshal相同的反射实现）
+func scalarReader[T Scalar]() func(*Buffer) (T, error) {
+	var r interface{}
+	switch interface{}(*new(T)).(type) {
+	case bool:
+		r = (*Buffer).TryReadBoolean
+	case int8:
+		r = (*Buffer).TryReadByt
+	case int16:
+		r = (*Buffer).TryReadShort
+	case int32:
+		r = (*Buffer).TryReadInt
+	case int64:
+		r = (*Buffer).TryReadLong
+	case int:
+		r = func(b *Buffer) (int, error) {
+			v, err := b.TryReadLong()
+			if err == nil && (v < math.MinInt || v > math.MaxInt) {
+				return 0, ErrOverflow
+			}
+			return int(v), err
+		}
+	case uint8:
+		r = (*Buffer).TryReadUnsignedByt
+	case uint16:
+		r = (*Buffer).TryReadUnsignedShort
+	case uint32:
+		r = (*Buffer).TryReadUnsignedInt
+	case uint64:
+		r = (*Buffer).TryReadUnsignedLong
+	case uint:
+		r = func(b *Buffer) (uint, error) {
+			v, err := b.TryReadUnsignedLong()
+			if err == nil && v > math.MaxUint {
+				return 0, ErrOverflow
+			}
+			return uint(v), err
+		}
+	case float32:
+		r = (*Buffer).TryReadFloat32
+	case float64:
+		r = (*Buffer).TryReadFloat
+	case string:
+		r = (*Buffer).TryReadUTF8String
+	default:
+		return func(b *Buffer) (T, error) {
+			var v T
+			err := decodeLeaf(b, reflect.ValueOf(&v).Elem(), "")
+			return v, err
+		}
+	}
+	return r.(func(*Buffer) (T, error))
+}
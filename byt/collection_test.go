@@ -0,0 +1,204 @@
+package byt
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testByte uint8
+
+//与Marshal写入的结果一致，且可以读取
+func TestGenericCollections(t *testing.T) {
+	n := int32(5)
+	b := NewBuffer()
+	WriteSlice(b, []int32{1, -2, 3})
+	WriteSlice[int32](b, nil)
+	WriteSlice(b, []string{})
+	WriteMapOf(b, map[string]int32{"b": 2, "a": 1, "cc": 3})
+	WriteMapOf[int16, bool](b, nil)
+	WriteOptionalOf(b, &n)
+	WriteOptionalOf[int32](b, nil)
+	got := b.GetByte()[:b.GetTop()]
+
+	want, err := Marshal(&struct {
+		A []int32
+		B []int32
+		C []string
+		D map[string]int32
+		E map[int16]bool
+		F *int32
+		G *int32
+	}{[]int32{1, -2, 3}, nil, []string{}, map[string]int32{"b": 2, "a": 1, "cc": 3}, nil, &n, nil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("generic\n% x\nMarshal\n% x", got, want)
+	}
+
+	if s, err := TryReadSlice[int32](b); err != nil || !reflect.DeepEqual(s, []int32{1, -2, 3}) {
+		t.Errorf("TryReadSlice = %v, %v", s, err)
+	}
+	if s := ReadSlice[int32](b); s != nil {
+		t.Errorf("nil slice read as %#v", s)
+	}
+	if s := ReadSlice[string](b); s == nil || len(s) != 0 {
+		t.Errorf("empty slice read as %#v", s)
+	}
+	if m, err := TryReadMapOf[string, int32](b); err != nil || !reflect.DeepEqual(m, map[string]int32{"a": 1, "b": 2, "cc": 3}) {
+		t.Errorf("TryReadMapOf = %v, %v", m, err)
+	}
+	if m := ReadMapOf[int16, bool](b); m != nil {
+		t.Errorf("nil map read as %#v", m)
+	}
+	if v := ReadOptionalOf[int32](b); v == nil || *v != 5 {
+		t.Errorf("ReadOptionalOf = %v", v)
+	}
+	if v, err := TryReadOptionalOf[int32](b); v != nil || err != nil {
+		t.Errorf("TryReadOptionalOf = %v, %v", v, err)
+	}
+	if b.Err() != nil || b.Remaining() != 0 {
+		t.Errorf("err %v, remaining %d", b.Err(), b.Remaining())
+	}
+}
+
+//字节切片与WriteData、ReadData一致
+func TestByteSlice(t *testing.T) {
+	b := NewBuffer()
+	WriteSlice[byte](b, nil)
+	WriteSlice(b, []byte{1, 2})
+	WriteSlice(b, []testByte{3, 4})
+	hb := NewBuffer()
+	hb.WriteData(nil)
+	hb.WriteData([]byte{1, 2})
+	hb.WriteData([]byte{3, 4})
+	if got, want := b.GetByte()[:b.GetTop()], hb.GetByte()[:hb.GetTop()]; !bytes.Equal(got, want) {
+		t.Fatalf("WriteSlice % x; WriteData % x", got, want)
+	}
+	if p := b.ReadData(); p == nil || len(p) != 0 {
+		t.Errorf("ReadData = %#v", p)
+	}
+	if s := ReadSlice[byte](b); !bytes.Equal(s, []byte{1, 2}) {
+		t.Errorf("ReadSlice[byte] = %v", s)
+	}
+	if s := ReadSlice[testByte](b); !reflect.DeepEqual(s, []testByte{3, 4}) {
+		t.Errorf("ReadSlice[testByte] = %v", s)
+	}
+	//之前的版本写入的nil
+	if s, err := TryReadSlice[byte](NewBufferWithByte([]byte{0x80})); s != nil || err != nil {
+		t.Errorf("legacy nil = %#v, %v", s, err)
+	}
+
+	//按字节数组而不是元素数量限制
+	b = NewBuffer()
+	WriteSlice(b, make([]byte, 5000))
+	b.SetLimits(Limits{MaxCount: 100})
+	if s, err := TryReadSlice[byte](b); err != nil || len(s) != 5000 {
+		t.Errorf("MaxCount 100: len %d, %v", len(s), err)
+	}
+	b.Reset()
+	b.SetLimits(Limits{MaxData: 100})
+	var le *LimitError
+	if _, err := TryReadSlice[byte](b); !errors.As(err, &le) || le.Kind != LimitData || b.GetOffset() != 0 {
+		t.Errorf("MaxData 100: err = %v, offset %d", err, b.GetOffset())
+	}
+}
+
+//超出数量限制或内容不完整时返回错误，偏移位置不变
+func TestCollectionErrors(t *testing.T) {
+	b := NewBuffer()
+	b.WriteBits(1, 2)
+	WriteSlice(b, make([]int32, 20))
+	b.ReadBits(2)
+	_off, _bits := b.GetOffset(), b.rbits
+	b.SetLimits(Limits{MaxCount: 10})
+	var le *LimitError
+	if _, err := TryReadSlice[int32](b); !errors.As(err, &le) || le.Kind != LimitCount || le.Len != 20 {
+		t.Errorf("TryReadSlice over MaxCount err = %v", err)
+	}
+	if _, err := TryReadMapOf[int32, int32](b); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("TryReadMapOf over MaxCount err = %v", err)
+	}
+	if _, err := b.TryReadArray(func(int) error { return nil }); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("TryReadArray over MaxCount err = %v", err)
+	}
+	if b.GetOffset() != _off || b.rbits != _bits {
+		t.Errorf("offset %d, rbits %d after errors", b.GetOffset(), b.rbits)
+	}
+	if ReadSlice[int32](b); !errors.Is(b.Err(), ErrTooLarge) {
+		t.Errorf("ReadSlice err = %v", b.Err())
+	}
+
+	//内容不完整
+	b = NewBuffer()
+	b.WriteLength(4)
+	b.WriteInt(1)
+	b.WriteInt(2)
+	if _, err := TryReadSlice[int32](b); err != ErrUnderflow || b.GetOffset() != 0 {
+		t.Errorf("truncated slice err = %v, offset %d", err, b.GetOffset())
+	}
+	if _, err := TryReadMapOf[int32, int32](b); err != ErrUnderflow || b.GetOffset() != 0 {
+		t.Errorf("truncated map err = %v, offset %d", err, b.GetOffset())
+	}
+	b = NewBuffer()
+	b.WriteBoolean(true)
+	b.WriteShort(1)
+	if _, err := TryReadOptionalOf[int32](b); err != ErrUnderflow || b.GetOffset() != 0 {
+		t.Errorf("truncated optional err = %v, offset %d", err, b.GetOffset())
+	}
+}
+
+//WriteArray、WriteMap、WriteOptional与对应的读取函数
+func TestArrayCallbacks(t *testing.T) {
+	items := []string{"a", "bb", "ccc"}
+	b := NewBuffer()
+	b.WriteArray(len(items), func(i int) { b.WriteUTF8String(items[i]) })
+	b.WriteArray(-1, nil)
+	b.WriteMap(2, func(i int) {
+		b.WriteInt(int32(i))
+		b.WriteBoolean(i == 1)
+	})
+	b.WriteOptional(true, func() { b.WriteInt(9) })
+	b.WriteOptional(false, func() { t.Error("fn called for absent value") })
+
+	var got []string
+	if n := b.ReadArray(func(int) { got = append(got, b.ReadUTF8String()) }); n != 3 || !reflect.DeepEqual(got, items) {
+		t.Errorf("ReadArray = %d, %v", n, got)
+	}
+	if n, err := b.TryReadArray(func(int) error { return errors.New("called") }); n != -1 || err != nil {
+		t.Errorf("nil array = %d, %v", n, err)
+	}
+	m := map[int32]bool{}
+	if n, err := b.TryReadMap(func(int) error {
+		k, err := b.TryReadInt()
+		if err != nil {
+			return err
+		}
+		m[k], err = b.TryReadBoolean()
+		return err
+	}); n != 2 || err != nil || !reflect.DeepEqual(m, map[int32]bool{0: false, 1: true}) {
+		t.Errorf("TryReadMap = %d, %v, %v", n, err, m)
+	}
+	var v int32
+	if ok := b.ReadOptional(func() { v = b.ReadInt() }); !ok || v != 9 {
+		t.Errorf("ReadOptional = %v, %d", ok, v)
+	}
+	if ok, err := b.TryReadOptional(func() error { return errors.New("called") }); ok || err != nil {
+		t.Errorf("absent TryReadOptional = %v, %v", ok, err)
+	}
+
+	//fn返回错误时偏移位置恢复
+	b.Reset()
+	_stop := errors.New("stop")
+	if _, err := b.TryReadArray(func(i int) error {
+		b.ReadUTF8String()
+		if i == 1 {
+			return _stop
+		}
+		return nil
+	}); err != _stop || b.GetOffset() != 0 {
+		t.Errorf("TryReadArray fn error = %v, offset %d", err, b.GetOffset())
+	}
+}
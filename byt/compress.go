@@ -0,0 +1,223 @@
+/********************************************************/
+// 字节对象（压缩区段）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.WriteShort(cmd)			//头部不压缩
+//			err:=buf.WriteCompressed(func(inner *byt.Buffer) {
+//				inner.WriteObject(snapshot)
+//			}, byt.Gzip)
+//			...
+//			cmd:=buf.ReadShort()
+//			inner:=buf.ReadCompressed()	//压缩算法记录在数据中
+//			inner.ReadObject(&snapshot)
+/********************************************************/
+
+package byt
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"math"
+	"strconv"
+)
+
+/**
+ * 压缩算法
+ */
+type Compression byte
+
+const (
+	Flate Compression = iota + 1 //DEFLATE（RFC 1951）
+	Gzip                         //gzip（RFC 1952）
+	Zlib                         //zlib（RFC 1950）
+)
+
+/**
+ * 算法名称
+ */
+func (c Compression) String() string {
+	switch c {
+	case Flate:
+		return "flate"
+	case Gzip:
+		return "gzip"
+	case Zlib:
+		return "zlib"
+	}
+	return "Compression(" + strconv.Itoa(int(c)) + ")"
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个压缩区段（默认压缩等级）
+ * 写入内容为：压缩算法（1字节）+ 压缩后的字节数组（与WriteData格式一致）
+ * @param fn 向inner写入需要压缩的内容（inner的编码模式与当前对象相同）
+ * @param algo 压缩算法
+ * @return 错误信息（ErrBadAlgorithm），出错时不写入任何内容
+ */
+func (b *Buffer) WriteCompressed(fn func(inner *Buffer), algo Compression) error {
+	return b.WriteCompressedLevel(fn, algo, flate.DefaultCompression)
+}
+
+/**
+ * 写一个压缩区段
+ * @param fn 向inner写入需要压缩的内容（inner的编码模式与当前对象相同）
+ * @param algo 压缩算法
+ * @param level 压缩等级（flate.HuffmanOnly、flate.DefaultCompression或flate.NoCompression ~ flate.BestCompression）
+ * @return 错误信息（未知的压缩算法返回ErrBadAlgorithm，压缩等级不合法时返回压缩库的错误），出错时不写入任何内容
+ */
+func (b *Buffer) WriteCompressedLevel(fn func(inner *Buffer), algo Compression, level int) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteCompressed", true)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	_z := Acquire(__capacity__)
+	defer Release(_z)
+	w, err := newCompressor(_z, algo, level)
+	if err != nil {
+		return err
+	}
+
+	inner := Acquire(__capacity__)
+	defer Release(inner)
+	inner.SetOrder(b.Order())
+	fn(inner)
+	w.Write(inner.byt[:inner.top])
+	w.Close()
+
+	b.WriteUnsignedByt(byte(algo))
+	b.WriteData(_z.byt[:_z.top])
+	return nil
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个压缩区段（读取失败时记录错误并返回一个空对象）
+ * @return 解压后的字节缓冲对象（编码模式与当前对象相同）
+ */
+func (b *Buffer) ReadCompressed() *Buffer {
+	if b.err == nil {
+		inner, err := b.TryReadCompressed()
+		if err == nil {
+			return inner
+		}
+		b.fail(err)
+	}
+	return b.view(b.byt[0:0:0])
+}
+
+/**
+ * 读取一个压缩区段
+ * 压缩数据及解压后的数据均不能超过数据长度限制，解压后的数据同时不能超过内容总长度的限制（参见SetLimits，防止解压炸弹）
+ * 返回的字节缓冲对象由Acquire创建，使用完毕后可调用Release放回对象池
+ * @return 解压后的字节缓冲对象，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrBadCompression）
+ */
+func (b *Buffer) TryReadCompressed() (v *Buffer, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadCompressed", true)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	algo, err := b.TryReadUnsignedByt()
+	if err != nil {
+		return nil, err
+	}
+	l, err := b.TryReadLength()
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	_len := l - 1
+	if _len < 0 {
+		b.offset, b.rbits = _start, _bits
+		return nil, ErrBadLength
+	}
+	if err := b.CheckLimit(LimitData, _len); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	data, err := b.next(_len)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+
+	inner, err := decompress(data, Compression(algo), b.limits)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	inner.SetOrder(b.Order())
+	inner.limits = b.limits
+	return inner, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//创建压缩对象
+func newCompressor(w io.Writer, algo Compression, level int) (io.WriteCloser, error) {
+	switch algo {
+	case Flate:
+		return flate.NewWriter(w, level)
+	case Gzip:
+		return gzip.NewWriterLevel(w, level)
+	case Zlib:
+		return zlib.NewWriterLevel(w, level)
+	}
+	return nil, ErrBadAlgorithm
+}
+
+//解压数据，解压后的长度超过数据长度或内容总长度的限制时返回*LimitError
+func decompress(data []byte, algo Compression, limits Limits) (*Buffer, error) {
+	var (
+		r   io.Reader
+		err error
+	)
+	_src := bytes.NewReader(data)
+	switch algo {
+	case Flate:
+		r = flate.NewReader(_src)
+	case Gzip:
+		r, err = gzip.NewReader(_src)
+	case Zlib:
+		r, err = zlib.NewReader(_src)
+	default:
+		return nil, ErrBadCompression
+	}
+	if err != nil {
+		return nil, ErrBadCompression
+	}
+
+	_kind, max := LimitData, limits.max(LimitData)
+	if _total := limits.max(LimitTotal); _total < max {
+		_kind, max = LimitTotal, _total
+	}
+	inner := Acquire(min(2*len(data)+__capacity__, max))
+	//多读取1个字节用于判断是否超出限制
+	if max < math.MaxInt {
+		r = io.LimitReader(r, int64(max)+1)
+	}
+	_, err = inner.ReadFrom(r)
+	if err == nil && inner.top > max {
+		err = &LimitError{Kind: _kind, Len: inner.top, Max: max}
+	} else if err != nil {
+		err = ErrBadCompression
+	}
+	if err != nil {
+		Release(inner)
+		return nil, err
+	}
+	return inner, nil
+}
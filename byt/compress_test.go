@@ -0,0 +1,47 @@
+package byt
+
+import (
+	"compress/flate"
+	"strings"
+	"testing"
+)
+
+func TestCompressedRoundTrip(t *testing.T) {
+	_text := strings.Repeat("HelloWorld!", 100)
+	for _, algo := range []Compression{Flate, Gzip, Zlib} {
+		b := NewBuffer()
+		b.WriteShort(7)
+		if err := b.WriteCompressed(func(inner *Buffer) { inner.WriteUTF8String(_text) }, algo); err != nil {
+			t.Fatalf("%v: %v", algo, err)
+		}
+		if b.ReadShort() != 7 {
+			t.Fatalf("%v: header mismatch", algo)
+		}
+		inner, err := b.TryReadCompressed()
+		if err != nil {
+			t.Fatalf("%v: TryReadCompressed: %v", algo, err)
+		}
+		if s := inner.ReadUTF8String(); s != _text || b.Remaining() != 0 {
+			t.Errorf("%v: read %d bytes, remaining %d", algo, len(s), b.Remaining())
+		}
+	}
+}
+
+//未知的算法或压缩等级返回错误且不写入任何内容
+func TestCompressedBadArgs(t *testing.T) {
+	b := NewBuffer()
+	b.WriteInt(1)
+	_called := false
+	fn := func(inner *Buffer) { _called = true; inner.WriteInt(2) }
+	if err := b.WriteCompressed(fn, Compression(9)); err != ErrBadAlgorithm {
+		t.Errorf("unknown algorithm: err = %v; want ErrBadAlgorithm", err)
+	}
+	for _, algo := range []Compression{Flate, Gzip, Zlib} {
+		if err := b.WriteCompressedLevel(fn, algo, flate.BestCompression+1); err == nil {
+			t.Errorf("%v: invalid level accepted", algo)
+		}
+	}
+	if b.GetTop() != 4 || _called {
+		t.Errorf("top = %d, fn called = %v", b.GetTop(), _called)
+	}
+}
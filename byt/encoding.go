@@ -0,0 +1,484 @@
+/********************************************************/
+// 字节对象（其他文字编码的字符串：Latin-1、UTF-16、GBK等）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.WriteString("Hello", byt.Latin1)				//长度前缀 + 编码后的内容
+//			buf.WriteFixedString("DEV-01", 16, byt.ASCII)		//16字节，不足部分以NUL填充
+//			buf.WriteCString("Hello", byt.UTF16LE)			//以NUL结尾
+//			s:=buf.ReadString(byt.Latin1)
+//			buf.WriteString("中文", byt.GBK)				//GBK、GB18030、Big5参见encoding_cjk.go
+//
+//			其他编码可以通过NewEncoding接入golang.org/x/text：
+//			sjis:=byt.NewEncoding("shiftjis",
+//				func(s string) ([]byte, error) { return japanese.ShiftJIS.NewEncoder().Bytes([]byte(s)) },
+//				func(p []byte) (string, error) { return japanese.ShiftJIS.NewDecoder().String(string(p)) })
+/********************************************************/
+
+package byt
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/**
+ * 文字编码
+ */
+type Encoding interface {
+	/**
+	 * 编码名称
+	 */
+	Name() string
+	/**
+	 * 将s编码后追加至dst（无法编码时返回错误，内置的文字编码返回*EncodeError）
+	 */
+	Encode(dst []byte, s string) ([]byte, error)
+	/**
+	 * 解码（内容不合法时返回ErrBadString）
+	 */
+	Decode(p []byte) (string, error)
+}
+
+/**
+ * 字符无法以指定的文字编码表示
+ */
+type EncodeError struct {
+	Encoding string //编码名称
+	Rune     rune   //无法编码的字符
+	Offset   int    //字符在字符串中的字节位置
+}
+
+func (e *EncodeError) Error() string {
+	return "byt: 字符" + strconv.QuoteRune(e.Rune) + "（位置" + strconv.Itoa(e.Offset) + "）无法以" + e.Encoding + "编码"
+}
+
+var (
+	/**
+	 * UTF-8（写入前检查字符串是否为合法的utf8）
+	 */
+	UTF8 Encoding = utf8Encoding{}
+	/**
+	 * ASCII（0x00 ~ 0x7F）
+	 */
+	ASCII Encoding = singleByte{name: "ascii", max: 0x7f}
+	/**
+	 * Latin-1（ISO-8859-1，0x00 ~ 0xFF与U+0000 ~ U+00FF一一对应）
+	 */
+	Latin1 Encoding = singleByte{name: "latin1", max: 0xff}
+	/**
+	 * UTF-16小端（不写入BOM）
+	 */
+	UTF16LE Encoding = utf16Encoding{little: true}
+	/**
+	 * UTF-16大端（不写入BOM）
+	 */
+	UTF16BE Encoding = utf16Encoding{little: false}
+	/**
+	 * GBK（CP936的双字节部分，不含0x80）
+	 */
+	GBK Encoding = &cjkEncoding{name: "gbk", kind: __cjkgbk__}
+	/**
+	 * GB18030（双字节及四字节，可以表示全部Unicode字符）
+	 */
+	GB18030 Encoding = &cjkEncoding{name: "gb18030", kind: __cjkgb18030__}
+	/**
+	 * Big5（繁体中文）
+	 */
+	Big5 Encoding = &cjkEncoding{name: "big5", kind: __cjkbig5__}
+)
+
+/**
+ * 使用编码、解码函数创建一个文字编码（用于接入Shift_JIS、EUC-KR等外部实现）
+ * @param name 编码名称
+ * @param encode 编码函数
+ * @param decode 解码函数
+ * @return 文字编码
+ */
+func NewEncoding(name string, encode func(s string) ([]byte, error), decode func(p []byte) (string, error)) Encoding {
+	return &funcEncoding{name: name, encode: encode, decode: decode}
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 以指定的文字编码写一个字符串（长度前缀与WriteUTF8String相同，为编码后的字节数+1）
+ * @param s 字符串
+ * @param enc 文字编码
+ * @return 错误信息（*EncodeError），出错时不写入任何内容
+ */
+func (b *Buffer) WriteString(s string, enc Encoding) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteString", true)
+		defer func() { b.traceEnd(_t_, s, err) }()
+	}
+	data, err := enc.Encode(nil, s)
+	if err != nil {
+		return err
+	}
+	b.WriteData(data)
+	return nil
+}
+
+/**
+ * 以指定的文字编码写一个定长字符串（不足部分以0填充）
+ * @param s 字符串
+ * @param width 字节长度
+ * @param enc 文字编码
+ * @return 错误信息（*EncodeError，编码后超过width时返回ErrTooLarge），出错时不写入任何内容
+ */
+func (b *Buffer) WriteFixedString(s string, width int, enc Encoding) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteFixedString", true)
+		defer func() { b.traceEnd(_t_, s, err) }()
+	}
+	if width < 0 {
+		return ErrBadLength
+	}
+	data, err := enc.Encode(nil, s)
+	if err != nil {
+		return err
+	}
+	if len(data) > width {
+		return ErrTooLarge
+	}
+	_dst := b.grow(width)
+	n := copy(_dst, data)
+	clear(_dst[n:])
+	return nil
+}
+
+/**
+ * 以指定的文字编码写一个以NUL结尾的字符串（UTF-16的NUL为2个字节）
+ * @param s 字符串（不能包含NUL字符）
+ * @param enc 文字编码
+ * @return 错误信息（*EncodeError，包含NUL时返回ErrBadString），出错时不写入任何内容
+ */
+func (b *Buffer) WriteCString(s string, enc Encoding) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteCString", true)
+		defer func() { b.traceEnd(_t_, s, err) }()
+	}
+	if strings.IndexByte(s, 0) >= 0 {
+		return ErrBadString
+	}
+	data, err := enc.Encode(nil, s)
+	if err != nil {
+		return err
+	}
+	_nul, err := enc.Encode(nil, "\x00")
+	if err != nil {
+		return err
+	}
+	b.WriteBytes(data, 0, len(data))
+	b.WriteBytes(_nul, 0, len(_nul))
+	return nil
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 以指定的文字编码读取一个字符串（与WriteString对应）
+ */
+func (b *Buffer) ReadString(enc Encoding) string {
+	if b.err != nil {
+		return ""
+	}
+	v, err := b.TryReadString(enc)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 以指定的文字编码读取一个定长字符串（去掉第一个NUL字符及之后的内容）
+ */
+func (b *Buffer) ReadFixedString(width int, enc Encoding) string {
+	if b.err != nil {
+		return ""
+	}
+	v, err := b.TryReadFixedString(width, enc)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 以指定的文字编码读取一个以NUL结尾的字符串
+ */
+func (b *Buffer) ReadCString(enc Encoding) string {
+	if b.err != nil {
+		return ""
+	}
+	v, err := b.TryReadCString(enc)
+	b.fail(err)
+	return v
+}
+
+/**
+ * 以指定的文字编码读取一个字符串
+ * @return 字符串，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrBadString）
+ */
+func (b *Buffer) TryReadString(enc Encoding) (v string, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadString", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	l, err := b.TryReadLength()
+	if err != nil {
+		return "", err
+	}
+	_len := l - 1
+	if _len < 0 {
+		b.offset, b.rbits = _start, _bits
+		return "", ErrBadLength
+	}
+	if err := b.CheckLimit(LimitString, _len); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	data, err := b.next(_len)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	s, err := enc.Decode(data)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	return s, nil
+}
+
+/**
+ * 以指定的文字编码读取一个定长字符串
+ * 内容在第一个NUL（按编码后的NUL的长度对齐，如UTF-16为2字节）处截断，之后的填充内容不解码
+ * @param width 字节长度
+ * @param enc 文字编码
+ * @return 字符串，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrBadString）
+ */
+func (b *Buffer) TryReadFixedString(width int, enc Encoding) (v string, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadFixedString", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	if err := b.CheckLimit(LimitString, width); err != nil {
+		return "", err
+	}
+	_start, _bits := b.offset, b.rbits
+	data, err := b.next(width)
+	if err != nil {
+		return "", err
+	}
+	_cut := false
+	if _nul, err := enc.Encode(nil, "\x00"); err == nil && len(_nul) > 0 {
+		for i := 0; i+len(_nul) <= len(data); i += len(_nul) {
+			if bytes.Equal(data[i:i+len(_nul)], _nul) {
+				data, _cut = data[:i], true
+				break
+			}
+		}
+	}
+	s, err := enc.Decode(data)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	if i := strings.IndexByte(s, 0); i >= 0 && !_cut {
+		s = s[:i] //无法编码NUL的文字编码
+	}
+	return s, nil
+}
+
+/**
+ * 以指定的文字编码读取一个以NUL结尾的字符串
+ * @param enc 文字编码
+ * @return 字符串，错误信息（找不到NUL时返回ErrUnderflow，*LimitError、ErrBadString）
+ */
+func (b *Buffer) TryReadCString(enc Encoding) (v string, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadCString", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	b.live()
+	_nul, err := enc.Encode(nil, "\x00")
+	if err != nil {
+		return "", err
+	}
+	_w := len(_nul)
+	_end := -1
+	for i := b.offset; i+_w <= b.top; i += _w {
+		if bytes.Equal(b.span(i, i+_w), _nul) {
+			_end = i
+			break
+		}
+	}
+	if _end < 0 {
+		return "", ErrUnderflow
+	}
+	if err := b.CheckLimit(LimitString, _end-b.offset); err != nil {
+		return "", err
+	}
+	s, err := enc.Decode(b.span(b.offset, _end))
+	if err != nil {
+		return "", err
+	}
+	b.next(_end + _w - b.offset)
+	return s, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部实现
+
+type utf8Encoding struct{}
+
+func (utf8Encoding) Name() string {
+	return "utf8"
+}
+
+func (utf8Encoding) Encode(dst []byte, s string) ([]byte, error) {
+	if !utf8.ValidString(s) {
+		for i, c := range s {
+			if c == utf8.RuneError && !isRuneError(s, i) {
+				return nil, &EncodeError{Encoding: "utf8", Rune: c, Offset: i}
+			}
+		}
+	}
+	return append(dst, s...), nil
+}
+
+func (utf8Encoding) Decode(p []byte) (string, error) {
+	if !utf8.Valid(p) {
+		return "", ErrBadString
+	}
+	return string(p), nil
+}
+
+//单字节编码（字符值即为字节值）
+type singleByte struct {
+	name string
+	max  rune
+}
+
+func (e singleByte) Name() string {
+	return e.name
+}
+
+func (e singleByte) Encode(dst []byte, s string) ([]byte, error) {
+	for i, c := range s {
+		if c > e.max || (c == utf8.RuneError && !isRuneError(s, i)) {
+			return nil, &EncodeError{Encoding: e.name, Rune: c, Offset: i}
+		}
+		dst = append(dst, byte(c))
+	}
+	return dst, nil
+}
+
+func (e singleByte) Decode(p []byte) (string, error) {
+	_news := make([]byte, 0, len(p))
+	for _, c := range p {
+		if rune(c) > e.max {
+			return "", ErrBadString
+		}
+		_news = utf8.AppendRune(_news, rune(c))
+	}
+	return string(_news), nil
+}
+
+type utf16Encoding struct {
+	little bool
+}
+
+func (e utf16Encoding) Name() string {
+	if e.little {
+		return "utf16le"
+	}
+	return "utf16be"
+}
+
+func (e utf16Encoding) Encode(dst []byte, s string) ([]byte, error) {
+	for i, c := range s {
+		if c == utf8.RuneError && !isRuneError(s, i) {
+			return nil, &EncodeError{Encoding: e.Name(), Rune: c, Offset: i}
+		}
+		if c >= 0x10000 {
+			r1, r2 := utf16.EncodeRune(c)
+			dst = e.appendUnit(dst, uint16(r1))
+			dst = e.appendUnit(dst, uint16(r2))
+			continue
+		}
+		dst = e.appendUnit(dst, uint16(c))
+	}
+	return dst, nil
+}
+
+func (e utf16Encoding) Decode(p []byte) (string, error) {
+	if len(p)%2 != 0 {
+		return "", ErrBadString
+	}
+	_units := make([]uint16, len(p)/2)
+	for i := range _units {
+		if e.little {
+			_units[i] = uint16(p[2*i]) | uint16(p[2*i+1])<<8
+		} else {
+			_units[i] = uint16(p[2*i])<<8 | uint16(p[2*i+1])
+		}
+	}
+	//不成对的代理视为不合法
+	for i := 0; i < len(_units); i++ {
+		if utf16.IsSurrogate(rune(_units[i])) {
+			if _units[i] >= 0xdc00 || i+1 >= len(_units) || _units[i+1] < 0xdc00 || _units[i+1] > 0xdfff {
+				return "", ErrBadString
+			}
+			i++
+		}
+	}
+	return string(utf16.Decode(_units)), nil
+}
+
+func (e utf16Encoding) appendUnit(dst []byte, u uint16) []byte {
+	if e.little {
+		return append(dst, byte(u), byte(u>>8))
+	}
+	return append(dst, byte(u>>8), byte(u))
+}
+
+type funcEncoding struct {
+	name   string
+	encode func(s string) ([]byte, error)
+	decode func(p []byte) (string, error)
+}
+
+func (e *funcEncoding) Name() string {
+	return e.name
+}
+
+func (e *funcEncoding) Encode(dst []byte, s string) ([]byte, error) {
+	data, err := e.encode(s)
+	if err != nil {
+		return nil, fmt.Errorf("byt: %s编码失败: %w", e.name, err)
+	}
+	return append(dst, data...), nil
+}
+
+func (e *funcEncoding) Decode(p []byte) (string, error) {
+	s, err := e.decode(p)
+	if err != nil {
+		return "", fmt.Errorf("%w（%s: %v）", ErrBadString, e.name, err)
+	}
+	return s, nil
+}
+
+//判断s[i]处的U+FFFD是字符串中真实存在的字符（而不是不合法的utf8字节）
+func isRuneError(s string, i int) bool {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	return size == 3
+}
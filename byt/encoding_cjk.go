@@ -0,0 +1,173 @@
+/********************************************************/
+// 字节对象（双字节文字编码：GBK、GB18030、Big5）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.WriteString("中文", byt.GBK)
+//			buf.WriteFixedString("繁體", 16, byt.Big5)
+//			buf.WriteCString("𠀀", byt.GB18030)			//GB18030可以表示全部Unicode字符
+//			s:=buf.ReadString(byt.GBK)
+/********************************************************/
+
+package byt
+
+import (
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	__cjkgbk__ byte = iota
+	__cjkgb18030__
+	__cjkbig5__
+)
+
+//GB18030四字节区中辅助平面字符（U+10000起）的起始线性下标
+const __gb18030supp__ int = 189000
+
+//双字节文字编码（码表参见encoding_cjk_table.go，编码使用的反向映射在首次编码时创建）
+type cjkEncoding struct {
+	name string
+	kind byte
+	once sync.Once
+	rev  map[rune]uint16 //字符 -> 双字节编码（首字节<<8|尾字节）
+}
+
+func (e *cjkEncoding) Name() string {
+	return e.name
+}
+
+func (e *cjkEncoding) Encode(dst []byte, s string) ([]byte, error) {
+	e.once.Do(e.build)
+	for i, c := range s {
+		if c < 0x80 {
+			dst = append(dst, byte(c))
+			continue
+		}
+		if c == utf8.RuneError && !isRuneError(s, i) {
+			return nil, &EncodeError{Encoding: e.name, Rune: c, Offset: i}
+		}
+		if _code, ok := e.rev[c]; ok {
+			dst = append(dst, byte(_code>>8), byte(_code))
+			continue
+		}
+		if e.kind == __cjkgb18030__ {
+			dst = appendGB18030Four(dst, c)
+			continue
+		}
+		return nil, &EncodeError{Encoding: e.name, Rune: c, Offset: i}
+	}
+	return dst, nil
+}
+
+func (e *cjkEncoding) Decode(p []byte) (string, error) {
+	_news := make([]byte, 0, len(p)+len(p)/2)
+	for i := 0; i < len(p); {
+		if p[i] < 0x80 {
+			_news = append(_news, p[i])
+			i++
+			continue
+		}
+		r, n := e.decodeRune(p[i:])
+		if n == 0 {
+			return "", ErrBadString
+		}
+		_news = utf8.AppendRune(_news, r)
+		i += n
+	}
+	return string(_news), nil
+}
+
+//解码p开头的一个多字节字符，不合法时n为0
+func (e *cjkEncoding) decodeRune(p []byte) (r rune, n int) {
+	if len(p) < 2 {
+		return 0, 0
+	}
+	_lead, _trail := p[0], p[1]
+	if e.kind == __cjkgb18030__ && _trail >= 0x30 && _trail <= 0x39 {
+		return decodeGB18030Four(p)
+	}
+	if _trail < 0x40 || _trail == 0x7f || _trail == 0xff {
+		return 0, 0
+	}
+	switch e.kind {
+	case __cjkbig5__:
+		if _lead < 0xa1 || _lead > 0xf9 {
+			return 0, 0
+		}
+		r = rune(__big5__[int(_lead-0xa1)*191+int(_trail-0x40)])
+	default:
+		if _lead < 0x81 || _lead == 0xff {
+			return 0, 0
+		}
+		_idx := int(_lead-0x81)*191 + int(_trail-0x40)
+		if e.kind == __cjkgbk__ && gbkExcluded(_idx) {
+			return 0, 0
+		}
+		r = rune(__gb18030two__[_idx])
+	}
+	if r == 0 {
+		return 0, 0
+	}
+	return r, 2
+}
+
+//创建反向映射（同一字符对应多个编码时使用码表中靠后的编码）
+func (e *cjkEncoding) build() {
+	var _table []uint16
+	var _lead0 int
+	if e.kind == __cjkbig5__ {
+		_table, _lead0 = __big5__[:], 0xa1
+	} else {
+		_table, _lead0 = __gb18030two__[:], 0x81
+	}
+	e.rev = make(map[rune]uint16, len(_table))
+	for i, c := range _table {
+		if c == 0 || (e.kind == __cjkgbk__ && gbkExcluded(i)) {
+			continue
+		}
+		e.rev[rune(c)] = uint16(_lead0+i/191)<<8 | uint16(0x40+i%191)
+	}
+}
+
+//双字节区的下标是否不属于GBK
+func gbkExcluded(idx int) bool {
+	i := sort.Search(len(__gbkexcluded__), func(i int) bool { return int(__gbkexcluded__[i][1]) > idx })
+	return i < len(__gbkexcluded__) && int(__gbkexcluded__[i][0]) <= idx
+}
+
+//解码GB18030的四字节字符（字节依次为0x81 ~ 0xFE、0x30 ~ 0x39、0x81 ~ 0xFE、0x30 ~ 0x39）
+func decodeGB18030Four(p []byte) (rune, int) {
+	if len(p) < 4 || p[0] < 0x81 || p[0] == 0xff || p[2] < 0x81 || p[2] == 0xff || p[3] < 0x30 || p[3] > 0x39 {
+		return 0, 0
+	}
+	_lin := ((int(p[0]-0x81)*10+int(p[1]-0x30))*126+int(p[2]-0x81))*10 + int(p[3]-0x30)
+	if _lin < __gb18030fourlen__ {
+		i := sort.Search(len(__gb18030four__), func(i int) bool { return int(__gb18030four__[i][1]) > _lin }) - 1
+		return rune(__gb18030four__[i][0]) + rune(_lin-int(__gb18030four__[i][1])), 4
+	}
+	if _lin >= __gb18030supp__ && _lin-__gb18030supp__ <= utf8.MaxRune-0x10000 {
+		return rune(_lin-__gb18030supp__) + 0x10000, 4
+	}
+	return 0, 0
+}
+
+//以GB18030的四字节形式写入不在双字节区中的字符
+func appendGB18030Four(dst []byte, c rune) []byte {
+	var _lin int
+	if c >= 0x10000 {
+		_lin = __gb18030supp__ + int(c-0x10000)
+	} else {
+		i := sort.Search(len(__gb18030four__), func(i int) bool { return rune(__gb18030four__[i][0]) > c }) - 1
+		_lin = int(__gb18030four__[i][1]) + int(c-rune(__gb18030four__[i][0]))
+	}
+	_b4 := byte(_lin%10) + 0x30
+	_lin /= 10
+	_b3 := byte(_lin%126) + 0x81
+	_lin /= 126
+	_b2 := byte(_lin%10) + 0x30
+	_b1 := byte(_lin/10) + 0x81
+	return append(dst, _b1, _b2, _b3, _b4)
+}
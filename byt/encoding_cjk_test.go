@@ -0,0 +1,79 @@
+package byt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCJKEncodings(t *testing.T) {
+	cases := []struct {
+		enc  Encoding
+		s    string
+		want []byte
+	}{
+		{GBK, "中文", []byte{0xd6, 0xd0, 0xce, 0xc4}},
+		{GBK, "a中", []byte{0x61, 0xd6, 0xd0}},
+		{GB18030, "中文", []byte{0xd6, 0xd0, 0xce, 0xc4}},
+		{GB18030, "€", []byte{0xa2, 0xe3}},
+		{GB18030, "\u0080", []byte{0x81, 0x30, 0x81, 0x30}},
+		{GB18030, "\U00020000", []byte{0x95, 0x32, 0x82, 0x36}},
+		{GB18030, "\U0010FFFF", []byte{0xe3, 0x32, 0x9a, 0x35}},
+		{Big5, "繁體", []byte{0xc1, 0x63, 0xc5, 0xe9}},
+	}
+	for _, c := range cases {
+		got, err := c.enc.Encode(nil, c.s)
+		if err != nil || !bytes.Equal(got, c.want) {
+			t.Errorf("%s.Encode(%q) = %X, %v; want %X", c.enc.Name(), c.s, got, err, c.want)
+			continue
+		}
+		s, err := c.enc.Decode(got)
+		if err != nil || s != c.s {
+			t.Errorf("%s.Decode(%X) = %q, %v; want %q", c.enc.Name(), got, s, err, c.s)
+		}
+	}
+}
+
+func TestCJKEncodingErrors(t *testing.T) {
+	var ee *EncodeError
+	if _, err := GBK.Encode(nil, "a€"); !errors.As(err, &ee) || ee.Rune != '€' || ee.Offset != 1 {
+		t.Errorf("GBK.Encode(€) error = %v", err)
+	}
+	if _, err := Big5.Encode(nil, "们"); !errors.As(err, &ee) {
+		t.Errorf("Big5.Encode(们) error = %v", err)
+	}
+	for _, c := range []struct {
+		enc Encoding
+		p   []byte
+	}{
+		{GBK, []byte{0xd6}},
+		{GBK, []byte{0x80}},
+		{GBK, []byte{0xa2, 0xe3}},
+		{GB18030, []byte{0x81, 0x30, 0x81}},
+		{GB18030, []byte{0x84, 0x31, 0xa5, 0x30}},
+		{Big5, []byte{0x81, 0x40}},
+	} {
+		if _, err := c.enc.Decode(c.p); err != ErrBadString {
+			t.Errorf("%s.Decode(%X) error = %v; want ErrBadString", c.enc.Name(), c.p, err)
+		}
+	}
+}
+
+func TestReadCJKString(t *testing.T) {
+	b := NewBuffer()
+	if err := b.WriteString("中文", GB18030); err != nil {
+		t.Fatal(err)
+	}
+	b.WriteFixedString("繁體", 8, Big5)
+	if s := b.ReadString(GB18030); s != "中文" {
+		t.Errorf("ReadString = %q", s)
+	}
+	b.SetLimits(Limits{MaxString: 4})
+	if _, err := b.TryReadFixedString(8, Big5); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("TryReadFixedString over limit error = %v", err)
+	}
+	b.SetLimits(Limits{})
+	if s, err := b.TryReadFixedString(8, Big5); err != nil || s != "繁體" {
+		t.Errorf("TryReadFixedString = %q, %v", s, err)
+	}
+}
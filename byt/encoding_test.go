@@ -0,0 +1,40 @@
+package byt
+
+import (
+	"testing"
+)
+
+//定长字符串在第一个NUL处截断，之后的填充内容（可能不是合法的编码）不解码
+func TestFixedStringPadding(t *testing.T) {
+	cases := []struct {
+		name string
+		enc  Encoding
+		data []byte
+		want string
+	}{
+		{"ascii", ASCII, []byte{'D', 'E', 'V', 0, 0xff, 0xfe, 0, 0}, "DEV"},
+		{"ascii full", ASCII, []byte{'D', 'E', 'V', '1'}, "DEV1"},
+		{"ascii empty", ASCII, []byte{0, 0xff}, ""},
+		{"utf16le", UTF16LE, []byte{'A', 0, 0, 0, 0x00, 0xd8, 0xff}, "A"},
+		{"utf16be aligned", UTF16BE, []byte{0x01, 0x00, 0x00, 'A', 0, 0, 0xdc, 0x00}, "ĀA"},
+		{"gbk", GBK, []byte{0xd6, 0xd0, 0, 0x81, 0xff}, "中"},
+		{"utf8", UTF8, []byte{0xe4, 0xb8, 0xad, 0, 0xe4, 0xb8}, "中"},
+	}
+	for _, c := range cases {
+		b := NewBufferWithByte(append(c.data, 0x7f))
+		s, err := b.TryReadFixedString(len(c.data), c.enc)
+		if err != nil || s != c.want {
+			t.Errorf("%s: TryReadFixedString = %q, %v; want %q", c.name, s, err, c.want)
+			continue
+		}
+		if b.GetOffset() != len(c.data) {
+			t.Errorf("%s: offset %d; want %d", c.name, b.GetOffset(), len(c.data))
+		}
+	}
+
+	//NUL之前的内容不合法
+	b := NewBufferWithByte([]byte{'A', 0xff, 0, 0})
+	if _, err := b.TryReadFixedString(4, ASCII); err != ErrBadString || b.GetOffset() != 0 {
+		t.Errorf("bad content: err = %v, offset %d", err, b.GetOffset())
+	}
+}
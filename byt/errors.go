@@ -0,0 +1,62 @@
+/********************************************************/
+// 字节对象错误定义
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			v,err:=buf.TryReadInt()
+//			if errors.Is(err,byt.ErrUnderflow) {...}
+/********************************************************/
+
+package byt
+
+import (
+	"errors"
+)
+
+var (
+	/**
+	 * 剩余可读取的内容不足
+	 */
+	ErrUnderflow = errors.New("byt: 剩余可读取的内容不足")
+	/**
+	 * 长度值不合法
+	 */
+	ErrBadLength = errors.New("byt: 长度值不合法")
+	/**
+	 * 数据长度超出限制（超出读取长度限制时返回的*LimitError同样满足errors.Is(err, ErrTooLarge)）
+	 */
+	ErrTooLarge = errors.New("byt: 数据长度超出限制")
+	/**
+	 * 字符串编码不合法
+	 */
+	ErrBadString = errors.New("byt: 字符串编码不合法")
+	/**
+	 * 数值溢出
+	 */
+	ErrOverflow = errors.New("byt: 数值溢出")
+	/**
+	 * 校验和不匹配
+	 */
+	ErrChecksum = errors.New("byt: 校验和不匹配")
+	/**
+	 * 未知的校验和或压缩算法
+	 */
+	ErrBadAlgorithm = errors.New("byt: 未知的算法")
+	/**
+	 * 压缩数据不合法
+	 */
+	ErrBadCompression = errors.New("byt: 压缩数据不合法")
+	/**
+	 * 类型标记不合法
+	 */
+	ErrBadTag = errors.New("byt: 类型标记不合法")
+	/**
+	 * 字段编号不合法
+	 */
+	ErrBadField = errors.New("byt: 字段编号不合法")
+	/**
+	 * 字段的编码类型不合法或与内容不符
+	 */
+	ErrBadWire = errors.New("byt: 字段的编码类型不合法")
+)
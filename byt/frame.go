@@ -0,0 +1,228 @@
+/********************************************************/
+// 字节对象（基于io.Reader/io.Writer的数据帧读写）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			fw:=byt.NewFrameWriter(conn,0)
+//			fw.WriteFrame(buf)				//长度前缀（WriteLength编码）+内容
+//
+//			fr:=byt.NewFrameReader(bufio.NewReader(conn),64*1024)
+//			for {
+//				frame,err:=fr.ReadFrame()	//每帧一个字节缓冲对象
+//				if err!=nil {break}		//io.EOF表示在帧边界处正常结束
+//				...
+//				byt.Release(frame)
+//			}
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+)
+
+/**
+ * 数据帧的最大长度（WriteLength可表示的最大值）
+ */
+const __maxframe__ int = 0x20000000 - 1
+
+/**
+ * 数据帧读取对象
+ * 从io.Reader中读取以WriteLength编码为长度前缀的数据帧，不完整的读取会被拼接为完整的帧
+ */
+type FrameReader struct {
+	r      io.Reader
+	max    int              //单帧最大长度
+	order  binary.ByteOrder //返回的字节缓冲对象的编码模式
+	limits Limits           //返回的字节缓冲对象的读取长度限制
+	hdr    [4]byte
+}
+
+/**
+ * 创建一个数据帧读取对象
+ * r为无缓冲的连接时建议使用bufio.Reader包装，以减少系统调用
+ * @param r 数据源（net.Conn、os.File等）
+ * @param maxSize 单帧最大长度（<=0时使用默认最大数据长度__maxlength__）
+ * @return 数据帧读取对象
+ */
+func NewFrameReader(r io.Reader, maxSize int) *FrameReader {
+	return &FrameReader{
+		r:     r,
+		max:   frameLimit(maxSize),
+		order: getEndian(),
+	}
+}
+
+/**
+ * 设置读取的字节缓冲对象的编码模式（默认为创建时的默认编码模式）
+ * @param order 编码模式
+ */
+func (f *FrameReader) SetOrder(order binary.ByteOrder) {
+	if order == nil {
+		return
+	}
+	f.order = order
+}
+
+/**
+ * 设置读取的字节缓冲对象的读取长度限制（MaxTotal同时限制帧长度）
+ * @param l 长度限制
+ */
+func (f *FrameReader) SetLimits(l Limits) {
+	f.limits = l
+}
+
+/**
+ * 读取一个数据帧
+ * 返回的字节缓冲对象由Acquire创建，使用完毕后可调用Release放回对象池
+ * @return 帧内容（top值为帧长度，偏移位置为0），错误信息
+ *         在帧边界处结束时返回io.EOF，帧内容不完整时返回io.ErrUnexpectedEOF，
+ *         长度前缀不合法时返回ErrBadLength，超出单帧最大长度时返回ErrTooLarge，超出内容总长度的限制时返回*LimitError
+ */
+func (f *FrameReader) ReadFrame() (*Buffer, error) {
+	if _, err := io.ReadFull(f.r, f.hdr[:1]); err != nil {
+		return nil, err
+	}
+	_size := lengthSize(f.hdr[0])
+	if _size == 0 {
+		return nil, ErrBadLength
+	}
+	if _size > 1 {
+		if _, err := io.ReadFull(f.r, f.hdr[1:_size]); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+	_len := getLength(f.hdr[:_size])
+	if _len > f.max {
+		return nil, ErrTooLarge
+	}
+	if err := f.limits.check(LimitTotal, _len); err != nil {
+		return nil, err
+	}
+
+	b := Acquire(_len)
+	b.SetOrder(f.order)
+	b.limits = f.limits
+	if _, err := io.ReadFull(f.r, b.byt[:_len]); err != nil {
+		Release(b)
+		return nil, unexpectedEOF(err)
+	}
+	b.top = _len
+	return b, nil
+}
+
+/**
+ * 数据帧写入对象
+ * 每帧写入WriteLength编码的长度前缀及帧内容
+ */
+type FrameWriter struct {
+	w   io.Writer
+	max int //单帧最大长度
+	hdr [4]byte
+}
+
+/**
+ * 创建一个数据帧写入对象
+ * @param w 写入目标（net.Conn、os.File等）
+ * @param maxSize 单帧最大长度（<=0时使用默认最大数据长度__maxlength__）
+ * @return 数据帧写入对象
+ */
+func NewFrameWriter(w io.Writer, maxSize int) *FrameWriter {
+	return &FrameWriter{
+		w:   w,
+		max: frameLimit(maxSize),
+	}
+}
+
+/**
+ * 将字节缓冲对象中已写入的全部内容（0至top）作为一个数据帧写入（分块模式下长度前缀与各块通过一次writev写入）
+ * @param b 字节缓冲对象
+ * @return 错误信息（超出单帧最大长度时返回ErrTooLarge）
+ */
+func (f *FrameWriter) WriteFrame(b *Buffer) error {
+	b.live()
+	return f.writeFrame(b.buffers(0, b.top), b.top)
+}
+
+/**
+ * 将字节数组作为一个数据帧写入
+ * 写入目标为net.Conn时长度前缀与内容通过一次writev写入
+ * @param p 帧内容
+ * @return 错误信息（超出单帧最大长度时返回ErrTooLarge）
+ */
+func (f *FrameWriter) WriteFrameBytes(p []byte) error {
+	return f.writeFrame(net.Buffers{p}, len(p))
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//写入一个数据帧（内容为bufs，总长度为n）
+func (f *FrameWriter) writeFrame(bufs net.Buffers, n int) error {
+	if n > f.max {
+		return ErrTooLarge
+	}
+	_size := putLength(f.hdr[:], n)
+	_bufs := append(net.Buffers{f.hdr[:_size]}, bufs...)
+	_, err := _bufs.WriteTo(f.w)
+	return err
+}
+
+//单帧最大长度
+func frameLimit(maxSize int) int {
+	if maxSize <= 0 {
+		return __maxlength__
+	}
+	if maxSize > __maxframe__ {
+		return __maxframe__
+	}
+	return maxSize
+}
+
+//根据长度前缀的首字节获取前缀的字节数（不合法时返回0）
+func lengthSize(first byte) int {
+	if first >= 0x80 {
+		return 1
+	} else if first >= 0x40 {
+		return 2
+	} else if first >= 0x20 {
+		return 4
+	}
+	return 0
+}
+
+//解码长度前缀（与TryReadLength一致）
+func getLength(hdr []byte) int {
+	switch len(hdr) {
+	case 1:
+		return int(hdr[0] - 0x80)
+	case 2:
+		return int(binary.BigEndian.Uint16(hdr) - 0x4000)
+	}
+	return int(binary.BigEndian.Uint32(hdr) - 0x20000000)
+}
+
+//编码长度前缀（与WriteLength一致），返回前缀的字节数
+func putLength(hdr []byte, val int) int {
+	if val >= 0x4000 {
+		binary.BigEndian.PutUint32(hdr, uint32(val+0x20000000))
+		return 4
+
+	} else if val >= 0x80 {
+		binary.BigEndian.PutUint16(hdr, uint16(val+0x4000))
+		return 2
+	}
+	hdr[0] = byte(val + 0x80)
+	return 1
+}
+
+//帧内容读取到一半时遇到的io.EOF视为io.ErrUnexpectedEOF
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,106 @@
+package byt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+//各长度前缀（1、2、4字节）的帧
+func testFrames() [][]byte {
+	var frames [][]byte
+	for _, n := range []int{0, 1, 0x7f, 0x80, 0x3fff, 0x4000, 70000} {
+		p := make([]byte, n)
+		for i := range p {
+			p[i] = byte(i * 7)
+		}
+		frames = append(frames, p)
+	}
+	return frames
+}
+
+func writeFrames(t *testing.T, frames [][]byte) []byte {
+	var out bytes.Buffer
+	fw := NewFrameWriter(&out, 1<<20)
+	for _, p := range frames {
+		if err := fw.WriteFrameBytes(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out.Bytes()
+}
+
+//不完整的读取被拼接为完整的帧
+func TestFrameSplitReads(t *testing.T) {
+	frames := testFrames()
+	data := writeFrames(t, frames)
+	readers := map[string]func(io.Reader) io.Reader{
+		"full":    func(r io.Reader) io.Reader { return r },
+		"onebyte": iotest.OneByteReader,
+		"half":    iotest.HalfReader,
+		"dataerr": iotest.DataErrReader,
+	}
+	for name, wrap := range readers {
+		fr := NewFrameReader(wrap(bytes.NewReader(data)), 1<<20)
+		for i, want := range frames {
+			frame, err := fr.ReadFrame()
+			if err != nil {
+				t.Fatalf("%s: frame %d: %v", name, i, err)
+			}
+			if got := frame.GetByte()[:frame.GetTop()]; !bytes.Equal(got, want) || frame.GetOffset() != 0 {
+				t.Fatalf("%s: frame %d: len %d, want %d", name, i, len(got), len(want))
+			}
+			Release(frame)
+		}
+		if _, err := fr.ReadFrame(); err != io.EOF {
+			t.Errorf("%s: after last frame err = %v; want io.EOF", name, err)
+		}
+	}
+}
+
+func TestFrameTruncated(t *testing.T) {
+	data := writeFrames(t, [][]byte{make([]byte, 0x4000)})
+	//4字节前缀只读到一部分
+	for _, n := range []int{1, 2, 3} {
+		fr := NewFrameReader(bytes.NewReader(data[:n]), 1<<20)
+		if _, err := fr.ReadFrame(); err != io.ErrUnexpectedEOF {
+			t.Errorf("header %d bytes: err = %v; want io.ErrUnexpectedEOF", n, err)
+		}
+	}
+	//内容不完整
+	fr := NewFrameReader(bytes.NewReader(data[:len(data)-1]), 1<<20)
+	if _, err := fr.ReadFrame(); err != io.ErrUnexpectedEOF {
+		t.Errorf("body: err = %v; want io.ErrUnexpectedEOF", err)
+	}
+	//不合法的长度前缀
+	fr = NewFrameReader(bytes.NewReader([]byte{0x1f, 0, 0, 0}), 1<<20)
+	if _, err := fr.ReadFrame(); err != ErrBadLength {
+		t.Errorf("bad prefix: err = %v; want ErrBadLength", err)
+	}
+}
+
+func TestFrameOversized(t *testing.T) {
+	var out bytes.Buffer
+	fw := NewFrameWriter(&out, 16)
+	if err := fw.WriteFrameBytes(make([]byte, 17)); err != ErrTooLarge {
+		t.Errorf("WriteFrameBytes err = %v; want ErrTooLarge", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("oversized frame wrote %d bytes", out.Len())
+	}
+
+	data := writeFrames(t, [][]byte{make([]byte, 17)})
+	fr := NewFrameReader(bytes.NewReader(data), 16)
+	if _, err := fr.ReadFrame(); err != ErrTooLarge {
+		t.Errorf("ReadFrame err = %v; want ErrTooLarge", err)
+	}
+
+	fr = NewFrameReader(bytes.NewReader(data), 0)
+	fr.SetLimits(Limits{MaxTotal: 16})
+	var le *LimitError
+	if _, err := fr.ReadFrame(); !errors.As(err, &le) || le.Kind != LimitTotal || le.Len != 17 {
+		t.Errorf("ReadFrame over MaxTotal err = %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+/********************************************************/
+// 字节对象（标准io接口实现）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.NewBuffer()
+//			w:=bufio.NewWriter(conn)
+//			buf.WriteTo(w)
+/********************************************************/
+
+package byt
+
+import (
+	"io"
+)
+
+/**
+ * ReadFrom每次扩容的最小长度
+ */
+const __minread__ int = 512
+
+//编译期检查接口实现
+var (
+	_ io.Reader     = (*Buffer)(nil)
+	_ io.Writer     = (*Buffer)(nil)
+	_ io.ByteReader = (*Buffer)(nil)
+	_ io.ByteWriter = (*Buffer)(nil)
+	_ io.ReaderFrom = (*Buffer)(nil)
+	_ io.WriterTo   = (*Buffer)(nil)
+)
+
+/**
+ * 读取数据至p中（io.Reader）
+ * 分块模式下每次最多读取至当前块的末尾
+ * @param p 目标字节数组
+ * @return 读取的字节数，若没有剩余可读取的内容则返回io.EOF
+ */
+func (b *Buffer) Read(p []byte) (n int, err error) {
+	b.live()
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if !b.HasRemaining() {
+		return 0, io.EOF
+	}
+	data, _ := b.next(min(len(p), b.Remaining(), b.run(b.offset)))
+	return copy(p, data), nil
+}
+
+/**
+ * 将p写入至字节缓冲对象中（io.Writer）
+ * @param p 要被写入的字节数组
+ * @return 写入的字节数，超出内容总长度的限制时不写入并返回*LimitError
+ */
+func (b *Buffer) Write(p []byte) (n int, err error) {
+	if err := b.CheckLimit(LimitTotal, b.top+len(p)); err != nil {
+		return 0, err
+	}
+	b.WriteBytes(p, 0, len(p))
+	return len(p), nil
+}
+
+/**
+ * 读取一个字节（io.ByteReader）
+ * @return 字节值，若没有剩余可读取的内容则返回io.EOF，已记录读取错误（参见Err）时返回该错误
+ */
+func (b *Buffer) ReadByte() (byte, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if !b.HasRemaining() {
+		return 0, io.EOF
+	}
+	return b.TryReadUnsignedByt()
+}
+
+/**
+ * 写入一个字节（io.ByteWriter）
+ * @param c 字节值
+ * @return 错误信息始终为nil
+ */
+func (b *Buffer) WriteByte(c byte) error {
+	b.WriteUnsignedByt(c)
+	return nil
+}
+
+/**
+ * 从r中读取数据直至io.EOF，并追加至字节缓冲对象的尾部（io.ReaderFrom）
+ * @param r 数据源
+ * @return 读取的字节数，读取过程中发生的错误（io.EOF不视为错误，超出内容总长度的限制时返回*LimitError）
+ */
+func (b *Buffer) ReadFrom(r io.Reader) (n int64, err error) {
+	_max := b.limits.max(LimitTotal)
+	if err := b.CheckLimit(LimitTotal, b.top); err != nil {
+		return 0, err
+	}
+	for {
+		if b.capacity()-b.top < __minread__ {
+			b.SetCapacity(b.top + __minread__)
+		}
+		//多读取1个字节用于判断是否超出限制（分块模式下每次最多读取至当前块的末尾）
+		_end := b.top + b.run(b.top)
+		if _max < _end-1 {
+			_end = _max + 1
+		}
+		m, e := r.Read(b.span(b.top, _end))
+		if m < 0 {
+			panic("byt: reader returned negative count from Read")
+		}
+		if m > 0 {
+			b.top += m
+			b.wbits = 0
+		}
+		n += int64(m)
+		if b.top > _max {
+			_len := b.top
+			n -= int64(_len - _max) //超出的部分不保留
+			b.top = _max
+			return n, &LimitError{Kind: LimitTotal, Len: _len, Max: _max}
+		}
+		if e == io.EOF {
+			return n, nil
+		}
+		if e != nil {
+			return n, e
+		}
+	}
+}
+
+/**
+ * 将剩余可读取的内容写入至w中（io.WriterTo）
+ * 分块模式下按块写入（w为net.Conn时通过一次writev写入）
+ * @param w 写入目标
+ * @return 写入的字节数，写入过程中发生的错误
+ */
+func (b *Buffer) WriteTo(w io.Writer) (n int64, err error) {
+	b.live()
+	l := b.Remaining()
+	if l <= 0 {
+		return 0, nil
+	}
+	if b.chunks != nil {
+		_bufs := b.buffers(b.offset, b.top)
+		n, err = _bufs.WriteTo(w)
+		b.offset += int(n)
+		b.rbits = 0
+		return n, err
+	}
+	m, e := w.Write(b.byt[b.offset:b.top])
+	if m > l {
+		panic("byt: invalid Write count")
+	}
+	b.next(m)
+	n = int64(m)
+	if e != nil {
+		return n, e
+	}
+	if m != l {
+		return n, io.ErrShortWrite
+	}
+	return n, nil
+}
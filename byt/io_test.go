@@ -0,0 +1,40 @@
+package byt
+
+import (
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+//io.ByteReader：读取至末尾返回io.EOF，已记录读取错误时返回该错误而不是0
+func TestReadByte(t *testing.T) {
+	b := NewBuffer()
+	b.WriteUvarint(300)
+	b.WriteUnsignedByt(7)
+	if v, err := binary.ReadUvarint(b); v != 300 || err != nil {
+		t.Fatalf("ReadUvarint = %d, %v", v, err)
+	}
+	if c, err := b.ReadByte(); c != 7 || err != nil {
+		t.Fatalf("ReadByte = %d, %v", c, err)
+	}
+	if _, err := b.ReadByte(); err != io.EOF {
+		t.Fatalf("ReadByte at end err = %v; want io.EOF", err)
+	}
+
+	b = NewBufferWithByte([]byte{1, 2, 3})
+	b.ReadLong()
+	if b.Err() != ErrUnderflow {
+		t.Fatalf("Err = %v", b.Err())
+	}
+	if _, err := b.ReadByte(); err != ErrUnderflow || b.GetOffset() != 0 {
+		t.Errorf("ReadByte after error = %v, offset %d", err, b.GetOffset())
+	}
+	//不会无限地返回0
+	if _, err := binary.ReadUvarint(b); err != ErrUnderflow {
+		t.Errorf("ReadUvarint after error = %v", err)
+	}
+	b.ClearErr()
+	if c, err := b.ReadByte(); c != 1 || err != nil {
+		t.Errorf("ReadByte after ClearErr = %d, %v", c, err)
+	}
+}
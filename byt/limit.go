@@ -0,0 +1,138 @@
+/********************************************************/
+// 字节对象（读取长度限制）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.SetLimits(byt.Limits{MaxString: 1024, MaxData: 1024, MaxCount: 64, MaxTotal: 4096})
+//			name,err:=buf.TryReadUTF8String()
+//			var le *byt.LimitError
+//			if errors.As(err, &le) {...}		//le.Kind为LimitString，errors.Is(err, byt.ErrTooLarge)同样成立
+//
+//			fr:=byt.NewFrameReader(conn, 0)
+//			fr.SetLimits(byt.Limits{MaxData: 8 << 20})	//读取的每帧均使用该限制
+/********************************************************/
+
+package byt
+
+import (
+	"math"
+	"strconv"
+)
+
+/**
+ * 读取长度限制
+ * 各项为0时使用默认最大数据长度__maxlength__（MaxTotal默认不限制），<0时不限制
+ */
+type Limits struct {
+	MaxString int //字符串的最大字节数（UTF8String、UTF、String、CString）
+	MaxData   int //字节数组的最大长度（Data、压缩及加密的区域、消息字段的内容）
+	MaxCount  int //数组、映射的最大元素数量
+	MaxTotal  int //字节缓冲对象的最大内容长度（通过io.Writer、io.ReaderFrom写入及FrameReader读取的帧、解压后的内容）
+}
+
+/**
+ * 长度限制的种类
+ */
+type LimitKind byte
+
+const (
+	LimitString LimitKind = iota //字符串长度
+	LimitData                    //字节数组长度
+	LimitCount                   //元素数量
+	LimitTotal                   //内容总长度
+)
+
+var (
+	__limitnames__ = [...]string{"string", "data", "count", "total"}
+	__limitdescs__ = [...]string{"字符串长度", "字节数组长度", "元素数量", "内容总长度"}
+)
+
+func (k LimitKind) String() string {
+	if int(k) < len(__limitnames__) {
+		return __limitnames__[k]
+	}
+	return "limit(" + strconv.Itoa(int(k)) + ")"
+}
+
+/**
+ * 超出长度限制的错误（errors.Is(err, ErrTooLarge)成立）
+ */
+type LimitError struct {
+	Kind LimitKind //超出的限制
+	Len  int       //实际的长度或数量
+	Max  int       //限制值
+}
+
+func (e *LimitError) Error() string {
+	_desc := e.Kind.String()
+	if int(e.Kind) < len(__limitdescs__) {
+		_desc = __limitdescs__[e.Kind]
+	}
+	return "byt: " + _desc + strconv.Itoa(e.Len) + "超出限制" + strconv.Itoa(e.Max)
+}
+
+func (e *LimitError) Unwrap() error {
+	return ErrTooLarge
+}
+
+/**
+ * 设置读取长度限制（Slice、Duplicate及ReadCompressed等返回的对象继承该限制）
+ * @param l 长度限制
+ */
+func (b *Buffer) SetLimits(l Limits) {
+	b.limits = l
+}
+
+/**
+ * 获取读取长度限制（与SetLimits设置的值相同）
+ */
+func (b *Buffer) Limits() Limits {
+	return b.limits
+}
+
+/**
+ * 检查长度或数量是否超出限制（供自定义的解码代码使用）
+ * @param kind 限制的种类
+ * @param n 长度或数量
+ * @return 超出时返回*LimitError，否则返回nil
+ */
+func (b *Buffer) CheckLimit(kind LimitKind, n int) error {
+	return b.limits.check(kind, n)
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//检查长度或数量是否超出限制
+func (l *Limits) check(kind LimitKind, n int) error {
+	if _max := l.max(kind); n > _max {
+		return &LimitError{Kind: kind, Len: n, Max: _max}
+	}
+	return nil
+}
+
+//生效的限制值
+func (l *Limits) max(kind LimitKind) int {
+	var v int
+	switch kind {
+	case LimitString:
+		v = l.MaxString
+	case LimitData:
+		v = l.MaxData
+	case LimitCount:
+		v = l.MaxCount
+	case LimitTotal:
+		if l.MaxTotal == 0 {
+			return math.MaxInt
+		}
+		v = l.MaxTotal
+	}
+	if v == 0 {
+		return __maxlength__
+	}
+	if v < 0 {
+		return math.MaxInt
+	}
+	return v
+}
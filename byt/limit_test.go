@@ -0,0 +1,110 @@
+package byt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+//ReadFrom读取至MaxTotal为止，超出的部分不保留
+func TestReadFromMaxTotal(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	buffers := map[string]func() *Buffer{
+		"flat":    NewBuffer,
+		"chunked": func() *Buffer { return NewChunkedBuffer(7) },
+	}
+	readers := map[string]func(io.Reader) io.Reader{
+		"full":    func(r io.Reader) io.Reader { return r },
+		"onebyte": iotest.OneByteReader,
+	}
+	for bn, nb := range buffers {
+		for rn, wrap := range readers {
+			name := bn + "/" + rn
+			//恰好等于限制
+			b := nb()
+			b.SetLimits(Limits{MaxTotal: 100})
+			if n, err := b.ReadFrom(wrap(bytes.NewReader(data))); n != 100 || err != nil {
+				t.Errorf("%s: at limit ReadFrom = %d, %v", name, n, err)
+			}
+
+			b = nb()
+			b.WriteInt(-1)
+			b.SetLimits(Limits{MaxTotal: 50})
+			n, err := b.ReadFrom(wrap(bytes.NewReader(data)))
+			var le *LimitError
+			if !errors.As(err, &le) || le.Kind != LimitTotal || le.Max != 50 || le.Len <= 50 {
+				t.Fatalf("%s: ReadFrom err = %v", name, err)
+			}
+			if !errors.Is(err, ErrTooLarge) {
+				t.Errorf("%s: errors.Is(%v, ErrTooLarge) = false", name, err)
+			}
+			if n != 46 || b.GetTop() != 50 {
+				t.Errorf("%s: n = %d, top %d; want 46, 50", name, n, b.GetTop())
+			}
+			_got := make([]byte, 46)
+			if b.ReadInt() != -1 || b.TryReadBytes(_got, 0, 46) != nil || !bytes.Equal(_got, data[:46]) {
+				t.Errorf("%s: content changed", name)
+			}
+
+			//已超出限制时不读取
+			if n, err := b.ReadFrom(wrap(bytes.NewReader(data))); n != 0 || !errors.Is(err, ErrTooLarge) {
+				t.Errorf("%s: over limit ReadFrom = %d, %v", name, n, err)
+			}
+		}
+	}
+	//<0时不限制
+	b := NewBuffer()
+	b.SetLimits(Limits{MaxTotal: -1})
+	if n, err := b.ReadFrom(bytes.NewReader(data)); n != 100 || err != nil {
+		t.Errorf("unlimited ReadFrom = %d, %v", n, err)
+	}
+}
+
+//各种读取超出限制时返回可以展开为ErrTooLarge的*LimitError
+func TestLimitErrorUnwrap(t *testing.T) {
+	b := NewBuffer()
+	b.WriteUTF8String(strings.Repeat("a", 20))
+	b.WriteData(make([]byte, 20))
+	b.WriteLength(21) //20个元素
+	cases := []struct {
+		limits Limits
+		kind   LimitKind
+		len    int
+		read   func() error
+	}{
+		{Limits{MaxString: 10}, LimitString, 20, func() error { _, err := b.TryReadUTF8String(); return err }},
+		{Limits{MaxData: 10}, LimitData, 20, func() error { _, err := b.TryReadData(); return err }},
+		{Limits{MaxCount: 10}, LimitCount, 20, func() error { _, err := b.TryReadCount(); return err }},
+		{Limits{MaxTotal: 10}, LimitTotal, 63, func() error { _, err := b.Write(make([]byte, 20)); return err }},
+	}
+	_off := []int{0, 21, 42, 0}
+	for i, c := range cases {
+		b.SetOffet(_off[i])
+		b.SetLimits(c.limits)
+		err := c.read()
+		var le *LimitError
+		if !errors.As(err, &le) || le.Kind != c.kind || le.Max != 10 || le.Len != c.len {
+			t.Errorf("%s: err = %#v", c.kind, err)
+			continue
+		}
+		if !errors.Is(err, ErrTooLarge) || errors.Unwrap(err) != ErrTooLarge {
+			t.Errorf("%s: %v does not unwrap to ErrTooLarge", c.kind, err)
+		}
+		if b.GetOffset() != _off[i] {
+			t.Errorf("%s: offset moved to %d", c.kind, b.GetOffset())
+		}
+		if got := b.CheckLimit(c.kind, 10); got != nil {
+			t.Errorf("%s: CheckLimit at limit = %v", c.kind, got)
+		}
+	}
+	b.SetLimits(Limits{MaxData: 10})
+	if err := b.CheckLimit(LimitData, 11); err == nil || err.Error() != "byt: 字节数组长度11超出限制10" {
+		t.Errorf("CheckLimit = %v", err)
+	}
+}
@@ -0,0 +1,805 @@
+/********************************************************/
+// 字节对象（结构体序列化）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			type Player struct {
+//				Id    int32
+//				Name  string
+//				Level int64   `byt:"varint"`
+//				Score []int32 `byt:"int16"`
+//				Cache string  `byt:"-"`
+//			}
+//			data,err:=byt.Marshal(&p)
+//			err=byt.Unmarshal(data,&p)
+/********************************************************/
+
+package byt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/*
+ * 字段的编码方式由字段类型决定，可通过`byt:"..."`标签指定：
+ *	bool                  WriteBoolean
+ *	int8 / uint8          WriteByt / WriteUnsignedByt
+ *	int16 / uint16        WriteShort / WriteUnsignedShort
+ *	int32 / uint32        WriteInt / WriteUnsignedInt
+ *	int64 / uint64        WriteLong / WriteUnsignedLong（int、uint同样按64位写入）
+ *	float32 / float64     WriteFloat32 / WriteFloat
+ *	complex64 / complex128 WriteComplex64 / WriteComplex128
+ *	varint / uvarint      WriteVarint / WriteUvarint
+ *	length                WriteLength
+ *	utf8                  WriteUTF8String（string的默认方式）
+ *	utf                   WriteUTF
+ *	data                  WriteData（[]byte的默认方式）
+ *	-                     忽略该字段
+ *
+ * 切片及映射先写入WriteLength(元素数量+1)（nil写入0），再依次写入各元素（映射按键的编码结果排序）；
+ * []byte按WriteData写入（nil与空数组相同，读取时长度值0视为nil）；
+ * 数组不写入长度；指针先写入WriteBoolean(是否非nil)。
+ * 切片、数组、映射及指针字段上的标签作用于元素（映射作用于值）。
+ *
+ * 标签中编码方式之后可以附加选项（如`byt:"varint,tag=3,default=1"`）：
+ *	tag=N                 字段编号，带有编号的结构体按消息格式序列化（参见message.go）
+ *	default=V             读取的消息中没有该字段时使用的值（bool、整数、浮点数及string）
+ */
+
+/**
+ * 不支持序列化的类型
+ */
+type UnsupportedTypeError struct {
+	Type reflect.Type
+	Wire string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	if e.Wire != "" {
+		return "byt: 类型" + e.Type.String() + "不支持编码方式" + e.Wire
+	}
+	return "byt: 不支持的类型" + e.Type.String()
+}
+
+/**
+ * 自定义序列化接口（bytgen生成的代码实现了该接口，WriteObject会优先使用）
+ */
+type Marshaler interface {
+	MarshalByt(b *Buffer) error
+}
+
+/**
+ * 自定义反序列化接口（bytgen生成的代码实现了该接口，ReadObject会优先使用）
+ */
+type Unmarshaler interface {
+	UnmarshalByt(b *Buffer) error
+}
+
+/**
+ * 将v序列化为字节数组（使用默认编码模式）
+ * @param v 结构体或结构体指针（也可以是其他支持的类型）
+ * @return 字节数组，错误信息
+ */
+func Marshal(v interface{}) ([]byte, error) {
+	b := NewBuffer()
+	if err := b.WriteObject(v); err != nil {
+		return nil, err
+	}
+	return b.GetByte()[:b.GetTop()], nil
+}
+
+/**
+ * 将字节数组反序列化至v中（使用默认编码模式）
+ * @param data 字节数组
+ * @param v 非nil指针
+ * @return 错误信息
+ */
+func Unmarshal(data []byte, v interface{}) error {
+	if data == nil {
+		data = []byte{}
+	}
+	return NewBufferWithByte(data).ReadObject(v)
+}
+
+/**
+ * 将v序列化后写入字节缓冲对象（使用字节缓冲对象的编码模式）
+ * 最外层的指针不写入是否为nil的标记，与ReadObject(&v)对应
+ * @param v 结构体或结构体指针（也可以是其他支持的类型）
+ * @return 错误信息
+ */
+func (b *Buffer) WriteObject(v interface{}) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteObject", false)
+		defer func() { b.traceEnd(_t_, fmt.Sprintf("%T", v), err) }()
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr {
+		return errors.New("byt: WriteObject(nil)")
+	}
+	_top := b.top
+	if err := encodeValue(b, rv, ""); err != nil {
+		b.top = _top
+		return err
+	}
+	return nil
+}
+
+/**
+ * 从字节缓冲对象中读取内容并反序列化至v中（使用字节缓冲对象的编码模式）
+ * 发生错误时偏移位置恢复至读取前的位置
+ * @param v 非nil指针
+ * @return 错误信息
+ */
+func (b *Buffer) ReadObject(v interface{}) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadObject", false)
+		defer func() { b.traceEnd(_t_, fmt.Sprintf("%T", v), err) }()
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("byt: ReadObject需要一个非nil指针")
+	}
+	_start, _bits := b.offset, b.rbits
+	if err := decodeValue(b, rv.Elem(), ""); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return err
+	}
+	return nil
+}
+
+/**
+ * 读取切片或映射的元素数量（WriteLength(元素数量+1)的形式，0表示nil）
+ * @return 元素数量（nil时为-1），错误信息（ErrUnderflow、ErrBadLength、*LimitError）
+ */
+func (b *Buffer) TryReadCount() (int, error) {
+	_start, _bits := b.offset, b.rbits
+	l, err := b.TryReadLength()
+	if err != nil {
+		return 0, err
+	}
+	if err := b.CheckLimit(LimitCount, l-1); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return 0, err
+	}
+	return l - 1, nil
+}
+
+/**
+ * 计算映射的写入顺序（按键的编码结果排序，供bytgen生成的代码使用）
+ * @param order 编码模式
+ * @param n 键的数量
+ * @param key 将第i个键写入kb的函数
+ * @return 排序后的键下标
+ */
+func SortKeys(order binary.ByteOrder, n int, key func(kb *Buffer, i int)) []int {
+	keys := make([][]byte, n)
+	index := make([]int, n)
+	kb := NewBufferWithOrder(order)
+	for i := 0; i < n; i++ {
+		kb.Zero()
+		key(kb, i)
+		keys[i] = append([]byte(nil), kb.GetByte()[:kb.GetTop()]...)
+		index[i] = i
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return bytes.Compare(keys[index[i]], keys[index[j]]) < 0
+	})
+	return index
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部实现
+
+var (
+	typeUint128     = reflect.TypeOf(Uint128{})
+	typeUUID        = reflect.TypeOf(UUID{})
+	typeMarshaler   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	typeUnmarshaler = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+	//结构体字段缓存（reflect.Type => []fieldInfo）
+	fieldCache sync.Map
+)
+
+type fieldInfo struct {
+	index int
+	wire  string
+	tag   int    //字段编号（tag=N，没有时为0）
+	def   string //默认值（default=V）
+}
+
+//获取结构体需要序列化的字段
+func structFields(t reflect.Type) []fieldInfo {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]fieldInfo)
+	}
+	fields := make([]fieldInfo, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue //未导出的字段
+		}
+		tag := sf.Tag.Get("byt")
+		if tag == "-" {
+			continue
+		}
+		f := fieldInfo{index: i, wire: tag}
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			f.wire = tag[:j]
+			for _, opt := range strings.Split(tag[j+1:], ",") {
+				if n, ok := strings.CutPrefix(opt, "tag="); ok {
+					if f.tag, _ = strconv.Atoi(n); f.tag <= 0 {
+						f.tag = -1 //编号不合法，由messageOf报告错误
+					}
+				} else if d, ok := strings.CutPrefix(opt, "default="); ok {
+					f.def = d
+				}
+			}
+		}
+		fields = append(fields, f)
+	}
+	f, _ := fieldCache.LoadOrStore(t, fields)
+	return f.([]fieldInfo)
+}
+
+func isByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func encodeValue(b *Buffer, v reflect.Value, wire string) error {
+	t := v.Type()
+	switch {
+	case wire == "" && v.Kind() != reflect.Ptr && v.CanAddr() && v.Addr().Type().Implements(typeMarshaler):
+		return v.Addr().Interface().(Marshaler).MarshalByt(b)
+	case t == typeUint128:
+		b.WriteUint128(v.Interface().(Uint128))
+		return nil
+	case t == typeUUID:
+		b.WriteUUID(v.Interface().(UUID))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		b.WriteBoolean(!v.IsNil())
+		if v.IsNil() {
+			return nil
+		}
+		return encodeValue(b, v.Elem(), wire)
+
+	case reflect.Slice:
+		if isByteSlice(t) && (wire == "" || wire == "data") {
+			b.WriteData(v.Bytes()) //nil与空数组相同（与WriteData一致）
+			return nil
+		}
+		if v.IsNil() {
+			b.WriteLength(0)
+			return nil
+		}
+		b.WriteLength(v.Len() + 1)
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(b, v.Index(i), wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(b, v.Index(i), wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteLength(0)
+			return nil
+		}
+		b.WriteLength(v.Len() + 1)
+		return encodeMap(b, v, wire)
+
+	case reflect.Struct:
+		if m, err := messageOf(t); err != nil {
+			return err
+		} else if m != nil {
+			return encodeMessage(b, v, m)
+		}
+		for _, f := range structFields(t) {
+			if err := encodeValue(b, v.Field(f.index), f.wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		return &UnsupportedTypeError{Type: t}
+	}
+	return encodeLeaf(b, v, wire)
+}
+
+//映射按键的编码结果排序，保证相同的内容得到相同的字节
+func encodeMap(b *Buffer, v reflect.Value, wire string) error {
+	type entry struct {
+		key []byte
+		val reflect.Value
+		kb  *Buffer
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		kb := NewBufferWithOrder(b.Order())
+		if b.tracing() {
+			kb.StartTrace()
+		}
+		if err := encodeValue(kb, iter.Key(), ""); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: kb.GetByte()[:kb.GetTop()], val: iter.Value(), kb: kb})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	for _, e := range entries {
+		b.writeKey(e.kb)
+		if err := encodeValue(b, e.val, wire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//默认编码方式
+func defaultWire(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int8:
+		return "int8"
+	case reflect.Uint8:
+		return "uint8"
+	case reflect.Int16:
+		return "int16"
+	case reflect.Uint16:
+		return "uint16"
+	case reflect.Int32:
+		return "int32"
+	case reflect.Uint32:
+		return "uint32"
+	case reflect.Int64, reflect.Int:
+		return "int64"
+	case reflect.Uint64, reflect.Uint, reflect.Uintptr:
+		return "uint64"
+	case reflect.Float32:
+		return "float32"
+	case reflect.Float64:
+		return "float64"
+	case reflect.Complex64:
+		return "complex64"
+	case reflect.Complex128:
+		return "complex128"
+	case reflect.String:
+		return "utf8"
+	}
+	return ""
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+//整数（有符号或无符号）的值
+func intOf(v reflect.Value) (int64, bool) {
+	if isIntKind(v.Kind()) {
+		return v.Int(), true
+	}
+	if isUintKind(v.Kind()) {
+		return int64(v.Uint()), true
+	}
+	return 0, false
+}
+
+func encodeLeaf(b *Buffer, v reflect.Value, wire string) error {
+	k := v.Kind()
+	if wire == "" {
+		wire = defaultWire(k)
+	}
+	bad := &UnsupportedTypeError{Type: v.Type(), Wire: wire}
+
+	switch wire {
+	case "bool":
+		if k != reflect.Bool {
+			return bad
+		}
+		b.WriteBoolean(v.Bool())
+
+	case "int8", "uint8", "int16", "uint16", "int32", "uint32", "int64", "uint64", "varint", "uvarint", "length":
+		n, ok := intOf(v)
+		if !ok {
+			return bad
+		}
+		switch wire {
+		case "int8":
+			b.WriteByt(int8(n))
+		case "uint8":
+			b.WriteUnsignedByt(uint8(n))
+		case "int16":
+			b.WriteShort(int16(n))
+		case "uint16":
+			b.WriteUnsignedShort(uint16(n))
+		case "int32":
+			b.WriteInt(int32(n))
+		case "uint32":
+			b.WriteUnsignedInt(uint32(n))
+		case "int64":
+			b.WriteLong(n)
+		case "uint64":
+			b.WriteUnsignedLong(uint64(n))
+		case "varint":
+			b.WriteVarint(n)
+		case "uvarint":
+			b.WriteUvarint(uint64(n))
+		case "length":
+			b.WriteLength(int(n))
+		}
+
+	case "float32", "float64":
+		if k != reflect.Float32 && k != reflect.Float64 {
+			return bad
+		}
+		if wire == "float32" {
+			b.WriteFloat32(float32(v.Float()))
+		} else {
+			b.WriteFloat(v.Float())
+		}
+
+	case "complex64", "complex128":
+		if k != reflect.Complex64 && k != reflect.Complex128 {
+			return bad
+		}
+		if wire == "complex64" {
+			b.WriteComplex64(complex64(v.Complex()))
+		} else {
+			b.WriteComplex128(v.Complex())
+		}
+
+	case "utf8", "utf", "data":
+		if k != reflect.String {
+			return bad
+		}
+		switch wire {
+		case "utf8":
+			b.WriteUTF8String(v.String())
+		case "utf":
+			b.WriteUTF(v.String())
+		case "data":
+			b.WriteDataString(v.String())
+		}
+
+	default:
+		return bad
+	}
+	return nil
+}
+
+func decodeValue(b *Buffer, v reflect.Value, wire string) error {
+	t := v.Type()
+	switch {
+	case wire == "" && v.Kind() != reflect.Ptr && v.CanAddr() && v.Addr().Type().Implements(typeUnmarshaler):
+		return v.Addr().Interface().(Unmarshaler).UnmarshalByt(b)
+	case t == typeUint128:
+		x, err := b.TryReadUint128()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	case t == typeUUID:
+		x, err := b.TryReadUUID()
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		ok, err := b.TryReadBoolean()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decodeValue(b, v.Elem(), wire)
+
+	case reflect.Slice:
+		if isByteSlice(t) && (wire == "" || wire == "data") {
+			//长度值0为之前的版本写入的nil
+			if n, err := b.PeekLength(); err != nil {
+				return err
+			} else if n == 0 {
+				b.TryReadLength()
+				v.Set(reflect.Zero(t))
+				return nil
+			}
+			data, err := b.TryReadData()
+			if err != nil {
+				return err
+			}
+			v.SetBytes(data)
+			return nil
+		}
+		n, err := b.TryReadCount()
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		s := reflect.MakeSlice(t, 0, min(n, b.Remaining()))
+		for i := 0; i < n; i++ {
+			e := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(b, e, wire); err != nil {
+				return err
+			}
+			s = reflect.Append(s, e)
+		}
+		v.Set(s)
+		return nil
+
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := decodeValue(b, v.Index(i), wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		n, err := b.TryReadCount()
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		m := reflect.MakeMapWithSize(t, min(n, b.Remaining()))
+		for i := 0; i < n; i++ {
+			k := reflect.New(t.Key()).Elem()
+			if err := decodeValue(b, k, ""); err != nil {
+				return err
+			}
+			e := reflect.New(t.Elem()).Elem()
+			if err := decodeValue(b, e, wire); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, e)
+		}
+		v.Set(m)
+		return nil
+
+	case reflect.Struct:
+		if m, err := messageOf(t); err != nil {
+			return err
+		} else if m != nil {
+			return decodeMessage(b, v, m)
+		}
+		for _, f := range structFields(t) {
+			if err := decodeValue(b, v.Field(f.index), f.wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Interface:
+		return &UnsupportedTypeError{Type: t}
+	}
+	return decodeLeaf(b, v, wire)
+}
+
+func setInt(v reflect.Value, n int64) error {
+	if isIntKind(v.Kind()) {
+		if v.OverflowInt(n) {
+			return ErrOverflow
+		}
+		v.SetInt(n)
+		return nil
+	}
+	if n < 0 || v.OverflowUint(uint64(n)) {
+		return ErrOverflow
+	}
+	v.SetUint(uint64(n))
+	return nil
+}
+
+func setUint(v reflect.Value, n uint64) error {
+	if isUintKind(v.Kind()) {
+		if v.OverflowUint(n) {
+			return ErrOverflow
+		}
+		v.SetUint(n)
+		return nil
+	}
+	if int64(n) < 0 || v.OverflowInt(int64(n)) {
+		return ErrOverflow
+	}
+	v.SetInt(int64(n))
+	return nil
+}
+
+func decodeLeaf(b *Buffer, v reflect.Value, wire string) error {
+	k := v.Kind()
+	if wire == "" {
+		wire = defaultWire(k)
+	}
+	bad := &UnsupportedTypeError{Type: v.Type(), Wire: wire}
+
+	switch wire {
+	case "bool":
+		if k != reflect.Bool {
+			return bad
+		}
+		x, err := b.TryReadBoolean()
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+
+	case "int8", "int16", "int32", "int64", "varint", "length":
+		if !isIntKind(k) && !isUintKind(k) {
+			return bad
+		}
+		var (
+			n   int64
+			err error
+		)
+		switch wire {
+		case "int8":
+			var x int8
+			x, err = b.TryReadByt()
+			n = int64(x)
+		case "int16":
+			var x int16
+			x, err = b.TryReadShort()
+			n = int64(x)
+		case "int32":
+			var x int32
+			x, err = b.TryReadInt()
+			n = int64(x)
+		case "int64":
+			n, err = b.TryReadLong()
+		case "varint":
+			n, err = b.TryReadVarint()
+		case "length":
+			var x int
+			x, err = b.TryReadLength()
+			n = int64(x)
+		}
+		if err != nil {
+			return err
+		}
+		return setInt(v, n)
+
+	case "uint8", "uint16", "uint32", "uint64", "uvarint":
+		if !isIntKind(k) && !isUintKind(k) {
+			return bad
+		}
+		var (
+			n   uint64
+			err error
+		)
+		switch wire {
+		case "uint8":
+			var x uint8
+			x, err = b.TryReadUnsignedByt()
+			n = uint64(x)
+		case "uint16":
+			var x uint16
+			x, err = b.TryReadUnsignedShort()
+			n = uint64(x)
+		case "uint32":
+			var x uint32
+			x, err = b.TryReadUnsignedInt()
+			n = uint64(x)
+		case "uint64":
+			n, err = b.TryReadUnsignedLong()
+		case "uvarint":
+			n, err = b.TryReadUvarint()
+		}
+		if err != nil {
+			return err
+		}
+		return setUint(v, n)
+
+	case "float32", "float64":
+		if k != reflect.Float32 && k != reflect.Float64 {
+			return bad
+		}
+		var (
+			x   float64
+			err error
+		)
+		if wire == "float32" {
+			var f float32
+			f, err = b.TryReadFloat32()
+			x = float64(f)
+		} else {
+			x, err = b.TryReadFloat()
+		}
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+
+	case "complex64", "complex128":
+		if k != reflect.Complex64 && k != reflect.Complex128 {
+			return bad
+		}
+		var (
+			x   complex128
+			err error
+		)
+		if wire == "complex64" {
+			var c complex64
+			c, err = b.TryReadComplex64()
+			x = complex128(c)
+		} else {
+			x, err = b.TryReadComplex128()
+		}
+		if err != nil {
+			return err
+		}
+		v.SetComplex(x)
+
+	case "utf8", "utf", "data":
+		if k != reflect.String {
+			return bad
+		}
+		var (
+			s   string
+			err error
+		)
+		switch wire {
+		case "utf8":
+			s, err = b.TryReadUTF8String()
+		case "utf":
+			s, err = b.TryReadUTF()
+		case "data":
+			var d []byte
+			d, err = b.TryReadData()
+			s = string(d)
+		}
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+
+	default:
+		return bad
+	}
+	return nil
+}
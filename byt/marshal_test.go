@@ -0,0 +1,64 @@
+package byt
+
+import (
+	"bytes"
+	"testing"
+)
+
+//nil字节数组与WriteData(nil)写入的内容相同，可以由ReadData读取
+func TestMarshalNilBytes(t *testing.T) {
+	type data struct {
+		A []byte
+		B []byte `byt:"data"`
+		C []byte
+	}
+	p, err := Marshal(&data{C: []byte{7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb := NewBuffer()
+	hb.WriteData(nil)
+	hb.WriteData(nil)
+	hb.WriteData([]byte{7})
+	if want := hb.GetByte()[:hb.GetTop()]; !bytes.Equal(p, want) {
+		t.Fatalf("Marshal = % x; want % x", p, want)
+	}
+	b := NewBufferWithByte(p)
+	for i, want := range [][]byte{{}, {}, {7}} {
+		if v, err := b.TryReadData(); err != nil || !bytes.Equal(v, want) {
+			t.Errorf("field %d: TryReadData = %v, %v", i, v, err)
+		}
+	}
+
+	//之前的版本将nil写为长度值0，仍然可以读取
+	var v data
+	if err := Unmarshal([]byte{0x80, 0x81, 0x82, 7}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A != nil || v.B == nil || len(v.B) != 0 || !bytes.Equal(v.C, []byte{7}) {
+		t.Errorf("Unmarshal = %#v", v)
+	}
+}
+
+//data编码的字符串与WriteData([]byte(s))写入的内容相同
+func TestMarshalDataString(t *testing.T) {
+	type data struct {
+		S string `byt:"data"`
+	}
+	p, err := Marshal(&data{S: "字节"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb := NewBuffer()
+	hb.WriteData([]byte("字节"))
+	db := NewBuffer()
+	db.WriteDataString("字节")
+	want := hb.GetByte()[:hb.GetTop()]
+	if !bytes.Equal(p, want) || !bytes.Equal(db.GetByte()[:db.GetTop()], want) {
+		t.Errorf("Marshal = % x, WriteDataString = % x; want % x", p, db.GetByte()[:db.GetTop()], want)
+	}
+	var v data
+	if err := Unmarshal(p, &v); err != nil || v.S != "字节" {
+		t.Errorf("Unmarshal = %q, %v", v.S, err)
+	}
+}
@@ -0,0 +1,484 @@
+/********************************************************/
+// 字节对象（带字段编号的消息，支持向前及向后兼容）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			type Profile struct {
+//				Id    int64  `byt:",tag=1"`
+//				Name  string `byt:",tag=2"`
+//				Level int32  `byt:"varint,tag=3,default=1"`	//旧版本的数据中没有该字段时为1
+//			}
+//			data,err:=byt.Marshal(&p)
+//
+//			//手写代码
+//			buf.WriteField(1, byt.WireFixed64, func() { buf.WriteLong(p.Id) })
+//			buf.WriteField(2, byt.WireBytes, func() { buf.WriteUTF8String(p.Name) })
+//			buf.WriteMessageEnd()
+//
+//			p.Level=1
+//			err=buf.TryReadMessage(func(tag int, wire byt.Wire, f *byt.Buffer) error {
+//				switch tag {
+//				case 1:
+//					p.Id=f.ReadLong()
+//				case 2:
+//					p.Name=f.ReadUTF8String()
+//				}
+//				return nil		//不认识的字段自动跳过
+//			})
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+/*
+ * 消息由若干字段及结束标记组成：
+ *	字段      Uvarint(编号<<3 | 编码类型) + 内容
+ *	结束标记  0x00
+ *
+ * 内容的长度由编码类型决定，因此读取方可以跳过不认识的字段：
+ *	WireFixed8 ~ WireFixed64  1、2、4、8个字节（按字节缓冲对象的编码模式）
+ *	WireVarint                一个变长整数（WriteVarint / WriteUvarint）
+ *	WireBytes                 Uvarint(内容长度) + 内容（字符串、切片、映射、结构体等按原有的格式写入）
+ *
+ * 结构体的字段标签中带有tag=N时按消息格式序列化（所有字段都需要带有不重复的编号），
+ * 读取时先将所有字段设为默认值（零值或default=指定的值），再填入消息中出现的字段。
+ * 新增字段使用新的编号、不再使用的字段删除后不要重复使用其编号，新旧版本之间即可互相读取。
+ */
+
+/**
+ * 字段的编码类型
+ */
+type Wire byte
+
+const (
+	WireFixed8  Wire = iota //bool、int8、uint8
+	WireFixed16             //int16、uint16
+	WireFixed32             //int32、uint32、float32
+	WireFixed64             //int64、uint64、float64、complex64
+	WireVarint              //varint、uvarint
+	WireBytes               //带长度前缀的内容（其他所有类型）
+)
+
+/**
+ * 字段编号的最大值
+ */
+const __maxtag__ int = 1<<29 - 1
+
+var __wirenames__ = [...]string{"fixed8", "fixed16", "fixed32", "fixed64", "varint", "bytes"}
+
+/**
+ * 编码类型名称
+ */
+func (w Wire) String() string {
+	if int(w) < len(__wirenames__) {
+		return __wirenames__[w]
+	}
+	return "Wire(" + strconv.Itoa(int(w)) + ")"
+}
+
+/**
+ * 固定长度的编码类型的内容长度（WireVarint、WireBytes返回0）
+ */
+func (w Wire) Size() int {
+	switch w {
+	case WireFixed8:
+		return 1
+	case WireFixed16:
+		return 2
+	case WireFixed32:
+		return 4
+	case WireFixed64:
+		return 8
+	}
+	return 0
+}
+
+/**
+ * 获取编码方式（与byt字段标签相同的名称，如"int32"、"varint"、"utf8"）对应的编码类型
+ * @param name 编码方式
+ * @return 编码类型（不是定长或变长整数的编码方式均为WireBytes）
+ */
+func WireOf(name string) Wire {
+	switch name {
+	case "bool", "int8", "uint8":
+		return WireFixed8
+	case "int16", "uint16":
+		return WireFixed16
+	case "int32", "uint32", "float32":
+		return WireFixed32
+	case "int64", "uint64", "float64", "complex64":
+		return WireFixed64
+	case "varint", "uvarint":
+		return WireVarint
+	}
+	return WireBytes
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个字段（写入字段头后调用fn写入内容，并检查内容与编码类型是否相符）
+ * @param tag 字段编号（1 ~ 2^29-1）
+ * @param wire 编码类型
+ * @param fn 写入字段内容的函数
+ * @return 错误信息（ErrBadField、ErrBadWire），出错时不写入任何内容
+ */
+func (b *Buffer) WriteField(tag int, wire Wire, fn func()) error {
+	if tag < 1 || tag > __maxtag__ {
+		return ErrBadField
+	}
+	if wire > WireBytes {
+		return ErrBadWire
+	}
+	if wire == WireBytes {
+		_start := b.BeginField(tag)
+		fn()
+		b.EndField(_start)
+		return nil
+	}
+
+	_top, _mark := b.top, b.traceMark()
+	b.WriteFieldKey(tag, wire)
+	_start := b.top
+	fn()
+	_n := b.top - _start
+	_ok := _n == wire.Size()
+	if wire == WireVarint {
+		_, k := binary.Uvarint(b.span(_start, min(_start+binary.MaxVarintLen64, b.top)))
+		_ok = k > 0 && k == _n
+	}
+	if !_ok {
+		b.top = _top
+		b.traceTruncate(_mark)
+		return ErrBadWire
+	}
+	return nil
+}
+
+/**
+ * 写一个字段头（之后由调用方写入与编码类型相符的内容，WireBytes应使用BeginField/EndField）
+ * @param tag 字段编号（1 ~ 2^29-1）
+ * @param wire 编码类型
+ */
+func (b *Buffer) WriteFieldKey(tag int, wire Wire) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteFieldKey", true), strconv.Itoa(tag)+" "+wire.String(), nil)
+	}
+	if tag < 1 || tag > __maxtag__ || wire > WireBytes {
+		fmt.Println("[ERR]: 字段编号或编码类型不合法.")
+		return
+	}
+	b.WriteUvarint(uint64(tag)<<3 | uint64(wire))
+}
+
+/**
+ * 开始一个WireBytes字段（写入字段头，之后写入的内容直至EndField为该字段的内容）
+ * @param tag 字段编号（1 ~ 2^29-1）
+ * @return 内容的起始位置（传给EndField）
+ */
+func (b *Buffer) BeginField(tag int) int {
+	b.WriteFieldKey(tag, WireBytes)
+	return b.top
+}
+
+/**
+ * 结束一个WireBytes字段（在内容之前插入内容长度）
+ * @param start BeginField返回的起始位置
+ */
+func (b *Buffer) EndField(start int) {
+	if start < 0 || start > b.top {
+		fmt.Println("[ERR]: 字段的起始位置不合法.")
+		return
+	}
+	_n := b.top - start
+	var _b_ [binary.MaxVarintLen64]byte
+	k := binary.PutUvarint(_b_[:], uint64(_n))
+	b.grow(k)
+	if b.chunks != nil {
+		b.put(start+k, append([]byte(nil), b.span(start, start+_n)...))
+	} else {
+		copy(b.byt[start+k:b.top], b.byt[start:start+_n])
+	}
+	b.put(start, _b_[:k])
+	if b.tracing() {
+		b.traceInsert(start, k)
+	}
+}
+
+/**
+ * 写入消息的结束标记
+ */
+func (b *Buffer) WriteMessageEnd() {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteMessageEnd", true), nil, nil)
+	}
+	b.WriteUnsignedByt(0)
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个消息（与TryReadMessage相同，fn中f的读取错误记录在b中）
+ */
+func (b *Buffer) ReadMessage(fn func(tag int, wire Wire, f *Buffer)) {
+	if b.err != nil {
+		return
+	}
+	b.fail(b.TryReadMessage(func(tag int, wire Wire, f *Buffer) error {
+		fn(tag, wire, f)
+		return nil
+	}))
+}
+
+/**
+ * 读取一个消息，每个字段调用一次fn（直至结束标记）
+ * f为只包含该字段内容的子对象，只在fn执行期间有效；fn不读取的字段（如新版本增加的字段）直接跳过。
+ * fn返回错误或f中记录了读取错误时停止读取，偏移位置恢复至读取前的位置
+ * @param fn 处理字段的函数
+ * @return 错误信息（ErrUnderflow、ErrOverflow、*LimitError、ErrBadField、ErrBadWire或fn返回的错误）
+ */
+func (b *Buffer) TryReadMessage(fn func(tag int, wire Wire, f *Buffer) error) error {
+	_start, _bits := b.offset, b.rbits
+	f := b.view(nil)
+	for {
+		_tr := b.tracing()
+		_t_ := -1
+		if _tr {
+			_t_ = b.traceBegin("ReadFieldKey", true)
+		}
+		tag, wire, data, err := b.nextField()
+		if _tr {
+			b.traceField(_t_, tag, wire, len(data), err)
+		}
+		if err != nil {
+			b.offset, b.rbits = _start, _bits
+			return err
+		}
+		if tag == 0 {
+			return nil
+		}
+		f.byt = data
+		f.Zero()
+		f.top = len(data)
+		if _tr {
+			f.trace, f.tbase = b.trace, b.tbase+b.offset-len(data) //字段中的读取记录在原对象的跟踪中
+		}
+		if err = fn(tag, wire, f); err == nil {
+			err = f.err
+		}
+		if err != nil {
+			b.offset, b.rbits = _start, _bits
+			return err
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//读取下一个字段的编号、编码类型及内容（遇到结束标记时编号为0）
+func (b *Buffer) nextField() (int, Wire, []byte, error) {
+	key, err := b.TryReadUvarint()
+	if err != nil || key == 0 {
+		return 0, 0, nil, err
+	}
+	tag, wire := key>>3, Wire(key&7)
+	if tag == 0 || tag > uint64(__maxtag__) {
+		return 0, 0, nil, ErrBadField
+	}
+
+	_n := wire.Size()
+	switch wire {
+	case WireVarint:
+		_, k := binary.Uvarint(b.span(b.offset, min(b.offset+binary.MaxVarintLen64, b.top)))
+		if k == 0 {
+			return 0, 0, nil, ErrUnderflow
+		}
+		if k < 0 {
+			return 0, 0, nil, ErrOverflow
+		}
+		_n = k
+	case WireBytes:
+		l, err := b.TryReadUvarint()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if l > math.MaxInt32 {
+			return 0, 0, nil, ErrTooLarge
+		}
+		if err := b.CheckLimit(LimitData, int(l)); err != nil {
+			return 0, 0, nil, err
+		}
+		_n = int(l)
+	case WireFixed8, WireFixed16, WireFixed32, WireFixed64:
+	default:
+		return 0, 0, nil, ErrBadWire
+	}
+	data, err := b.next(_n)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return int(tag), wire, data[:_n:_n], nil
+}
+
+//带字段编号的结构体
+type messageInfo struct {
+	fields []messageField
+	byTag  map[int]int   //字段编号 => fields中的下标
+	zero   reflect.Value //各字段为默认值的结构体
+}
+
+type messageField struct {
+	fieldInfo
+	name string
+	kind Wire
+}
+
+type messageEntry struct {
+	m   *messageInfo
+	err error
+}
+
+//结构体消息信息缓存（reflect.Type => messageEntry）
+var messageCache sync.Map
+
+//获取结构体的消息信息（字段均没有编号时返回nil）
+func messageOf(t reflect.Type) (*messageInfo, error) {
+	if e, ok := messageCache.Load(t); ok {
+		return e.(messageEntry).m, e.(messageEntry).err
+	}
+	m, err := newMessageInfo(t)
+	e, _ := messageCache.LoadOrStore(t, messageEntry{m: m, err: err})
+	return e.(messageEntry).m, e.(messageEntry).err
+}
+
+func newMessageInfo(t reflect.Type) (*messageInfo, error) {
+	fields := structFields(t)
+	_tagged := false
+	for _, f := range fields {
+		if f.tag > 0 {
+			_tagged = true
+		}
+	}
+	if !_tagged {
+		return nil, nil
+	}
+
+	m := &messageInfo{byTag: make(map[int]int, len(fields)), zero: reflect.New(t).Elem()}
+	for _, f := range fields {
+		sf := t.Field(f.index)
+		if f.tag <= 0 || f.tag > __maxtag__ {
+			return nil, fmt.Errorf("byt: %s.%s缺少字段编号或编号不合法", t.Name(), sf.Name)
+		}
+		if _, ok := m.byTag[f.tag]; ok {
+			return nil, fmt.Errorf("byt: %s.%s的字段编号%d重复", t.Name(), sf.Name, f.tag)
+		}
+		if f.def != "" {
+			if err := setDefault(m.zero.Field(f.index), f.def); err != nil {
+				return nil, fmt.Errorf("byt: %s.%s的默认值%q不合法", t.Name(), sf.Name, f.def)
+			}
+		}
+		m.byTag[f.tag] = len(m.fields)
+		m.fields = append(m.fields, messageField{fieldInfo: f, name: sf.Name, kind: fieldWire(sf.Type, f.wire)})
+	}
+	return m, nil
+}
+
+//字段的编码类型
+func fieldWire(t reflect.Type, wire string) Wire {
+	if t == typeUint128 || t == typeUUID {
+		return WireBytes
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Interface:
+		return WireBytes
+	}
+	if wire == "" {
+		if reflect.PointerTo(t).Implements(typeMarshaler) {
+			return WireBytes
+		}
+		wire = defaultWire(t.Kind())
+	}
+	return WireOf(wire)
+}
+
+//解析字段标签中default=指定的默认值
+func setDefault(v reflect.Value, s string) error {
+	switch {
+	case v.Kind() == reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case isIntKind(v.Kind()):
+		x, err := strconv.ParseInt(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+	case isUintKind(v.Kind()):
+		x, err := strconv.ParseUint(s, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	default:
+		return ErrBadField
+	}
+	return nil
+}
+
+func encodeMessage(b *Buffer, v reflect.Value, m *messageInfo) error {
+	for _, f := range m.fields {
+		if f.kind != WireBytes {
+			b.WriteFieldKey(f.tag, f.kind)
+			if err := encodeValue(b, v.Field(f.index), f.wire); err != nil {
+				return err
+			}
+			continue
+		}
+		_start := b.BeginField(f.tag)
+		if err := encodeValue(b, v.Field(f.index), f.wire); err != nil {
+			return err
+		}
+		b.EndField(_start)
+	}
+	b.WriteMessageEnd()
+	return nil
+}
+
+func decodeMessage(b *Buffer, v reflect.Value, m *messageInfo) error {
+	v.Set(m.zero)
+	return b.TryReadMessage(func(tag int, wire Wire, f *Buffer) error {
+		i, ok := m.byTag[tag]
+		if !ok {
+			return nil
+		}
+		if wire != m.fields[i].kind {
+			return ErrBadWire
+		}
+		return decodeValue(f, v.Field(m.fields[i].index), m.fields[i].wire)
+	})
+}
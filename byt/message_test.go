@@ -0,0 +1,146 @@
+package byt
+
+import (
+	"reflect"
+	"testing"
+)
+
+//内容与编码类型不符时撤销写入的字段及其跟踪记录
+func TestWriteFieldRollback(t *testing.T) {
+	b := NewBuffer()
+	b.StartTrace()
+	b.WriteInt(1)
+	if err := b.WriteField(2, WireFixed32, func() { b.WriteShort(1) }); err != ErrBadWire {
+		t.Fatalf("WriteField err = %v; want ErrBadWire", err)
+	}
+	if b.GetTop() != 4 {
+		t.Errorf("top = %d; want 4", b.GetTop())
+	}
+	if tr := b.Trace(); len(tr) != 1 || tr[0].Op != "WriteInt" {
+		t.Errorf("trace = %+v", tr)
+	}
+	if err := b.WriteField(2, WireVarint, func() { b.WriteVarint(3) }); err != nil {
+		t.Fatal(err)
+	}
+	if tr := b.Trace(); len(tr) != 3 || tr[1].Op != "WriteFieldKey" || tr[1].Offset != 4 {
+		t.Errorf("trace = %+v", tr)
+	}
+}
+
+//第一版及增加了各种编码类型的字段的第二版
+type msgV1 struct {
+	Id   int64  `byt:",tag=1"`
+	Name string `byt:",tag=2"`
+}
+
+type msgV2 struct {
+	Id    int64    `byt:",tag=1"`
+	Name  string   `byt:",tag=2"`
+	Level int32    `byt:"varint,tag=3,default=1"`
+	Vip   bool     `byt:",tag=4,default=true"`
+	Rank  int16    `byt:",tag=5,default=-3"`
+	Score float32  `byt:",tag=6,default=1.5"`
+	Exp   uint64   `byt:",tag=7,default=99"`
+	Title string   `byt:",tag=8,default=新手"`
+	Tags  []string `byt:",tag=9"`
+	Owner *msgV1   `byt:",tag=10"`
+}
+
+func testMsgV2() msgV2 {
+	return msgV2{Id: 7, Name: "新版", Level: 300, Vip: false, Rank: 12, Score: -2.5, Exp: 1 << 40,
+		Title: "勇者", Tags: []string{"a", "b"}, Owner: &msgV1{Id: 1, Name: "旧版"}}
+}
+
+//旧版本读取新版本写入的内容：跳过不认识的各种编码类型的字段
+func TestMessageSkipUnknown(t *testing.T) {
+	v2 := testMsgV2()
+	data, err := Marshal(&v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, 0xab) //消息之后的内容
+
+	var v1 msgV1
+	b := NewBufferWithByte(data)
+	if err := b.ReadObject(&v1); err != nil {
+		t.Fatal(err)
+	}
+	if v1 != (msgV1{Id: 7, Name: "新版"}) || b.ReadUnsignedByt() != 0xab {
+		t.Errorf("ReadObject = %+v, remaining %d", v1, b.Remaining())
+	}
+
+	//手写的读取代码
+	var (
+		hand  msgV1
+		wires []Wire
+	)
+	b = NewBufferWithByte(data)
+	err = b.TryReadMessage(func(tag int, wire Wire, f *Buffer) error {
+		wires = append(wires, wire)
+		switch tag {
+		case 1:
+			hand.Id = f.ReadLong()
+		case 2:
+			hand.Name = f.ReadUTF8String()
+		}
+		return f.Err()
+	})
+	if err != nil || hand != v1 || b.ReadUnsignedByt() != 0xab {
+		t.Errorf("TryReadMessage = %+v, %v", hand, err)
+	}
+	want := []Wire{WireFixed64, WireBytes, WireVarint, WireFixed8, WireFixed16, WireFixed32, WireFixed64, WireBytes, WireBytes, WireBytes}
+	if !reflect.DeepEqual(wires, want) {
+		t.Errorf("wires = %v; want %v", wires, want)
+	}
+}
+
+//新版本读取旧版本写入的内容：没有出现的字段为默认值
+func TestMessageDefaults(t *testing.T) {
+	data, err := Marshal(&msgV1{Id: 7, Name: "旧版"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := msgV2{Id: 7, Name: "旧版", Level: 1, Vip: true, Rank: -3, Score: 1.5, Exp: 99, Title: "新手"}
+
+	var v2 msgV2
+	if err := Unmarshal(data, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v2, want) {
+		t.Errorf("Unmarshal =\n%+v\nwant\n%+v", v2, want)
+	}
+	//之前的值被默认值替换
+	v2 = testMsgV2()
+	if err := Unmarshal(data, &v2); err != nil || !reflect.DeepEqual(v2, want) {
+		t.Errorf("Unmarshal into a filled struct =\n%+v\nwant\n%+v", v2, want)
+	}
+
+	//手写的读取代码：先设置默认值
+	hand := msgV2{Level: 1, Vip: true, Rank: -3, Score: 1.5, Exp: 99, Title: "新手"}
+	err = NewBufferWithByte(data).TryReadMessage(func(tag int, wire Wire, f *Buffer) error {
+		switch tag {
+		case 1:
+			hand.Id = f.ReadLong()
+		case 2:
+			hand.Name = f.ReadUTF8String()
+		case 3:
+			hand.Level = int32(f.ReadVarint())
+		default:
+			t.Errorf("unexpected tag %d", tag)
+		}
+		return f.Err()
+	})
+	if err != nil || !reflect.DeepEqual(hand, want) {
+		t.Errorf("TryReadMessage =\n%+v\nwant\n%+v", hand, want)
+	}
+
+	//新版本写入、新版本读取
+	v2 = testMsgV2()
+	if data, err = Marshal(&v2); err != nil {
+		t.Fatal(err)
+	}
+	var got msgV2
+	if err := Unmarshal(data, &got); err != nil || !reflect.DeepEqual(got, v2) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, v2)
+	}
+}
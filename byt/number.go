@@ -0,0 +1,392 @@
+/********************************************************/
+// 字节对象（无符号整数、单精度浮点数、复数及128位数值）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.NewBuffer()
+//			buf.WriteUnsignedInt(4000000000)
+//			buf.WriteFloat32(1.5)
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+/**
+ * 128位无符号整数（Hi为高64位，Lo为低64位）
+ */
+type Uint128 struct {
+	Hi uint64
+	Lo uint64
+}
+
+/**
+ * UUID（RFC 4122字节顺序）
+ */
+type UUID [16]byte
+
+/**
+ * 转换为128位无符号整数
+ */
+func (u UUID) Uint128() Uint128 {
+	return Uint128{
+		Hi: binary.BigEndian.Uint64(u[0:8]),
+		Lo: binary.BigEndian.Uint64(u[8:16]),
+	}
+}
+
+/**
+ * 转换为UUID
+ */
+func (v Uint128) UUID() UUID {
+	var u UUID
+	binary.BigEndian.PutUint64(u[0:8], v.Hi)
+	binary.BigEndian.PutUint64(u[8:16], v.Lo)
+	return u
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个无符号的short值（uint16）
+ */
+func (b *Buffer) ReadUnsignedShort() uint16 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadUnsignedShort()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个无符号的int值（uint32）
+ */
+func (b *Buffer) ReadUnsignedInt() uint32 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadUnsignedInt()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个无符号的long值（uint64）
+ */
+func (b *Buffer) ReadUnsignedLong() uint64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadUnsignedLong()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个单精度float值（float32）
+ */
+func (b *Buffer) ReadFloat32() float32 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadFloat32()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个complex64值（实部与虚部各为一个float32）
+ */
+func (b *Buffer) ReadComplex64() complex64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadComplex64()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个complex128值（实部与虚部各为一个float64）
+ */
+func (b *Buffer) ReadComplex128() complex128 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadComplex128()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个128位无符号整数
+ */
+func (b *Buffer) ReadUint128() Uint128 {
+	if b.err != nil {
+		return Uint128{}
+	}
+	v, err := b.TryReadUint128()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个UUID（按128位整数以字节缓冲对象的编码模式读取）
+ */
+func (b *Buffer) ReadUUID() UUID {
+	if b.err != nil {
+		return UUID{}
+	}
+	v, err := b.TryReadUUID()
+	b.fail(err)
+	return v
+}
+
+////////////////////////////////////////////////////
+//					读（返回错误）					  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个无符号的short值（uint16）
+ */
+func (b *Buffer) TryReadUnsignedShort() (v uint16, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUnsignedShort", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 2, b.Order())
+	return uint16(_val_), err
+}
+
+/**
+ * 读取一个无符号的int值（uint32）
+ */
+func (b *Buffer) TryReadUnsignedInt() (v uint32, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUnsignedInt", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 4, b.Order())
+	return uint32(_val_), err
+}
+
+/**
+ * 读取一个无符号的long值（uint64）
+ */
+func (b *Buffer) TryReadUnsignedLong() (v uint64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUnsignedLong", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	return readValue(b, 8, b.Order())
+}
+
+/**
+ * 读取一个单精度float值（float32）
+ */
+func (b *Buffer) TryReadFloat32() (v float32, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadFloat32", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_val_, err := readValue(b, 4, b.Order())
+	return math.Float32frombits(uint32(_val_)), err
+}
+
+/**
+ * 读取一个complex64值（实部与虚部各为一个float32）
+ */
+func (b *Buffer) TryReadComplex64() (v complex64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadComplex64", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_b_, err := b.next(8)
+	if err != nil {
+		return 0, err
+	}
+	order := b.Order()
+	return complex(
+		math.Float32frombits(order.Uint32(_b_[0:4])),
+		math.Float32frombits(order.Uint32(_b_[4:8]))), nil
+}
+
+/**
+ * 读取一个complex128值（实部与虚部各为一个float64）
+ */
+func (b *Buffer) TryReadComplex128() (v complex128, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadComplex128", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_b_, err := b.next(16)
+	if err != nil {
+		return 0, err
+	}
+	order := b.Order()
+	return complex(
+		math.Float64frombits(order.Uint64(_b_[0:8])),
+		math.Float64frombits(order.Uint64(_b_[8:16]))), nil
+}
+
+/**
+ * 读取一个128位无符号整数
+ * 大端模式下先读高64位，小端模式下先读低64位
+ */
+func (b *Buffer) TryReadUint128() (v Uint128, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUint128", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_b_, err := b.next(16)
+	if err != nil {
+		return Uint128{}, err
+	}
+	order := b.Order()
+	v0, v1 := order.Uint64(_b_[0:8]), order.Uint64(_b_[8:16])
+	if isLittleEndian(order) {
+		return Uint128{Hi: v1, Lo: v0}, nil
+	}
+	return Uint128{Hi: v0, Lo: v1}, nil
+}
+
+/**
+ * 读取一个UUID（按128位整数以字节缓冲对象的编码模式读取）
+ */
+func (b *Buffer) TryReadUUID() (v UUID, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUUID", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_u, err := b.TryReadUint128()
+	if err != nil {
+		return UUID{}, err
+	}
+	return _u.UUID(), nil
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个无符号的short值（uint16）
+ * @param val 值
+ */
+func (b *Buffer) WriteUnsignedShort(val uint16) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUnsignedShort", true), val, nil)
+	}
+	writeValue(b, 2, b.Order(), uint64(val))
+}
+
+/**
+ * 写一个无符号的int值（uint32）
+ * @param val 值
+ */
+func (b *Buffer) WriteUnsignedInt(val uint32) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUnsignedInt", true), val, nil)
+	}
+	writeValue(b, 4, b.Order(), uint64(val))
+}
+
+/**
+ * 写一个无符号的long值（uint64）
+ * @param val 值
+ */
+func (b *Buffer) WriteUnsignedLong(val uint64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUnsignedLong", true), val, nil)
+	}
+	writeValue(b, 8, b.Order(), val)
+}
+
+/**
+ * 写一个单精度float值（float32）
+ * @param val 浮点数
+ */
+func (b *Buffer) WriteFloat32(val float32) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteFloat32", true), val, nil)
+	}
+	writeValue(b, 4, b.Order(), uint64(math.Float32bits(val)))
+}
+
+/**
+ * 写一个complex64值（实部与虚部各为一个float32）
+ * @param val 复数
+ */
+func (b *Buffer) WriteComplex64(val complex64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteComplex64", true), val, nil)
+	}
+	_b_ := b.grow(8)
+	order := b.Order()
+	order.PutUint32(_b_[0:4], math.Float32bits(real(val)))
+	order.PutUint32(_b_[4:8], math.Float32bits(imag(val)))
+}
+
+/**
+ * 写一个complex128值（实部与虚部各为一个float64）
+ * @param val 复数
+ */
+func (b *Buffer) WriteComplex128(val complex128) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteComplex128", true), val, nil)
+	}
+	_b_ := b.grow(16)
+	order := b.Order()
+	order.PutUint64(_b_[0:8], math.Float64bits(real(val)))
+	order.PutUint64(_b_[8:16], math.Float64bits(imag(val)))
+}
+
+/**
+ * 写一个128位无符号整数
+ * 大端模式下先写高64位，小端模式下先写低64位
+ * @param val 128位无符号整数
+ */
+func (b *Buffer) WriteUint128(val Uint128) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUint128", true), val, nil)
+	}
+	if isLittleEndian(b.Order()) {
+		writeValue(b, 8, b.Order(), val.Lo)
+		writeValue(b, 8, b.Order(), val.Hi)
+		return
+	}
+	writeValue(b, 8, b.Order(), val.Hi)
+	writeValue(b, 8, b.Order(), val.Lo)
+}
+
+/**
+ * 写一个UUID（按128位整数以字节缓冲对象的编码模式写入，大端模式下与RFC 4122字节顺序一致）
+ * @param val UUID
+ */
+func (b *Buffer) WriteUUID(val UUID) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUUID", true), val, nil)
+	}
+	b.WriteUint128(val.Uint128())
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//判断编码模式是否为小端
+func isLittleEndian(order binary.ByteOrder) bool {
+	switch order {
+	case binary.LittleEndian:
+		return true
+	case binary.BigEndian:
+		return false
+	}
+	return order.Uint16([]byte{1, 0}) == 1
+}
@@ -0,0 +1,96 @@
+package byt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+//无符号整数、float32、复数及128位数值在两种编码模式下的读写
+func TestNumberRoundTrip(t *testing.T) {
+	u128 := Uint128{Hi: 0x0102030405060708, Lo: 0x090a0b0c0d0e0f10}
+	uuid := UUID{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+	for _, order := range []binary.AppendByteOrder{binary.BigEndian, binary.LittleEndian} {
+		b := NewBufferWithOrder(order.(binary.ByteOrder))
+		b.WriteUnsignedShort(0xfedc)
+		b.WriteUnsignedInt(0xfedcba98)
+		b.WriteUnsignedLong(0xfedcba9876543210)
+		b.WriteFloat32(-1.25)
+		b.WriteComplex64(complex(float32(1.5), float32(-2)))
+		b.WriteComplex128(complex(math.Pi, math.Inf(-1)))
+		b.WriteUint128(u128)
+		b.WriteUUID(uuid)
+
+		//与encoding/binary按相同的编码模式写入的字节比较
+		var want []byte
+		want = order.AppendUint16(want, 0xfedc)
+		want = order.AppendUint32(want, 0xfedcba98)
+		want = order.AppendUint64(want, 0xfedcba9876543210)
+		want = order.AppendUint32(want, math.Float32bits(-1.25))
+		want = order.AppendUint32(want, math.Float32bits(1.5))
+		want = order.AppendUint32(want, math.Float32bits(-2))
+		want = order.AppendUint64(want, math.Float64bits(math.Pi))
+		want = order.AppendUint64(want, math.Float64bits(math.Inf(-1)))
+		//128位整数整体按编码模式写入（小端模式下为大端字节的逆序）
+		_u128 := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+		_uuid := append([]byte(nil), uuid[:]...)
+		if order == binary.LittleEndian {
+			reverse(_u128)
+			reverse(_uuid)
+		}
+		want = append(append(want, _u128...), _uuid...)
+		if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+			t.Errorf("%v: wrote\n% x\nwant\n% x", order, got, want)
+		}
+
+		if v := b.ReadUnsignedShort(); v != 0xfedc {
+			t.Errorf("%v: ReadUnsignedShort = %#x", order, v)
+		}
+		if v := b.ReadUnsignedInt(); v != 0xfedcba98 {
+			t.Errorf("%v: ReadUnsignedInt = %#x", order, v)
+		}
+		if v := b.ReadUnsignedLong(); v != 0xfedcba9876543210 {
+			t.Errorf("%v: ReadUnsignedLong = %#x", order, v)
+		}
+		if v := b.ReadFloat32(); v != -1.25 {
+			t.Errorf("%v: ReadFloat32 = %v", order, v)
+		}
+		if v := b.ReadComplex64(); v != complex(float32(1.5), float32(-2)) {
+			t.Errorf("%v: ReadComplex64 = %v", order, v)
+		}
+		if v := b.ReadComplex128(); v != complex(math.Pi, math.Inf(-1)) {
+			t.Errorf("%v: ReadComplex128 = %v", order, v)
+		}
+		if v := b.ReadUint128(); v != u128 {
+			t.Errorf("%v: ReadUint128 = %+v", order, v)
+		}
+		if v := b.ReadUUID(); v != uuid {
+			t.Errorf("%v: ReadUUID = % x", order, v)
+		}
+		if b.Err() != nil || b.Remaining() != 0 {
+			t.Errorf("%v: err %v, %d bytes left", order, b.Err(), b.Remaining())
+		}
+
+		//内容不完整时返回ErrUnderflow，偏移位置不变
+		tb := NewBufferWithByte(make([]byte, 15))
+		tb.SetOrder(order.(binary.ByteOrder))
+		if _, err := tb.TryReadUint128(); err != ErrUnderflow || tb.GetOffset() != 0 {
+			t.Errorf("%v: short Uint128: %v, offset %d", order, err, tb.GetOffset())
+		}
+		if _, err := tb.TryReadComplex128(); err != ErrUnderflow || tb.GetOffset() != 0 {
+			t.Errorf("%v: short Complex128: %v, offset %d", order, err, tb.GetOffset())
+		}
+	}
+
+	//UUID与Uint128的转换
+	if uuid.Uint128().UUID() != uuid || u128.UUID().Uint128() != u128 {
+		t.Error("UUID <-> Uint128")
+	}
+}
+
+func reverse(p []byte) {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
@@ -0,0 +1,111 @@
+/********************************************************/
+// 字节对象池
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.Acquire(64)
+//			buf.WriteUTF8String("HelloWorld!")
+//			...
+//			byt.Release(buf)
+//
+//			使用 go build -tags bytdebug 编译时，释放后的对象不再放回对象池，
+//			重复释放以及释放后继续使用都会直接panic
+/********************************************************/
+
+package byt
+
+import (
+	"fmt"
+	"sync"
+)
+
+/**
+ * 对象池的容量等级（64字节 ~ 1M字节，每级4倍）
+ */
+var __poolsizes__ = [...]int{64, 256, 1024, 4 * 1024, 16 * 1024, 64 * 1024, 256 * 1024, 1024 * 1024}
+
+var __pools__ [len(__poolsizes__)]sync.Pool
+
+/**
+ * 从对象池中获取一个字节缓冲对象
+ * 对象的top值、偏移位置均为0，编码模式为默认编码模式；使用完毕后应调用Release放回对象池
+ * @param sizeHint 预计写入的字节长度
+ * @return 字节缓冲对象
+ */
+func Acquire(sizeHint int) *Buffer {
+	i := poolIndex(sizeHint)
+	if i >= len(__poolsizes__) {
+		//超出最大等级的对象不进行缓存
+		b := NewBufferWithLen(sizeHint)
+		b.pooled = true
+		return b
+	}
+
+	b, _ := __pools__[i].Get().(*Buffer)
+	if b == nil {
+		b = &Buffer{byt: make([]byte, __poolsizes__[i])}
+	}
+	b.reset()
+	b.pooled = true
+	b.released = false
+	return b
+}
+
+/**
+ * 将Acquire获取的字节缓冲对象放回对象池
+ * 放回后不可再使用该对象（包括GetByte返回的字节数组）
+ * @param b 字节缓冲对象
+ */
+func Release(b *Buffer) {
+	if b == nil {
+		return
+	}
+	if b.released {
+		if __pooldebug__ {
+			panic("byt: Release 重复释放字节缓冲对象")
+		}
+		fmt.Println("[ERR]: Release 重复释放字节缓冲对象.")
+		return
+	}
+	if !b.pooled {
+		fmt.Println("[ERR]: Release 字节缓冲对象不是由Acquire创建的.")
+		return
+	}
+	b.released = true
+
+	if __pooldebug__ {
+		//调试模式下不再复用，使之后的任何访问都能被检测到
+		b.byt = nil
+		b.Zero()
+		return
+	}
+
+	//按当前容量放入不大于该容量的最大等级
+	l := len(b.byt)
+	if l < __poolsizes__[0] || l > 2*__poolsizes__[len(__poolsizes__)-1] {
+		return
+	}
+	i := poolIndex(l)
+	if i >= len(__poolsizes__) || __poolsizes__[i] > l {
+		i--
+	}
+	__pools__[i].Put(b)
+}
+
+//容量不小于n的最小等级
+func poolIndex(n int) int {
+	for i, size := range __poolsizes__ {
+		if n <= size {
+			return i
+		}
+	}
+	return len(__poolsizes__)
+}
+
+//调试模式下检查对象是否已被释放
+func (b *Buffer) live() {
+	if __pooldebug__ && b.released {
+		panic("byt: 使用已释放的字节缓冲对象")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build bytdebug
+
+package byt
+
+//调试模式（go build -tags bytdebug）
+const __pooldebug__ = true
@@ -0,0 +1,33 @@
+//go:build bytdebug
+
+package byt
+
+import (
+	"testing"
+)
+
+func expectPanic(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s did not panic", name)
+		}
+	}()
+	fn()
+}
+
+//调试模式下重复释放及释放后继续使用都会panic，释放的对象不再复用
+func TestReleaseDebug(t *testing.T) {
+	b := Acquire(10)
+	b.WriteInt(1)
+	Release(b)
+	expectPanic(t, "double Release", func() { Release(b) })
+	expectPanic(t, "WriteInt after Release", func() { b.WriteInt(1) })
+	expectPanic(t, "ReadInt after Release", func() { b.ReadInt() })
+	expectPanic(t, "GetByte after Release", func() { b.GetByte() })
+	for i := 0; i < 10; i++ {
+		if Acquire(10) == b {
+			t.Fatal("released buffer reused in debug mode")
+		}
+	}
+}
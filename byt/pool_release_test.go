@@ -0,0 +1,28 @@
+//go:build !bytdebug
+
+package byt
+
+import (
+	"testing"
+)
+
+//Release后同一等级的Acquire复用该对象（sync.Pool可能随机丢弃对象，多次尝试）
+func TestReleaseReuse(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		b := Acquire(200)
+		Release(b)
+		if Acquire(200) == b {
+			return
+		}
+	}
+	t.Error("released buffer never reused")
+}
+
+//非调试模式下重复释放及释放非Acquire创建的对象只输出错误
+func TestReleaseMisuse(t *testing.T) {
+	b := Acquire(10)
+	Release(b)
+	Release(b)
+	Release(NewBuffer())
+	Release(nil)
+}
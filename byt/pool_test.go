@@ -0,0 +1,46 @@
+package byt
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+//Acquire按容量等级分配（复用的对象的容量不小于该等级且小于下一等级），超出最大等级时按需要的长度分配
+func TestAcquireSizeClasses(t *testing.T) {
+	cases := []struct{ hint, capa int }{
+		{0, 64}, {1, 64}, {64, 64}, {65, 256}, {1000, 1024}, {1025, 4 * 1024},
+		{64*1024 + 1, 256 * 1024}, {1024 * 1024, 1024 * 1024}, {1024*1024 + 1, 1024*1024 + 1},
+	}
+	for _, c := range cases {
+		b := Acquire(c.hint)
+		if _capa := b.capacity(); _capa < c.capa || _capa >= 4*c.capa || c.capa > 1024*1024 && _capa != c.capa {
+			t.Errorf("Acquire(%d) capacity = %d; want %d", c.hint, b.capacity(), c.capa)
+		}
+		if b.GetTop() != 0 || b.GetOffset() != 0 || b.Order() != getEndian() {
+			t.Errorf("Acquire(%d): top %d, offset %d, order %v", c.hint, b.GetTop(), b.GetOffset(), b.Order())
+		}
+		Release(b)
+	}
+}
+
+//放回对象池的对象被重置后再次使用
+func TestAcquireReset(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		b := Acquire(100)
+		b.SetOrder(binary.LittleEndian)
+		b.SetLimits(Limits{MaxString: 1})
+		b.WriteBits(1, 3)
+		b.WriteUTF8String("dirty")
+		b.ReadBits(2)
+		Release(b)
+	}
+	b := Acquire(100)
+	defer Release(b)
+	if b.GetTop() != 0 || b.GetOffset() != 0 || b.Order() != getEndian() || b.Limits() != (Limits{}) || b.Err() != nil {
+		t.Errorf("reused buffer not reset: top %d, offset %d, limits %+v", b.GetTop(), b.GetOffset(), b.Limits())
+	}
+	b.WriteInt(5)
+	if b.ReadInt() != 5 {
+		t.Error("reused buffer unusable")
+	}
+}
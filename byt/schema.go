@@ -0,0 +1,417 @@
+/********************************************************/
+// 字节对象（消息结构描述及兼容性检查）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			s,err:=byt.SchemaOf(&Profile{})
+//			os.WriteFile("profile.schema", []byte(s.String()), 0644)	//保存当前版本（包括字段引用的消息）
+//			...
+//			old,err:=byt.ParseSchema(text)					//之前保存的版本
+//			for _,c:=range byt.DiffSchema(old[0], s) {
+//				fmt.Println(c)							//c.Breaking为true时新旧版本无法互相读取
+//			}
+/********************************************************/
+
+package byt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/**
+ * 消息结构描述（带字段编号的结构体）
+ */
+type Schema struct {
+	Name   string        //消息名称（结构体名称）
+	Fields []SchemaField //字段（按声明顺序）
+}
+
+/**
+ * 消息字段描述
+ */
+type SchemaField struct {
+	Tag  int    //字段编号
+	Name string //字段名称
+	Wire Wire   //编码类型
+	Type string //内容的编码方式（如int32、varint、utf8、[]int16、map[utf8]varint、*Item）
+
+	Default string  //默认值（default=V，没有时为空）
+	Message *Schema //内容中的消息（如*Item、[]Item中的Item带字段编号时），没有时为nil
+}
+
+/**
+ * 两个版本之间的一项差异
+ */
+type SchemaChange struct {
+	Tag      int    //字段编号
+	Name     string //字段名称
+	Breaking bool   //是否导致新旧版本无法互相读取
+	Desc     string //说明
+	Message  string //字段所在的嵌套消息（字段引用的消息中的差异），顶层消息为空
+}
+
+func (c SchemaChange) String() string {
+	s := "字段" + strconv.Itoa(c.Tag) + "（" + c.Name + "）: " + c.Desc
+	if c.Message != "" {
+		s = "消息" + c.Message + "的" + s
+	}
+	if c.Breaking {
+		return "[不兼容] " + s
+	}
+	return s
+}
+
+/**
+ * 获取结构体的消息结构描述
+ * @param v 带字段编号的结构体或结构体指针
+ * @return 消息结构描述，错误信息
+ */
+func SchemaOf(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("byt: SchemaOf需要一个结构体")
+	}
+	m, err := messageOf(t)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("byt: %s的字段没有编号", t.Name())
+	}
+	return schemaOf(t, m, map[reflect.Type]*Schema{})
+}
+
+/**
+ * 文本形式（ParseSchema可以读取），字段引用的消息依次写在之后
+ *	message Profile
+ *		1	Id	fixed64	int64
+ *		2	Name	bytes	utf8
+ *		3	Level	varint	varint	default=1
+ *		4	Equip	bytes	*Item
+ *	message Item
+ *		...
+ */
+func (s *Schema) String() string {
+	var sb strings.Builder
+	_seen := map[*Schema]bool{}
+	_list := []*Schema{s}
+	for len(_list) > 0 {
+		m := _list[0]
+		_list = _list[1:]
+		if _seen[m] {
+			continue
+		}
+		_seen[m] = true
+		sb.WriteString("message " + m.Name + "\n")
+		for _, f := range m.Fields {
+			fmt.Fprintf(&sb, "\t%d\t%s\t%s\t%s", f.Tag, f.Name, f.Wire, f.Type)
+			if f.Default != "" {
+				sb.WriteString("\tdefault=" + quoteDefault(f.Default))
+			}
+			sb.WriteString("\n")
+			if f.Message != nil {
+				_list = append(_list, f.Message)
+			}
+		}
+	}
+	return sb.String()
+}
+
+/**
+ * 读取文本形式的消息结构描述（可以包含多个消息，#之后为注释）
+ * 字段内容中的消息在同一文本中时设置SchemaField.Message
+ * @param text 文本
+ * @return 消息结构描述，错误信息
+ */
+func ParseSchema(text string) ([]*Schema, error) {
+	var (
+		list []*Schema
+		cur  *Schema
+	)
+	sc := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; sc.Scan(); line++ {
+		s := uncomment(sc.Text())
+		items := strings.Fields(s)
+		if len(items) == 0 {
+			continue
+		}
+		if items[0] == "message" && len(items) == 2 {
+			cur = &Schema{Name: items[1]}
+			list = append(list, cur)
+			continue
+		}
+		if cur == nil || len(items) < 4 || len(items) > 4 && !strings.HasPrefix(items[4], "default=") {
+			return nil, fmt.Errorf("byt: 第%d行格式不正确", line)
+		}
+		tag, err := strconv.Atoi(items[0])
+		if err != nil || tag < 1 || tag > __maxtag__ {
+			return nil, fmt.Errorf("byt: 第%d行的字段编号不合法", line)
+		}
+		wire := Wire(len(__wirenames__))
+		for i, n := range __wirenames__ {
+			if n == items[2] {
+				wire = Wire(i)
+			}
+		}
+		if wire > WireBytes {
+			return nil, fmt.Errorf("byt: 第%d行的编码类型不合法", line)
+		}
+		f := SchemaField{Tag: tag, Name: items[1], Wire: wire, Type: items[3]}
+		if len(items) > 4 {
+			_def := strings.TrimSpace(s[strings.Index(s, "default=")+len("default="):])
+			_ok := len(items) == 5
+			if strings.HasPrefix(_def, "\"") {
+				_def, err = strconv.Unquote(_def)
+				_ok = err == nil
+			}
+			if !_ok {
+				return nil, fmt.Errorf("byt: 第%d行的默认值不合法", line)
+			}
+			f.Default = _def
+		}
+		cur.Fields = append(cur.Fields, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	_names := make(map[string]*Schema, len(list))
+	for _, m := range list {
+		_names[m.Name] = m
+	}
+	for _, m := range list {
+		for i := range m.Fields {
+			m.Fields[i].Message = _names[elemName(m.Fields[i].Type)]
+		}
+	}
+	return list, nil
+}
+
+/**
+ * 比较同一消息的两个版本
+ * 新增、删除及重命名字段、改变默认值是兼容的；编号相同但编码类型或内容的编码方式改变、
+ * 同名字段的编号改变时新旧版本无法互相读取。字段引用的消息（SchemaField.Message）同样比较
+ * @param old 旧版本
+ * @param cur 新版本
+ * @return 差异（按字段编号排序，之后为字段引用的消息中的差异）
+ */
+func DiffSchema(old, cur *Schema) []SchemaChange {
+	return diffSchema(old, cur, "", map[[2]*Schema]bool{{old, cur}: true})
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//比较消息的两个版本，msg为嵌套消息的名称，seen为已比较过的消息
+func diffSchema(old, cur *Schema, msg string, seen map[[2]*Schema]bool) []SchemaChange {
+	_old := make(map[int]SchemaField, len(old.Fields))
+	_oldName := make(map[string]SchemaField, len(old.Fields))
+	for _, f := range old.Fields {
+		_old[f.Tag] = f
+		_oldName[f.Name] = f
+	}
+	_cur := make(map[int]SchemaField, len(cur.Fields))
+	_curName := make(map[string]SchemaField, len(cur.Fields))
+	for _, f := range cur.Fields {
+		_cur[f.Tag] = f
+		_curName[f.Name] = f
+	}
+
+	var changes []SchemaChange
+	add := func(f SchemaField, breaking bool, desc string) {
+		changes = append(changes, SchemaChange{Tag: f.Tag, Name: f.Name, Breaking: breaking, Desc: desc, Message: msg})
+	}
+	for _, f := range cur.Fields {
+		o, ok := _old[f.Tag]
+		switch {
+		case !ok:
+			if p, moved := _oldName[f.Name]; moved {
+				add(f, true, "编号由"+strconv.Itoa(p.Tag)+"改为"+strconv.Itoa(f.Tag))
+			} else {
+				add(f, false, "新增")
+			}
+			continue
+		case o.Wire != f.Wire:
+			add(f, true, "编码类型由"+o.Wire.String()+"改为"+f.Wire.String())
+			continue
+		case o.Type != f.Type:
+			add(f, true, "编码方式由"+o.Type+"改为"+f.Type)
+			continue
+		case o.Name != f.Name:
+			add(f, false, "由"+o.Name+"重命名")
+		}
+		if o.Default != f.Default {
+			add(f, false, "默认值由"+showDefault(o.Default)+"改为"+showDefault(f.Default))
+		}
+	}
+	for _, o := range old.Fields {
+		if _, ok := _cur[o.Tag]; ok {
+			continue
+		}
+		if _, moved := _curName[o.Name]; !moved {
+			changes = append(changes, SchemaChange{Tag: o.Tag, Name: o.Name, Desc: "删除（之后不要再使用该编号）", Message: msg})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Tag < changes[j].Tag
+	})
+
+	//编码方式相同的字段引用的消息
+	for _, f := range cur.Fields {
+		o, ok := _old[f.Tag]
+		if !ok || o.Wire != f.Wire || o.Type != f.Type || o.Message == nil || f.Message == nil {
+			continue
+		}
+		_pair := [2]*Schema{o.Message, f.Message}
+		if seen[_pair] {
+			continue
+		}
+		seen[_pair] = true
+		changes = append(changes, diffSchema(o.Message, f.Message, f.Message.Name, seen)...)
+	}
+	return changes
+}
+
+//结构体的消息结构描述，字段引用的带字段编号的结构体同样生成（seen中为已生成的描述）
+func schemaOf(t reflect.Type, m *messageInfo, seen map[reflect.Type]*Schema) (*Schema, error) {
+	s := &Schema{Name: t.Name()}
+	seen[t] = s
+	for _, f := range m.fields {
+		ft := t.Field(f.index).Type
+		sf := SchemaField{
+			Tag:     f.tag,
+			Name:    f.name,
+			Wire:    f.kind,
+			Type:    typeName(ft, f.wire),
+			Default: f.def,
+		}
+		_elem := elemType(ft)
+		if _elem.Kind() == reflect.Struct && _elem.Name() != "" {
+			if n, ok := seen[_elem]; ok {
+				sf.Message = n
+			} else if nm, err := messageOf(_elem); err != nil {
+				return nil, err
+			} else if nm != nil {
+				if sf.Message, err = schemaOf(_elem, nm, seen); err != nil {
+					return nil, err
+				}
+			}
+		}
+		s.Fields = append(s.Fields, sf)
+	}
+	return s, nil
+}
+
+//指针、切片、数组及映射的值的元素类型
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+//编码方式中的元素名称（*Item、[]Item、[4]Item、map[utf8]Item中的Item）
+func elemName(typ string) string {
+	for {
+		switch {
+		case strings.HasPrefix(typ, "*"):
+			typ = typ[1:]
+		case strings.HasPrefix(typ, "["), strings.HasPrefix(typ, "map["):
+			_depth := 0
+			for i := 0; i < len(typ); i++ {
+				if typ[i] == '[' {
+					_depth++
+				} else if typ[i] == ']' {
+					if _depth--; _depth == 0 {
+						typ = typ[i+1:]
+						break
+					}
+				}
+			}
+			if _depth != 0 {
+				return typ
+			}
+		default:
+			return typ
+		}
+	}
+}
+
+//去掉#之后的注释（引号中的#除外）
+func uncomment(s string) string {
+	_quote := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case _quote && s[i] == '\\':
+			i++
+		case s[i] == '"':
+			_quote = !_quote
+		case !_quote && s[i] == '#':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+//文本形式中的默认值（包含空白、引号或#时加引号）
+func quoteDefault(def string) string {
+	if strings.ContainsAny(def, " \t\"#") {
+		return strconv.Quote(def)
+	}
+	return def
+}
+
+func showDefault(def string) string {
+	if def == "" {
+		return "（无）"
+	}
+	return def
+}
+
+//内容的编码方式（与bytgen生成的描述一致）
+func typeName(t reflect.Type, wire string) string {
+	switch {
+	case t == typeUint128:
+		return "uint128"
+	case t == typeUUID:
+		return "uuid"
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeName(t.Elem(), wire)
+	case reflect.Slice:
+		if isByteSlice(t) && (wire == "" || wire == "data") {
+			return "data"
+		}
+		return "[]" + typeName(t.Elem(), wire)
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + typeName(t.Elem(), wire)
+	case reflect.Map:
+		return "map[" + typeName(t.Key(), "") + "]" + typeName(t.Elem(), wire)
+	case reflect.Struct:
+		if t.Name() == "" {
+			return "struct"
+		}
+		return t.Name()
+	}
+	if wire == "" {
+		if reflect.PointerTo(t).Implements(typeMarshaler) && t.Name() != "" {
+			return t.Name()
+		}
+		wire = defaultWire(t.Kind())
+	}
+	return wire
+}
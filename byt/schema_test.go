@@ -0,0 +1,100 @@
+package byt
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaItem struct {
+	Id    int32 `byt:",tag=1"`
+	Count int32 `byt:"varint,tag=2,default=1"`
+}
+
+type schemaNode struct {
+	Id       int64        `byt:",tag=1"`
+	Item     *schemaItem  `byt:",tag=2"`
+	Children []schemaNode `byt:",tag=3"`
+	Name     string       `byt:",tag=4,default=a #b"`
+}
+
+//字段引用的消息及默认值写入文本形式，读取后相同
+func TestSchemaText(t *testing.T) {
+	s, err := SchemaOf(&schemaNode{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "message schemaNode\n" +
+		"\t1\tId\tfixed64\tint64\n" +
+		"\t2\tItem\tbytes\t*schemaItem\n" +
+		"\t3\tChildren\tbytes\t[]schemaNode\n" +
+		"\t4\tName\tbytes\tutf8\tdefault=\"a #b\"\n" +
+		"message schemaItem\n" +
+		"\t1\tId\tfixed32\tint32\n" +
+		"\t2\tCount\tvarint\tvarint\tdefault=1\n"
+	if got := s.String(); got != want {
+		t.Fatalf("String =\n%s\nwant\n%s", got, want)
+	}
+	if s.Fields[1].Message == nil || s.Fields[1].Message.Name != "schemaItem" || s.Fields[2].Message != s {
+		t.Errorf("nested messages not linked: %+v", s.Fields)
+	}
+	list, err := ParseSchema(want + "# 注释\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !reflect.DeepEqual(list[0], s) {
+		t.Errorf("ParseSchema =\n%v", list)
+	}
+
+	for _, bad := range []string{
+		"message A\n\t1\tId\tfixed64\tint64\tx=1\n",
+		"message A\n\t1\tId\tfixed64\tint64\tdefault=1 2\n",
+		"message A\n\t1\tId\tbytes\tutf8\tdefault=\"a\n",
+	} {
+		if _, err := ParseSchema(bad); err == nil {
+			t.Errorf("ParseSchema(%q) succeeded", bad)
+		}
+	}
+}
+
+//比较字段引用的消息及默认值
+func TestDiffSchemaNested(t *testing.T) {
+	old, err := ParseSchema("message Node\n" +
+		"\t1\tItem\tbytes\t*Item\n" +
+		"\t2\tChildren\tbytes\t[]Node\n" +
+		"\t3\tLevel\tvarint\tvarint\tdefault=1\n" +
+		"message Item\n" +
+		"\t1\tId\tfixed32\tint32\n" +
+		"\t2\tCount\tvarint\tvarint\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := ParseSchema("message Node\n" +
+		"\t1\tItem\tbytes\t*Item\n" +
+		"\t2\tChildren\tbytes\t[]Node\n" +
+		"\t3\tLevel\tvarint\tvarint\tdefault=2\n" +
+		"message Item\n" +
+		"\t1\tId\tfixed32\tint32\n" +
+		"\t2\tCount\tfixed16\tint16\n" +
+		"\t3\tName\tbytes\tutf8\tdefault=x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SchemaChange{
+		{Tag: 3, Name: "Level", Desc: "默认值由1改为2"},
+		{Tag: 2, Name: "Count", Breaking: true, Desc: "编码类型由varint改为fixed16", Message: "Item"},
+		{Tag: 3, Name: "Name", Desc: "新增", Message: "Item"},
+	}
+	got := DiffSchema(old[0], cur[0])
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffSchema =\n%v\nwant\n%v", got, want)
+	}
+	if s := got[1].String(); s != "[不兼容] 消息Item的字段2（Count）: 编码类型由varint改为fixed16" {
+		t.Errorf("String = %q", s)
+	}
+
+	//引用的消息改变时不再比较其字段
+	cur[0].Fields[0].Type = "*Other"
+	if got := DiffSchema(old[0], cur[0]); len(got) != 2 || !got[0].Breaking || got[0].Tag != 1 {
+		t.Errorf("DiffSchema after type change =\n%v", got)
+	}
+}
@@ -0,0 +1,203 @@
+/********************************************************/
+// 字节对象（AEAD加密区段：AES-GCM、ChaCha20-Poly1305）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			aead,_:=byt.NewAESGCM(key)	//或chacha20poly1305.New(key)（golang.org/x/crypto）
+//			err:=buf.WriteSealed(aead, byt.RandomNonce, func(inner *byt.Buffer) {
+//				inner.WriteUTF8String(token)
+//			})
+//			...
+//			inner,err:=buf.TryReadSealed(aead)	//被篡改时返回*byt.AuthError
+//			token:=inner.ReadUTF8String()
+/********************************************************/
+
+package byt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"sync/atomic"
+)
+
+/**
+ * 解密认证失败（密钥错误或内容被篡改）
+ */
+type AuthError struct {
+	Offset int   //加密区段在字节缓冲对象中的起始位置
+	Err    error //cipher.AEAD.Open返回的错误
+}
+
+func (e *AuthError) Error() string {
+	return "byt: 加密区段认证失败. offset = " + strconv.Itoa(e.Offset)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+/**
+ * nonce来源（向nonce中填充一个新的nonce，同一密钥下不能重复）
+ */
+type NonceSource func(nonce []byte) error
+
+/**
+ * 随机nonce（crypto/rand），适用于AES-GCM及ChaCha20-Poly1305的12字节nonce
+ */
+var RandomNonce NonceSource = func(nonce []byte) error {
+	_, err := io.ReadFull(rand.Reader, nonce)
+	return err
+}
+
+/**
+ * 创建一个计数器nonce来源（前4字节为随机前缀，后8字节为从1开始的递增计数，并发安全）
+ * 一个来源可生成2^64个不重复的nonce，适用于长连接中大量加密区段。
+ * 注意：只能用于每个密钥只有一个发送方的情况。不同的来源（多个进程、重启后、通信的双方）
+ * 使用同一密钥时，随机前缀相同（概率为2^-32）即从第一个nonce开始重复，GCM的安全性完全失效；
+ * 此时应使用RandomNonce，或为每个发送方分别协商密钥
+ * @return nonce来源，错误信息（读取随机前缀失败）
+ */
+func NewCounterNonce() (NonceSource, error) {
+	var (
+		_prefix  [4]byte
+		_counter uint64
+	)
+	if _, err := io.ReadFull(rand.Reader, _prefix[:]); err != nil {
+		return nil, err
+	}
+	return func(nonce []byte) error {
+		if len(nonce) < 12 {
+			return ErrBadLength
+		}
+		n := copy(nonce, _prefix[:])
+		for i := n; i < len(nonce)-8; i++ {
+			nonce[i] = 0
+		}
+		binary.BigEndian.PutUint64(nonce[len(nonce)-8:], atomic.AddUint64(&_counter, 1))
+		return nil
+	}, nil
+}
+
+/**
+ * 创建AES-GCM加密对象
+ * @param key 密钥（16、24或32字节，对应AES-128、AES-192、AES-256）
+ * @return AEAD加密对象，错误信息
+ */
+func NewAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个加密区段
+ * 写入内容为：nonce（aead.NonceSize()字节）+ 密文（与WriteData格式一致，包含认证标签）
+ * @param aead 加密对象（AES-GCM、ChaCha20-Poly1305等）
+ * @param nonce nonce来源
+ * @param fn 向inner写入需要加密的内容（inner的编码模式与当前对象相同）
+ * @return 错误信息（nonce来源返回的错误），出错时不写入任何内容
+ */
+func (b *Buffer) WriteSealed(aead cipher.AEAD, nonce NonceSource, fn func(inner *Buffer)) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteSealed", true)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	inner := Acquire(__capacity__)
+	defer Release(inner)
+	inner.SetOrder(b.Order())
+	fn(inner)
+
+	_top := b.top
+	_nonce := b.grow(aead.NonceSize())
+	if err := nonce(_nonce); err != nil {
+		b.top = _top
+		return err
+	}
+	_len := inner.top + aead.Overhead()
+	b.WriteLength(_len + 1)
+	_nv := b.span(_top, _top+aead.NonceSize())
+	_dst := b.grow(_len)
+	aead.Seal(_dst[:0], _nv, inner.byt[:inner.top], nil)
+	return nil
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个加密区段（读取失败时记录错误并返回一个空对象）
+ * @param aead 加密对象
+ * @return 解密后的字节缓冲对象（编码模式与当前对象相同）
+ */
+func (b *Buffer) ReadSealed(aead cipher.AEAD) *Buffer {
+	if b.err == nil {
+		inner, err := b.TryReadSealed(aead)
+		if err == nil {
+			return inner
+		}
+		b.fail(err)
+	}
+	return b.view(b.byt[0:0:0])
+}
+
+/**
+ * 读取一个加密区段
+ * 返回的字节缓冲对象由Acquire创建，使用完毕后可调用Release放回对象池
+ * @param aead 加密对象
+ * @return 解密后的字节缓冲对象，错误信息（ErrUnderflow、ErrBadLength、*LimitError、*AuthError）
+ */
+func (b *Buffer) TryReadSealed(aead cipher.AEAD) (v *Buffer, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadSealed", true)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	_nonce, err := b.next(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	l, err := b.TryReadLength()
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	_len := l - 1
+	if _len < aead.Overhead() {
+		b.offset, b.rbits = _start, _bits
+		return nil, ErrBadLength
+	}
+	if err := b.CheckLimit(LimitData, _len); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	data, err := b.next(_len)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+
+	inner := Acquire(_len - aead.Overhead())
+	_plain, err := aead.Open(inner.byt[:0], _nonce, data, nil)
+	if err != nil {
+		Release(inner)
+		b.offset, b.rbits = _start, _bits
+		return nil, &AuthError{Offset: _start, Err: err}
+	}
+	inner.byt = _plain[:cap(_plain)]
+	inner.top = len(_plain)
+	inner.SetOrder(b.Order())
+	inner.limits = b.limits
+	return inner, nil
+}
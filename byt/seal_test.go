@@ -0,0 +1,268 @@
+package byt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+////////////////////////////////////////////////////////////////////////
+//ChaCha20-Poly1305（RFC 8439）的简单实现，仅用于测试（标准库中没有导出的实现）
+
+type chachaPoly struct {
+	key [32]byte
+}
+
+func (c *chachaPoly) NonceSize() int { return 12 }
+func (c *chachaPoly) Overhead() int  { return 16 }
+
+//第counter块的密钥流
+func (c *chachaPoly) block(nonce []byte, counter uint32, out *[64]byte) {
+	var s, x [16]uint32
+	s[0], s[1], s[2], s[3] = 0x61707865, 0x3320646e, 0x79622d32, 0x6b206574
+	for i := 0; i < 8; i++ {
+		s[4+i] = binary.LittleEndian.Uint32(c.key[i*4:])
+	}
+	s[12] = counter
+	for i := 0; i < 3; i++ {
+		s[13+i] = binary.LittleEndian.Uint32(nonce[i*4:])
+	}
+	x = s
+	qr := func(a, b, c, d int) {
+		x[a] += x[b]
+		x[d] = bits.RotateLeft32(x[d]^x[a], 16)
+		x[c] += x[d]
+		x[b] = bits.RotateLeft32(x[b]^x[c], 12)
+		x[a] += x[b]
+		x[d] = bits.RotateLeft32(x[d]^x[a], 8)
+		x[c] += x[d]
+		x[b] = bits.RotateLeft32(x[b]^x[c], 7)
+	}
+	for i := 0; i < 10; i++ {
+		qr(0, 4, 8, 12)
+		qr(1, 5, 9, 13)
+		qr(2, 6, 10, 14)
+		qr(3, 7, 11, 15)
+		qr(0, 5, 10, 15)
+		qr(1, 6, 11, 12)
+		qr(2, 7, 8, 13)
+		qr(3, 4, 9, 14)
+	}
+	for i := range x {
+		binary.LittleEndian.PutUint32(out[i*4:], x[i]+s[i])
+	}
+}
+
+func (c *chachaPoly) xor(dst, src, nonce []byte) {
+	var ks [64]byte
+	for i := 0; i < len(src); i += 64 {
+		c.block(nonce, uint32(i/64+1), &ks)
+		for j := i; j < len(src) && j < i+64; j++ {
+			dst[j] = src[j] ^ ks[j-i]
+		}
+	}
+}
+
+func (c *chachaPoly) tag(nonce, ad, ct []byte) []byte {
+	var ks [64]byte
+	c.block(nonce, 0, &ks)
+	le := func(p []byte) *big.Int {
+		q := make([]byte, len(p))
+		for i := range p {
+			q[len(p)-1-i] = p[i]
+		}
+		return new(big.Int).SetBytes(q)
+	}
+	clamp, _ := new(big.Int).SetString("0ffffffc0ffffffc0ffffffc0fffffff", 16)
+	r := new(big.Int).And(le(ks[:16]), clamp)
+	p := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 130), big.NewInt(5))
+	pad := func(p []byte) []byte { return append(p, make([]byte, (16-len(p)%16)%16)...) }
+	msg := append(pad(append([]byte(nil), ad...)), pad(append([]byte(nil), ct...))...)
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(len(ad)))
+	msg = binary.LittleEndian.AppendUint64(msg, uint64(len(ct)))
+	acc := new(big.Int)
+	for i := 0; i < len(msg); i += 16 {
+		acc.Add(acc, le(append(append([]byte(nil), msg[i:i+16]...), 1)))
+		acc.Mul(acc, r).Mod(acc, p)
+	}
+	acc.Add(acc, le(ks[16:32]))
+	out := make([]byte, 16)
+	for i, v := range acc.Bytes() {
+		if j := len(acc.Bytes()) - 1 - i; j < 16 {
+			out[j] = v
+		}
+	}
+	return out
+}
+
+func (c *chachaPoly) Seal(dst, nonce, plaintext, ad []byte) []byte {
+	ret := append(dst, make([]byte, len(plaintext)+16)...)
+	out := ret[len(dst):]
+	c.xor(out, plaintext, nonce)
+	copy(out[len(plaintext):], c.tag(nonce, ad, out[:len(plaintext)]))
+	return ret
+}
+
+func (c *chachaPoly) Open(dst, nonce, ciphertext, ad []byte) ([]byte, error) {
+	if len(ciphertext) < 16 {
+		return nil, errors.New("chacha20poly1305: message authentication failed")
+	}
+	ct := ciphertext[:len(ciphertext)-16]
+	if subtle.ConstantTimeCompare(c.tag(nonce, ad, ct), ciphertext[len(ct):]) != 1 {
+		return nil, errors.New("chacha20poly1305: message authentication failed")
+	}
+	ret := append(dst, make([]byte, len(ct))...)
+	c.xor(ret[len(dst):], ct, nonce)
+	return ret, nil
+}
+
+func unhexSeal(s string) []byte {
+	p, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+//RFC 8439 2.8.2的示例
+func TestChachaPolyVector(t *testing.T) {
+	c := &chachaPoly{}
+	for i := range c.key {
+		c.key[i] = byte(0x80 + i)
+	}
+	nonce := unhexSeal("07000000 40414243 44454647")
+	ad := unhexSeal("50515253 c0c1c2c3 c4c5c6c7")
+	plain := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	out := c.Seal(nil, nonce, plain, ad)
+	if want := unhexSeal("d31a8d34648e60db7b86afbc53ef7ec2"); !bytes.Equal(out[:16], want) {
+		t.Errorf("ciphertext = % x; want % x", out[:16], want)
+	}
+	if want := unhexSeal("1ae10b594f09e26a7e902ecbd0600691"); !bytes.Equal(out[len(out)-16:], want) {
+		t.Errorf("tag = % x; want % x", out[len(out)-16:], want)
+	}
+	if p, err := c.Open(nil, nonce, out, ad); err != nil || !bytes.Equal(p, plain) {
+		t.Errorf("Open = %q, %v", p, err)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+
+//密钥的各字节均为k的加密对象
+func testAEADs(t *testing.T, k byte) map[string]cipher.AEAD {
+	gcm, err := NewAESGCM(bytes.Repeat([]byte{k}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := &chachaPoly{}
+	copy(cp.key[:], bytes.Repeat([]byte{k}, 32))
+	return map[string]cipher.AEAD{"AES-GCM": gcm, "ChaCha20-Poly1305": cp}
+}
+
+//加密区段的读写（普通模式及分块模式）
+func TestSealedRoundTrip(t *testing.T) {
+	counter, err := NewCounterNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, aead := range testAEADs(t, 1) {
+		for _, b := range []*Buffer{NewBuffer(), NewChunkedBuffer(5)} {
+			b.WriteInt(-1)
+			for _, nonce := range []NonceSource{RandomNonce, counter} {
+				if err := b.WriteSealed(aead, nonce, func(inner *Buffer) {
+					inner.WriteUTF8String("密文")
+					inner.WriteLong(42)
+				}); err != nil {
+					t.Fatalf("%s: WriteSealed: %v", name, err)
+				}
+			}
+			//空内容
+			if err := b.WriteSealed(aead, RandomNonce, func(*Buffer) {}); err != nil {
+				t.Fatal(err)
+			}
+			b.WriteInt(-2)
+
+			if b.ReadInt() != -1 {
+				t.Fatalf("%s: prefix", name)
+			}
+			for i := 0; i < 2; i++ {
+				inner, err := b.TryReadSealed(aead)
+				if err != nil {
+					t.Fatalf("%s: TryReadSealed: %v", name, err)
+				}
+				if s, v := inner.ReadUTF8String(), inner.ReadLong(); s != "密文" || v != 42 || inner.Remaining() != 0 {
+					t.Errorf("%s: decrypted %q %d", name, s, v)
+				}
+				Release(inner)
+			}
+			if inner := b.ReadSealed(aead); b.Err() != nil || inner.GetTop() != 0 {
+				t.Errorf("%s: empty section: %v, top %d", name, b.Err(), inner.GetTop())
+			}
+			if b.ReadInt() != -2 || b.Remaining() != 0 {
+				t.Errorf("%s: suffix", name)
+			}
+		}
+	}
+}
+
+//nonce、密文或认证标签被篡改时返回*AuthError，偏移位置不变
+func TestSealedTampered(t *testing.T) {
+	for name, aead := range testAEADs(t, 1) {
+		b := NewBuffer()
+		b.WriteShort(7)
+		if err := b.WriteSealed(aead, RandomNonce, func(inner *Buffer) { inner.WriteUTF8String("secret") }); err != nil {
+			t.Fatal(err)
+		}
+		_data := b.GetByte()[:b.GetTop()]
+		//nonce的首字节、密文的首字节（nonce及长度之后）、认证标签的末字节
+		for _, at := range []int{2, 2 + aead.NonceSize() + 1, len(_data) - 1} {
+			p := append([]byte(nil), _data...)
+			p[at] ^= 0x01
+			tb := NewBufferWithByte(p)
+			tb.ReadShort()
+			inner, err := tb.TryReadSealed(aead)
+			var ae *AuthError
+			if !errors.As(err, &ae) || inner != nil {
+				t.Errorf("%s: byte %d: err = %v", name, at, err)
+				continue
+			}
+			if ae.Offset != 2 || tb.GetOffset() != 2 {
+				t.Errorf("%s: byte %d: AuthError.Offset %d, offset %d; want 2", name, at, ae.Offset, tb.GetOffset())
+			}
+			if tb.ReadSealed(aead); !errors.As(tb.Err(), &ae) {
+				t.Errorf("%s: byte %d: ReadSealed err = %v", name, at, tb.Err())
+			}
+		}
+		//错误的密钥
+		tb := NewBufferWithByte(_data)
+		tb.ReadShort()
+		var ae *AuthError
+		if _, err := tb.TryReadSealed(testAEADs(t, 2)[name]); !errors.As(err, &ae) {
+			t.Errorf("%s: wrong key err = %v", name, err)
+		}
+	}
+}
+
+//计数器nonce：前缀相同，计数递增
+func TestCounterNonce(t *testing.T) {
+	src, err := NewCounterNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n1, n2 [12]byte
+	if src(n1[:]) != nil || src(n2[:]) != nil {
+		t.Fatal("nonce source failed")
+	}
+	if !bytes.Equal(n1[:4], n2[:4]) || binary.BigEndian.Uint64(n1[4:]) != 1 || binary.BigEndian.Uint64(n2[4:]) != 2 {
+		t.Errorf("nonces % x, % x", n1, n2)
+	}
+	if err := src(make([]byte, 8)); err != ErrBadLength {
+		t.Errorf("short nonce err = %v; want ErrBadLength", err)
+	}
+}
@@ -0,0 +1,302 @@
+/********************************************************/
+// 字节对象（读写跟踪及格式化输出）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.StartTrace()
+//			buf.WriteInt(42)
+//			buf.WriteUTF8String("bob")
+//			fmt.Println(buf)		//每次写入一行：偏移位置、长度、操作、值、字节
+//			fmt.Printf("%x\n", buf)		//十六进制内容
+//
+//			//接收方同样可以跟踪读取，两边的输出可以直接diff
+//			r.StartTrace()
+//			id:=r.ReadInt()
+//			name:=r.ReadUTF8String()
+//			fmt.Println(r)
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/**
+ * 跟踪记录的一项操作
+ */
+type TraceEntry struct {
+	Op     string      //方法名（如WriteInt、ReadUTF8String，TryReadXxx记为ReadXxx）
+	Offset int         //起始位置（写入时为top值，读取时为偏移位置）
+	Len    int         //写入或读取的字节数
+	Value  interface{} //写入或读取的值
+	Err    error       //读取错误（写入出错时已写入的内容被撤销，Len为0）
+	Depth  int         //嵌套层数（WriteArray、WriteObject等复合操作中的操作为1层以上）
+
+	leaf  bool //不可再分的操作（其中的调用不再记录）
+	write bool
+}
+
+/**
+ * 开始跟踪读写操作（清除之前的记录）
+ * 记录基本类型、字符串、数组、映射、可选值、消息字段及WriteObject/ReadObject等复合操作，
+ * 基本类型的操作中的内部调用（如WriteUTF8String中的WriteLength）不单独记录；io接口的读写及Peek不记录
+ */
+func (b *Buffer) StartTrace() {
+	if b.trace == nil {
+		b.trace = &tracer{}
+	}
+	b.trace.entries = b.trace.entries[:0]
+	b.trace.on = true
+}
+
+/**
+ * 停止跟踪（保留已有的记录）
+ */
+func (b *Buffer) StopTrace() {
+	if b.trace != nil {
+		b.trace.on = false
+	}
+}
+
+/**
+ * 获取跟踪记录
+ * @return 按操作开始的顺序排列的记录（复合操作在其包含的操作之前）
+ */
+func (b *Buffer) Trace() []TraceEntry {
+	if b.trace == nil {
+		return nil
+	}
+	return append([]TraceEntry(nil), b.trace.entries...)
+}
+
+/**
+ * 格式化输出
+ * 有跟踪记录时输出注释表格（每项操作一行，操作名去掉Write/Read前缀，发送方与接收方的输出可以直接比较），
+ * 否则输出十六进制视图（每行16字节）
+ */
+func (b *Buffer) String() string {
+	var sb strings.Builder
+	if b.trace != nil && len(b.trace.entries) > 0 {
+		b.writeTable(&sb)
+	} else {
+		b.writeDump(&sb)
+	}
+	return sb.String()
+}
+
+/**
+ * 实现fmt.Formatter
+ *	%v %s    与String()相同
+ *	%+v      注释表格之后附加十六进制视图
+ *	%x %X    内容（0 ~ top）的十六进制，支持% x等标志
+ */
+func (b *Buffer) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		io.WriteString(f, b.String())
+		if verb == 'v' && f.Flag('+') && b.trace != nil && len(b.trace.entries) > 0 {
+			var sb strings.Builder
+			b.writeDump(&sb)
+			io.WriteString(f, "\n"+sb.String())
+		}
+	case 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), b.span(0, b.top))
+	default:
+		fmt.Fprintf(f, "%%!%c(*byt.Buffer)", verb)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+type tracer struct {
+	entries []TraceEntry
+	on      bool
+	depth   int //当前未结束的记录层数
+	leaf    int //>0时处于不可再分的操作或预读中，不记录
+}
+
+//是否正在跟踪
+func (b *Buffer) tracing() bool {
+	return b.trace != nil && b.trace.on
+}
+
+//开始记录一项操作，返回记录的下标（不记录时返回-1），需要与traceEnd成对调用
+func (b *Buffer) traceBegin(op string, leaf bool) int {
+	t := b.trace
+	if t.leaf > 0 {
+		t.leaf++
+		return -1
+	}
+	write := strings.HasPrefix(op, "Write")
+	_pos_ := b.offset
+	if write {
+		_pos_ = b.top
+	}
+	t.entries = append(t.entries, TraceEntry{Op: op, Offset: _pos_ + b.tbase, Depth: t.depth, leaf: leaf, write: write})
+	t.depth++
+	if leaf {
+		t.leaf++
+	}
+	return len(t.entries) - 1
+}
+
+//结束一项操作，记录长度、值及错误
+func (b *Buffer) traceEnd(i int, val interface{}, err error) {
+	t := b.trace
+	if i < 0 {
+		t.leaf--
+		return
+	}
+	t.depth--
+	if i >= len(t.entries) {
+		return //记录已被StartTrace清除
+	}
+	e := &t.entries[i]
+	if e.leaf {
+		t.leaf--
+	}
+	_pos_ := b.offset
+	if e.write {
+		_pos_ = b.top
+	}
+	e.Len = max(_pos_+b.tbase-e.Offset, 0)
+	if p, ok := val.([]byte); ok && p != nil {
+		val = append([]byte{}, p...)
+	}
+	e.Value, e.Err = val, err
+	if err != nil && e.write {
+		t.entries = t.entries[:i+1] //写入出错时内容已被撤销
+		e.Len = 0
+	}
+}
+
+//当前的记录数（传给traceTruncate）
+func (b *Buffer) traceMark() int {
+	if b.trace == nil {
+		return 0
+	}
+	return len(b.trace.entries)
+}
+
+//撤销写入的内容时丢弃之后的记录
+func (b *Buffer) traceTruncate(n int) {
+	if b.trace != nil && n < len(b.trace.entries) {
+		b.trace.entries = b.trace.entries[:n]
+	}
+}
+
+//结束消息字段的记录（TryReadMessage中），内容由字段的读取函数另行记录，不计入长度
+func (b *Buffer) traceField(i, tag int, wire Wire, n int, err error) {
+	if i < 0 {
+		b.traceEnd(i, nil, err)
+		return
+	}
+	var val interface{}
+	if tag == 0 && err == nil {
+		b.trace.entries[i].Op = "ReadMessageEnd"
+	} else if err == nil {
+		val = strconv.Itoa(tag) + " " + wire.String()
+	}
+	b.traceEnd(i, val, err)
+	if i < len(b.trace.entries) {
+		b.trace.entries[i].Len -= n
+	}
+}
+
+//写入映射的键（键先写入单独的对象以便排序，跟踪时其中的记录一并移入）
+func (b *Buffer) writeKey(kb *Buffer) {
+	if !b.tracing() || kb.trace == nil {
+		b.WriteBytes(kb.byt, 0, kb.top)
+		return
+	}
+	if t := b.trace; t.leaf == 0 {
+		for _, e := range kb.trace.entries {
+			e.Offset += b.top + b.tbase
+			e.Depth += t.depth
+			t.entries = append(t.entries, e)
+		}
+	}
+	b.tracePause()
+	b.WriteBytes(kb.byt, 0, kb.top)
+	b.traceResume()
+}
+
+//暂停记录（预读），需要与traceResume成对调用
+func (b *Buffer) tracePause() {
+	if b.trace != nil {
+		b.trace.leaf++
+	}
+}
+
+func (b *Buffer) traceResume() {
+	if b.trace != nil {
+		b.trace.leaf--
+	}
+}
+
+//在位置pos插入了n个字节（EndField插入内容长度），调整之后的记录
+func (b *Buffer) traceInsert(pos, n int) {
+	for i := range b.trace.entries {
+		e := &b.trace.entries[i]
+		if e.Offset >= pos+b.tbase {
+			e.Offset += n
+		} else if e.Offset+e.Len == pos+b.tbase {
+			e.Len += n
+		}
+	}
+}
+
+func (b *Buffer) writeTable(w io.Writer) {
+	fmt.Fprintf(w, "%-8s %-6s %-24s %-32s %s\n", "offset", "len", "op", "value", "bytes")
+	for _, e := range b.trace.entries {
+		op := strings.Repeat("  ", e.Depth) + strings.TrimPrefix(strings.TrimPrefix(e.Op, "Write"), "Read")
+		val := traceValue(e.Value)
+		if e.Err != nil {
+			val = "[ERR]: " + e.Err.Error()
+		}
+		_raw, _more := []byte(nil), ""
+		if _end := e.Offset - b.tbase + e.Len; e.Len > 0 && _end <= b.top {
+			_raw = b.span(e.Offset-b.tbase, _end)
+		}
+		if len(_raw) > 16 {
+			_raw, _more = _raw[:16], " ..."
+		}
+		fmt.Fprintf(w, "%-8d %-6d %-24s %-32s % x%s\n", e.Offset, e.Len, op, val, _raw, _more)
+	}
+}
+
+func (b *Buffer) writeDump(w io.Writer) {
+	for line := 0; line < b.top; line += 16 {
+		_row := b.span(line, min(line+16, b.top))
+		_asc := []byte(string(_row))
+		for i, c := range _asc {
+			if c < 0x20 || c >= 0x7f {
+				_asc[i] = '.'
+			}
+		}
+		fmt.Fprintf(w, "%08x  %-48s |%s|\n", line, fmt.Sprintf("% x", _row), _asc)
+	}
+}
+
+//格式化记录的值
+func traceValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strconv.Quote(x)
+	case []byte:
+		if len(x) > 12 {
+			return "[" + strconv.Itoa(len(x)) + "]" + hex.EncodeToString(x[:12]) + "..."
+		}
+		return "[" + strconv.Itoa(len(x)) + "]" + hex.EncodeToString(x)
+	}
+	return fmt.Sprintf("%v", v)
+}
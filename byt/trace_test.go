@@ -0,0 +1,120 @@
+package byt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//跟踪写入及读取同一组内容，两边的注释表格相同
+func TestTraceWriteRead(t *testing.T) {
+	type item struct {
+		Id   int32
+		Name string
+	}
+	w := NewBuffer()
+	w.StartTrace()
+	w.WriteInt(42)
+	w.WriteUTF8String("bob")
+	w.WriteData([]byte{1, 2, 3})
+	w.WriteVarint(-3)
+	if err := w.WriteObject(&item{7, "剑"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewBufferWithByte(w.GetByte()[:w.GetTop()])
+	r.StartTrace()
+	r.ReadInt()
+	r.ReadUTF8String()
+	r.ReadData()
+	r.ReadVarint()
+	var v item
+	if err := r.ReadObject(&v); err != nil {
+		t.Fatal(err)
+	}
+
+	if ws, rs := w.String(), r.String(); ws != rs {
+		t.Errorf("write trace\n%s\nread trace\n%s", ws, rs)
+	}
+	_lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+	if len(_lines) != 8 || !strings.HasPrefix(_lines[0], "offset") {
+		t.Fatalf("table:\n%s", w)
+	}
+	for i, want := range []string{
+		`0        4      Int                      42                               00 00 00 2a`,
+		`4        4      UTF8String               "bob"                            84 62 6f 62`,
+		`13       8      Object                   "*byt.item"                      00 00 00 07 84 e5 89 91`,
+		`13       4        Int                    7                                00 00 00 07`,
+	} {
+		if !containsLine(_lines, want) {
+			t.Errorf("line %d %q not in table:\n%s", i, want, w)
+		}
+	}
+	if tr := r.Trace(); len(tr) != 7 || tr[0].Op != "ReadInt" || tr[0].Value != int32(42) || tr[6].Depth != 1 {
+		t.Errorf("Trace() = %+v", tr)
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if strings.TrimRight(l, " ") == want {
+			return true
+		}
+	}
+	return false
+}
+
+//读取失败记录错误，StopTrace之后不再记录
+func TestTraceErrorAndStop(t *testing.T) {
+	b := NewBufferWithByte([]byte{0, 1})
+	b.StartTrace()
+	if _, err := b.TryReadInt(); err != ErrUnderflow {
+		t.Fatalf("TryReadInt err = %v", err)
+	}
+	b.StopTrace()
+	b.ReadShort()
+	tr := b.Trace()
+	if len(tr) != 1 || tr[0].Op != "ReadInt" || tr[0].Err != ErrUnderflow || tr[0].Len != 0 {
+		t.Errorf("Trace() = %+v", tr)
+	}
+	if s := b.String(); !strings.Contains(s, "[ERR]: "+ErrUnderflow.Error()) {
+		t.Errorf("String() = %s", s)
+	}
+}
+
+//没有跟踪记录时输出十六进制视图，%+v在表格之后附加十六进制视图，%x输出内容
+func TestFormat(t *testing.T) {
+	b := NewBuffer()
+	b.WriteBytes([]byte("0123456789abcdefXY\x00"), 0, 19)
+	dump := "00000000  30 31 32 33 34 35 36 37 38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+		"00000010  58 59 00" + strings.Repeat(" ", 41) + "|XY.|\n"
+	if s := b.String(); s != dump {
+		t.Errorf("String() =\n%q\nwant\n%q", s, dump)
+	}
+	if s := fmt.Sprintf("%v", b); s != dump {
+		t.Errorf("%%v =\n%s", s)
+	}
+	if s := fmt.Sprintf("%+v", b); s != dump {
+		t.Errorf("%%+v without trace =\n%s", s)
+	}
+
+	b = NewBuffer()
+	b.StartTrace()
+	b.WriteShort(-2)
+	table := b.String()
+	if !strings.HasPrefix(table, "offset") {
+		t.Fatalf("String() with trace =\n%s", table)
+	}
+	if s := fmt.Sprintf("%v", b); s != table {
+		t.Errorf("%%v =\n%s\nwant\n%s", s, table)
+	}
+	want := table + "\n" + "00000000  ff fe" + strings.Repeat(" ", 44) + "|..|\n"
+	if s := fmt.Sprintf("%+v", b); s != want {
+		t.Errorf("%%+v =\n%q\nwant\n%q", s, want)
+	}
+	for format, want := range map[string]string{"%x": "fffe", "% x": "ff fe", "%X": "FFFE", "%d": "%!d(*byt.Buffer)"} {
+		if s := fmt.Sprintf(format, b); s != want {
+			t.Errorf("%s = %q; want %q", format, s, want)
+		}
+	}
+}
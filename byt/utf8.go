@@ -0,0 +1,220 @@
+/********************************************************/
+// 字节对象（Java/ActionScript兼容的modified UTF-8字符串）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.NewBuffer()
+//			buf.WriteUTF("HelloWorld!")	//与DataOutputStream.writeUTF一致
+//			s:=buf.ReadUTF()			//与DataInputStream.readUTF一致
+/********************************************************/
+
+package byt
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/**
+ * WriteUTF可写入的最大字节长度（长度前缀为uint16）
+ */
+const __maxutflength__ int = 0xffff
+
+/**
+ * 读取一个modified UTF-8字符串（与Java DataInputStream.readUTF、ActionScript ByteArray.readUTF兼容）
+ * 长度前缀为uint16（使用字节缓冲对象的编码模式），同时兼容标准的4字节utf8序列
+ */
+func (b *Buffer) ReadUTF() string {
+	if b.err != nil {
+		return ""
+	}
+	v, err := b.TryReadUTF()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个modified UTF-8字符串（与Java DataInputStream.readUTF、ActionScript ByteArray.readUTF兼容）
+ * @return 字符串，错误信息（ErrUnderflow、*LimitError、ErrBadString）
+ */
+func (b *Buffer) TryReadUTF() (v string, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUTF", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	l, err := b.TryReadUnsignedShort()
+	if err != nil {
+		return "", err
+	}
+	if err := b.CheckLimit(LimitString, int(l)); err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	data, err := b.next(int(l))
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	s, err := decodeModifiedUTF8(data)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return "", err
+	}
+	return s, nil
+}
+
+/**
+ * 写一个modified UTF-8字符串（与Java DataOutputStream.writeUTF、ActionScript ByteArray.writeUTF兼容）
+ * NUL写为0xC0 0x80，U+FFFF以上的字符写为代理对（每个代理3个字节），长度前缀为uint16
+ * @param s 字符串值（编码后不能超过65535字节）
+ */
+func (b *Buffer) WriteUTF(s string) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUTF", true), s, nil)
+	}
+	_len := modifiedUTF8Length(s)
+	if _len > __maxutflength__ {
+		fmt.Println("[ERR]: WriteUTF 字符串过长. length = " + strconv.Itoa(_len))
+		return
+	}
+	b.WriteUnsignedShort(uint16(_len))
+
+	appendModifiedUTF8(b.grow(_len)[:0], s)
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//modified UTF-8编码后的字节长度
+func modifiedUTF8Length(s string) int {
+	var _len_ int = 0
+	for _, c := range s {
+		if (c >= 0x0001) && (c <= 0x007f) {
+			_len_++
+		} else if c > 0xffff {
+			_len_ += 6
+		} else if c > 0x07ff {
+			_len_ += 3
+		} else {
+			_len_ += 2
+		}
+	}
+	return _len_
+}
+
+//以modified UTF-8编码追加字符串
+func appendModifiedUTF8(dst []byte, s string) []byte {
+	for _, c := range s {
+		if (c >= 0x0001) && (c <= 0x007f) {
+			dst = append(dst, byte(c))
+
+		} else if c > 0xffff {
+			r1, r2 := utf16.EncodeRune(c)
+			dst = appendModifiedUTF8Char(dst, r1)
+			dst = appendModifiedUTF8Char(dst, r2)
+
+		} else {
+			dst = appendModifiedUTF8Char(dst, c)
+		}
+	}
+	return dst
+}
+
+//写入一个U+FFFF以内的字符（NUL及代理同样按2/3字节形式写入）
+func appendModifiedUTF8Char(dst []byte, c rune) []byte {
+	if c > 0x07ff {
+		return append(dst,
+			byte(0xe0|((c>>12)&0x0f)),
+			byte(0x80|((c>>6)&0x3f)),
+			byte(0x80|(c&0x3f)))
+	}
+	return append(dst,
+		byte(0xc0|((c>>6)&0x1f)),
+		byte(0x80|(c&0x3f)))
+}
+
+//解码modified UTF-8内容，同时兼容标准的4字节utf8序列
+func decodeModifiedUTF8(data []byte) (string, error) {
+	var (
+		c   int
+		cc  int
+		ccc int
+		r   rune
+	)
+
+	_news := make([]byte, 0, len(data))
+	_pos := 0
+	_end := len(data)
+	for _pos < _end {
+		c = int(data[_pos])
+		switch c >> 4 {
+		case 0, 1, 2, 3, 4, 5, 6, 7:
+			// 0xxx xxxx
+			_pos++
+			_news = append(_news, byte(c))
+			continue
+
+		case 12, 13:
+			// 110x xxxx 10xx xxxx
+			if _pos+2 > _end {
+				return "", ErrBadString
+			}
+			cc = int(data[_pos+1])
+			if (cc & 0xC0) != 0x80 {
+				return "", ErrBadString
+			}
+			_pos += 2
+			r = rune(((c & 0x1f) << 6) | (cc & 0x3f))
+
+		case 14:
+			// 1110 xxxx 10xx xxxx 10xx xxxx
+			if _pos+3 > _end {
+				return "", ErrBadString
+			}
+			cc = int(data[_pos+1])
+			ccc = int(data[_pos+2])
+			if ((cc & 0xC0) != 0x80) || ((ccc & 0xC0) != 0x80) {
+				return "", ErrBadString
+			}
+			_pos += 3
+			r = rune(((c & 0x0f) << 12) | ((cc & 0x3f) << 6) | (ccc & 0x3f))
+
+			//代理对
+			if utf16.IsSurrogate(r) {
+				if r >= 0xdc00 || _pos+3 > _end || data[_pos] != 0xed {
+					return "", ErrBadString
+				}
+				cc = int(data[_pos+1])
+				ccc = int(data[_pos+2])
+				if ((cc & 0xC0) != 0x80) || ((ccc & 0xC0) != 0x80) {
+					return "", ErrBadString
+				}
+				r2 := rune((0x0d << 12) | ((cc & 0x3f) << 6) | (ccc & 0x3f))
+				if r2 < 0xdc00 || r2 > 0xdfff {
+					return "", ErrBadString
+				}
+				_pos += 3
+				r = utf16.DecodeRune(r, r2)
+			}
+
+		case 15:
+			// 1111 0xxx 10xx xxxx 10xx xxxx 10xx xxxx（标准utf8）
+			rr, size := utf8.DecodeRune(data[_pos:])
+			if rr == utf8.RuneError && size <= 1 {
+				return "", ErrBadString
+			}
+			_pos += size
+			r = rr
+
+		default:
+			// 10xx xxxx
+			return "", ErrBadString
+		}
+		_news = utf8.AppendRune(_news, r)
+	}
+	return string(_news), nil
+}
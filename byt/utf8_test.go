@@ -0,0 +1,72 @@
+package byt
+
+import (
+	"bytes"
+	"testing"
+)
+
+//U+FFFF以上的字符按4字节utf8写入及读取
+func TestUTF8StringSupplementary(t *testing.T) {
+	for _, s := range []string{"😀", "a𝄞b", "𠀀中文😀"} {
+		b := NewBuffer()
+		b.WriteUTF8String(s)
+		if want := append([]byte{byte(0x80 + len(s) + 1)}, s...); !bytes.Equal(b.GetByte()[:b.GetTop()], want) {
+			t.Errorf("%q: wrote % x; want % x", s, b.GetByte()[:b.GetTop()], want)
+		}
+		if v, err := b.TryReadUTF8String(); err != nil || v != s {
+			t.Errorf("%q: TryReadUTF8String = %q, %v", s, v, err)
+		}
+	}
+}
+
+//modified UTF-8：NUL写为C0 80，U+FFFF以上的字符写为代理对
+func TestModifiedUTF8(t *testing.T) {
+	cases := []struct {
+		s    string
+		data []byte
+	}{
+		{"", nil},
+		{"a\x00b", []byte{'a', 0xc0, 0x80, 'b'}},
+		{"é中", []byte{0xc3, 0xa9, 0xe4, 0xb8, 0xad}},
+		{"😀", []byte{0xed, 0xa0, 0xbd, 0xed, 0xb8, 0x80}}, //U+1F600 = D83D DE00
+	}
+	for _, c := range cases {
+		b := NewBuffer()
+		b.WriteUTF(c.s)
+		want := append([]byte{0, byte(len(c.data))}, c.data...)
+		if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+			t.Errorf("WriteUTF(%q) = % x; want % x", c.s, got, want)
+		}
+		if v, err := b.TryReadUTF(); err != nil || v != c.s {
+			t.Errorf("TryReadUTF = %q, %v; want %q", v, err, c.s)
+		}
+	}
+
+	//标准的4字节utf8序列同样可以读取
+	b := NewBufferWithByte([]byte{0, 4, 0xf0, 0x9f, 0x98, 0x80})
+	if v, err := b.TryReadUTF(); err != nil || v != "😀" {
+		t.Errorf("4-byte sequence: %q, %v", v, err)
+	}
+}
+
+//不合法的modified UTF-8返回ErrBadString，偏移位置不变
+func TestModifiedUTF8Malformed(t *testing.T) {
+	for _, data := range [][]byte{
+		{0x80},                               //单独的后续字节
+		{0xc3},                               //不完整的2字节序列
+		{0xc3, 0x28},                         //后续字节不合法
+		{0xe4, 0xb8},                         //不完整的3字节序列
+		{0xed, 0xa0, 0xbd},                   //只有高位代理
+		{0xed, 0xb8, 0x80, 0xed, 0xa0, 0xbd}, //低位代理在前
+		{0xed, 0xa0, 0xbd, 0xe4, 0xb8, 0xad}, //高位代理之后不是低位代理
+		{0xf0, 0x9f, 0x98},                   //不完整的4字节序列
+	} {
+		b := NewBufferWithByte(append([]byte{0, byte(len(data))}, data...))
+		if _, err := b.TryReadUTF(); err != ErrBadString || b.GetOffset() != 0 {
+			t.Errorf("% x: err %v, offset %d; want ErrBadString, 0", data, err, b.GetOffset())
+		}
+		if b.ReadUTF(); b.Err() != ErrBadString {
+			t.Errorf("% x: ReadUTF err = %v", data, b.Err())
+		}
+	}
+}
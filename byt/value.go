@@ -0,0 +1,346 @@
+/********************************************************/
+// 字节对象（自描述的带类型标记的值）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			var tree interface{}
+//			json.Unmarshal(text, &tree)
+//			buf.WriteValue(tree)			//每个值前写入1字节类型标记
+//			...
+//			v,err:=buf.ReadValue()			//不需要事先知道数据结构
+/********************************************************/
+
+package byt
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+/**
+ * 值的类型标记
+ */
+type Tag byte
+
+const (
+	TagNull    Tag = iota //nil（无内容）
+	TagBool               //WriteBoolean
+	TagInt8               //WriteByt
+	TagInt16              //WriteShort
+	TagInt32              //WriteInt
+	TagInt64              //WriteLong（int也按int64写入）
+	TagUint8              //WriteUnsignedByt
+	TagUint16             //WriteUnsignedShort
+	TagUint32             //WriteUnsignedInt
+	TagUint64             //WriteUnsignedLong（uint也按uint64写入）
+	TagFloat32            //WriteFloat32
+	TagFloat64            //WriteFloat
+	TagUTF8               //WriteUTF8String
+	TagData               //WriteData
+	TagArray              //WriteLength(元素数量+1)，之后为各元素（带类型标记）
+	TagMap                //WriteLength(键值对数量+1)，之后为各键值对（键、值均带类型标记）
+)
+
+/**
+ * 嵌套的数组、映射的最大深度
+ */
+const __maxdepth__ int = 64
+
+var __tagnames__ = [...]string{"null", "bool", "int8", "int16", "int32", "int64",
+	"uint8", "uint16", "uint32", "uint64", "float32", "float64", "utf8", "data", "array", "map"}
+
+/**
+ * 类型名称
+ */
+func (t Tag) String() string {
+	if int(t) < len(__tagnames__) {
+		return __tagnames__[t]
+	}
+	return "Tag(" + strconv.Itoa(int(t)) + ")"
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个带类型标记的值
+ * 支持nil、bool、各种整数、float32、float64、string、[]byte，以及由它们组成的切片、数组、映射和指针
+ * （encoding/json解码得到的map[string]interface{}、[]interface{}可以直接写入）
+ * 映射按键的编码结果排序，相同的内容得到相同的字节
+ * @param v 值
+ * @return 错误信息（*UnsupportedTypeError），出错时不写入任何内容
+ */
+func (b *Buffer) WriteValue(v interface{}) (err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("WriteValue", false)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	_top := b.top
+	if err := writeTagged(b, reflect.ValueOf(v), 0); err != nil {
+		b.top = _top
+		return err
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个带类型标记的值
+ * 整数、浮点数按写入时的类型返回，[]byte、string、nil保持不变；
+ * 数组返回[]interface{}，键均为string的映射返回map[string]interface{}，否则返回map[interface{}]interface{}
+ * 出错时偏移位置恢复至读取前的位置
+ * @return 值，错误信息（ErrUnderflow、ErrBadLength、*LimitError、ErrTooLarge（嵌套过深）、ErrBadString、ErrBadTag）
+ */
+func (b *Buffer) ReadValue() (v interface{}, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadValue", false)
+		defer func() { b.traceEnd(_t_, nil, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	v, err = readTagged(b, 0)
+	if err != nil {
+		b.offset, b.rbits = _start, _bits
+		return nil, err
+	}
+	return v, nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//depth与readTagged相同，只计算数组及映射的嵌套层数
+func writeTagged(b *Buffer, v reflect.Value, depth int) error {
+	if depth > __maxdepth__ {
+		return ErrTooLarge
+	}
+	if !v.IsValid() {
+		b.WriteUnsignedByt(byte(TagNull))
+		return nil
+	}
+	//指针及接口不计入嵌套层数，但限制连续解引用的次数（防止循环引用）
+	for i := 0; v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr; i++ {
+		if v.IsNil() {
+			b.WriteUnsignedByt(byte(TagNull))
+			return nil
+		}
+		if i >= __maxdepth__ {
+			return ErrTooLarge
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		b.WriteUnsignedByt(byte(TagBool))
+		b.WriteBoolean(v.Bool())
+	case reflect.Int8:
+		b.WriteUnsignedByt(byte(TagInt8))
+		b.WriteByt(int8(v.Int()))
+	case reflect.Int16:
+		b.WriteUnsignedByt(byte(TagInt16))
+		b.WriteShort(int16(v.Int()))
+	case reflect.Int32:
+		b.WriteUnsignedByt(byte(TagInt32))
+		b.WriteInt(int32(v.Int()))
+	case reflect.Int64, reflect.Int:
+		b.WriteUnsignedByt(byte(TagInt64))
+		b.WriteLong(v.Int())
+	case reflect.Uint8:
+		b.WriteUnsignedByt(byte(TagUint8))
+		b.WriteUnsignedByt(uint8(v.Uint()))
+	case reflect.Uint16:
+		b.WriteUnsignedByt(byte(TagUint16))
+		b.WriteUnsignedShort(uint16(v.Uint()))
+	case reflect.Uint32:
+		b.WriteUnsignedByt(byte(TagUint32))
+		b.WriteUnsignedInt(uint32(v.Uint()))
+	case reflect.Uint64, reflect.Uint:
+		b.WriteUnsignedByt(byte(TagUint64))
+		b.WriteUnsignedLong(v.Uint())
+	case reflect.Float32:
+		b.WriteUnsignedByt(byte(TagFloat32))
+		b.WriteFloat32(float32(v.Float()))
+	case reflect.Float64:
+		b.WriteUnsignedByt(byte(TagFloat64))
+		b.WriteFloat(v.Float())
+	case reflect.String:
+		b.WriteUnsignedByt(byte(TagUTF8))
+		b.WriteUTF8String(v.String())
+
+	case reflect.Slice, reflect.Array:
+		if isByteSlice(v.Type()) {
+			b.WriteUnsignedByt(byte(TagData))
+			if v.IsNil() {
+				b.WriteLength(0)
+				return nil
+			}
+			b.WriteData(v.Bytes())
+			return nil
+		}
+		b.WriteUnsignedByt(byte(TagArray))
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteLength(0)
+			return nil
+		}
+		b.WriteLength(v.Len() + 1)
+		for i := 0; i < v.Len(); i++ {
+			if err := writeTagged(b, v.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		b.WriteUnsignedByt(byte(TagMap))
+		if v.IsNil() {
+			b.WriteLength(0)
+			return nil
+		}
+		b.WriteLength(v.Len() + 1)
+		return writeTaggedMap(b, v, depth)
+
+	default:
+		return &UnsupportedTypeError{Type: v.Type()}
+	}
+	return nil
+}
+
+//映射按键的编码结果排序
+func writeTaggedMap(b *Buffer, v reflect.Value, depth int) error {
+	type entry struct {
+		key []byte
+		val reflect.Value
+		kb  *Buffer
+	}
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		kb := NewBufferWithOrder(b.Order())
+		if b.tracing() {
+			kb.StartTrace()
+		}
+		if err := writeTagged(kb, iter.Key(), depth+1); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: kb.GetByte()[:kb.GetTop()], val: iter.Value(), kb: kb})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	for _, e := range entries {
+		b.writeKey(e.kb)
+		if err := writeTagged(b, e.val, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readTagged(b *Buffer, depth int) (interface{}, error) {
+	if depth > __maxdepth__ {
+		return nil, ErrTooLarge
+	}
+	t, err := b.TryReadUnsignedByt()
+	if err != nil {
+		return nil, err
+	}
+
+	switch Tag(t) {
+	case TagNull:
+		return nil, nil
+	case TagBool:
+		return b.TryReadBoolean()
+	case TagInt8:
+		return b.TryReadByt()
+	case TagInt16:
+		return b.TryReadShort()
+	case TagInt32:
+		return b.TryReadInt()
+	case TagInt64:
+		return b.TryReadLong()
+	case TagUint8:
+		return b.TryReadUnsignedByt()
+	case TagUint16:
+		return b.TryReadUnsignedShort()
+	case TagUint32:
+		return b.TryReadUnsignedInt()
+	case TagUint64:
+		return b.TryReadUnsignedLong()
+	case TagFloat32:
+		return b.TryReadFloat32()
+	case TagFloat64:
+		return b.TryReadFloat()
+	case TagUTF8:
+		return b.TryReadUTF8String()
+
+	case TagData:
+		if n, err := b.PeekLength(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			b.TryReadLength()
+			return []byte(nil), nil
+		}
+		return b.TryReadData()
+
+	case TagArray:
+		n, err := b.TryReadCount()
+		if err != nil || n < 0 {
+			return []interface{}(nil), err
+		}
+		arr := make([]interface{}, 0, min(n, b.Remaining()))
+		for i := 0; i < n; i++ {
+			e, err := readTagged(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, e)
+		}
+		return arr, nil
+
+	case TagMap:
+		n, err := b.TryReadCount()
+		if err != nil || n < 0 {
+			return map[string]interface{}(nil), err
+		}
+		keys := make([]interface{}, 0, min(n, b.Remaining()))
+		vals := make([]interface{}, 0, min(n, b.Remaining()))
+		_str := true
+		for i := 0; i < n; i++ {
+			k, err := readTagged(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			if k != nil && !reflect.TypeOf(k).Comparable() {
+				return nil, ErrBadTag
+			}
+			if _, ok := k.(string); !ok {
+				_str = false
+			}
+			e, err := readTagged(b, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+			vals = append(vals, e)
+		}
+		if _str {
+			m := make(map[string]interface{}, len(keys))
+			for i, k := range keys {
+				m[k.(string)] = vals[i]
+			}
+			return m, nil
+		}
+		m := make(map[interface{}]interface{}, len(keys))
+		for i, k := range keys {
+			m[k] = vals[i]
+		}
+		return m, nil
+	}
+	return nil, ErrBadTag
+}
@@ -0,0 +1,92 @@
+package byt
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+//n层嵌套的数组，每层的元素经过指针及接口包装
+func nestedValue(n int) interface{} {
+	var v interface{} = "leaf"
+	for i := 0; i < n; i++ {
+		var e interface{} = v
+		v = []interface{}{&e}
+	}
+	return v
+}
+
+//写入与读取的嵌套层数限制相同（指针及接口不计入层数）
+func TestValueDepthLimit(t *testing.T) {
+	b := NewBuffer()
+	if err := b.WriteValue(nestedValue(__maxdepth__)); err != nil {
+		t.Fatalf("WriteValue(depth %d): %v", __maxdepth__, err)
+	}
+	v, err := b.ReadValue()
+	if err != nil {
+		t.Fatalf("ReadValue(depth %d): %v", __maxdepth__, err)
+	}
+	for i := 0; i < __maxdepth__; i++ {
+		v = v.([]interface{})[0]
+	}
+	if v != "leaf" {
+		t.Errorf("leaf = %v", v)
+	}
+
+	b.Zero()
+	if err := b.WriteValue(nestedValue(__maxdepth__ + 1)); err != ErrTooLarge || b.GetTop() != 0 {
+		t.Errorf("WriteValue(depth %d) = %v, top %d", __maxdepth__+1, err, b.GetTop())
+	}
+	//手工写入超过限制的嵌套，读取时同样失败
+	for i := 0; i <= __maxdepth__; i++ {
+		b.WriteUnsignedByt(byte(TagArray))
+		b.WriteLength(2)
+	}
+	b.WriteUnsignedByt(byte(TagNull))
+	if _, err := b.ReadValue(); err != ErrTooLarge || b.GetOffset() != 0 {
+		t.Errorf("ReadValue(depth %d) = %v, offset %d", __maxdepth__+1, err, b.GetOffset())
+	}
+}
+
+//循环引用的指针返回错误而不是无限递归
+func TestValuePointerCycle(t *testing.T) {
+	var x interface{}
+	x = &x
+	b := NewBuffer()
+	if err := b.WriteValue(x); err != ErrTooLarge {
+		t.Errorf("WriteValue(cycle) = %v; want ErrTooLarge", err)
+	}
+}
+
+//encoding/json解码得到的值写入后读取的结果与原值相同
+func TestValueJSON(t *testing.T) {
+	for _, text := range []string{
+		`null`,
+		`true`,
+		`-1.5e300`,
+		`"文本"`,
+		`[]`,
+		`{}`,
+		`[1, "a", null, false, [2.5, []], {"k": {}}]`,
+		`{"name": "bob", "hp": 100, "pos": [1.25, -3], "tags": ["a", "b"], "vip": null,
+		  "bag": {"items": [{"id": 1, "n": 2}, {"id": 3, "n": 0.5}], "gold": 1e15}, "": ""}`,
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(text), &v); err != nil {
+			t.Fatal(err)
+		}
+		b := NewBuffer()
+		if err := b.WriteValue(v); err != nil {
+			t.Errorf("%s: WriteValue: %v", text, err)
+			continue
+		}
+		got, err := b.ReadValue()
+		if err != nil || b.Remaining() != 0 {
+			t.Errorf("%s: ReadValue: %v, %d bytes left", text, err, b.Remaining())
+			continue
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("%s: ReadValue = %#v; want %#v", text, got, v)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+/********************************************************/
+// 字节对象（变长整数编码）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf:=byt.NewBuffer()
+//			buf.WriteUvarint(300)	//与binary.PutUvarint及protobuf uint64一致
+//			buf.WriteVarint(-1)	//与binary.PutVarint及protobuf sint64一致（zigzag）
+/********************************************************/
+
+package byt
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+/**
+ * zigzag编码（将有符号整数映射为无符号整数：0,-1,1,-2... => 0,1,2,3...）
+ */
+func ZigzagEncode(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+/**
+ * zigzag解码
+ */
+func ZigzagDecode(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+////////////////////////////////////////////////////
+//						读						  //
+////////////////////////////////////////////////////
+
+/**
+ * 读取一个无符号变长整数（与binary.Uvarint及protobuf uint32/uint64一致）
+ */
+func (b *Buffer) ReadUvarint() uint64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadUvarint()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个zigzag编码的有符号变长整数（与binary.Varint及protobuf sint64一致）
+ */
+func (b *Buffer) ReadVarint() int64 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadVarint()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个zigzag编码的32位有符号变长整数（与protobuf sint32一致）
+ */
+func (b *Buffer) ReadZigzag32() int32 {
+	if b.err != nil {
+		return 0
+	}
+	v, err := b.TryReadZigzag32()
+	b.fail(err)
+	return v
+}
+
+/**
+ * 读取一个无符号变长整数
+ * @return 值，错误信息（ErrUnderflow、ErrOverflow）
+ */
+func (b *Buffer) TryReadUvarint() (v uint64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadUvarint", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	b.live()
+	//多取一个字节：连续10个以上的后续字节为溢出而不是内容不完整
+	v, n := binary.Uvarint(b.span(b.offset, min(b.offset+binary.MaxVarintLen64+1, b.top)))
+	if n == 0 {
+		return 0, ErrUnderflow
+	}
+	if n < 0 {
+		return 0, ErrOverflow
+	}
+	b.next(n)
+	return v, nil
+}
+
+/**
+ * 读取一个zigzag编码的有符号变长整数
+ * @return 值，错误信息（ErrUnderflow、ErrOverflow）
+ */
+func (b *Buffer) TryReadVarint() (v int64, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadVarint", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_u, err := b.TryReadUvarint()
+	if err != nil {
+		return 0, err
+	}
+	return ZigzagDecode(_u), nil
+}
+
+/**
+ * 读取一个zigzag编码的32位有符号变长整数
+ * @return 值，错误信息（ErrUnderflow、ErrOverflow）
+ */
+func (b *Buffer) TryReadZigzag32() (v int32, err error) {
+	if b.tracing() {
+		_t_ := b.traceBegin("ReadZigzag32", true)
+		defer func() { b.traceEnd(_t_, v, err) }()
+	}
+	_start, _bits := b.offset, b.rbits
+	_u, err := b.TryReadVarint()
+	if err != nil {
+		return 0, err
+	}
+	if _u < math.MinInt32 || _u > math.MaxInt32 {
+		b.offset, b.rbits = _start, _bits
+		return 0, ErrOverflow
+	}
+	return int32(_u), nil
+}
+
+////////////////////////////////////////////////////
+//						写						  //
+////////////////////////////////////////////////////
+
+/**
+ * 写一个无符号变长整数（每字节7位，最多10个字节）
+ * protobuf的int32/int64字段（非zigzag）可使用WriteUvarint(uint64(v))写入
+ * @param val 值
+ */
+func (b *Buffer) WriteUvarint(val uint64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteUvarint", true), val, nil)
+	}
+	var _b_ [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(_b_[:], val)
+	copy(b.grow(n), _b_[:n])
+}
+
+/**
+ * 写一个zigzag编码的有符号变长整数
+ * @param val 值
+ */
+func (b *Buffer) WriteVarint(val int64) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteVarint", true), val, nil)
+	}
+	b.WriteUvarint(ZigzagEncode(val))
+}
+
+/**
+ * 写一个zigzag编码的32位有符号变长整数
+ * @param val 值
+ */
+func (b *Buffer) WriteZigzag32(val int32) {
+	if b.tracing() {
+		defer b.traceEnd(b.traceBegin("WriteZigzag32", true), val, nil)
+	}
+	b.WriteVarint(int64(val))
+}
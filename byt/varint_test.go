@@ -0,0 +1,89 @@
+package byt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+//写入的内容与binary.AppendUvarint/AppendVarint相同，读取的值相同
+func TestVarintMatchesBinary(t *testing.T) {
+	uvals := []uint64{0, 1, 127, 128, 300, 16383, 16384, 1<<32 - 1, 1 << 32, 1<<63 - 1, 1 << 63, math.MaxUint64}
+	vals := []int64{0, 1, -1, 63, -64, 64, -65, 300, -300, math.MaxInt32, math.MinInt32, math.MaxInt64, math.MinInt64}
+	b := NewBuffer()
+	var want []byte
+	for _, v := range uvals {
+		b.WriteUvarint(v)
+		want = binary.AppendUvarint(want, v)
+	}
+	for _, v := range vals {
+		b.WriteVarint(v)
+		want = binary.AppendVarint(want, v)
+	}
+	if got := b.GetByte()[:b.GetTop()]; !bytes.Equal(got, want) {
+		t.Fatalf("wrote\n% x\nwant\n% x", got, want)
+	}
+	for _, v := range uvals {
+		if got, err := b.TryReadUvarint(); err != nil || got != v {
+			t.Errorf("TryReadUvarint = %d, %v; want %d", got, err, v)
+		}
+	}
+	for _, v := range vals {
+		if got, err := b.TryReadVarint(); err != nil || got != v {
+			t.Errorf("TryReadVarint = %d, %v; want %d", got, err, v)
+		}
+	}
+	if b.Remaining() != 0 {
+		t.Errorf("%d bytes left", b.Remaining())
+	}
+}
+
+//溢出及内容不完整时返回错误，偏移位置不变
+func TestVarintErrors(t *testing.T) {
+	_over := append(bytes.Repeat([]byte{0xff}, 9), 0x02) //超过64位
+	_long := bytes.Repeat([]byte{0x80}, 11)              //超过10个字节
+	cases := []struct {
+		name string
+		data []byte
+		read func(b *Buffer) error
+		want error
+	}{
+		{"Uvarint empty", nil, readUvarint, ErrUnderflow},
+		{"Uvarint truncated", []byte{0xff, 0xff}, readUvarint, ErrUnderflow},
+		{"Uvarint truncated 9 bytes", bytes.Repeat([]byte{0x80}, 9), readUvarint, ErrUnderflow},
+		{"Uvarint overflow", _over, readUvarint, ErrOverflow},
+		{"Uvarint too long", _long, readUvarint, ErrOverflow},
+		{"Zigzag32 truncated", []byte{0x80}, readZigzag32, ErrUnderflow},
+		{"Zigzag32 overflow", binary.AppendVarint(nil, math.MaxInt32+1), readZigzag32, ErrOverflow},
+		{"Zigzag32 underflow", binary.AppendVarint(nil, math.MinInt32-1), readZigzag32, ErrOverflow},
+		{"Zigzag32 64-bit overflow", _over, readZigzag32, ErrOverflow},
+	}
+	for _, c := range cases {
+		b := NewBuffer()
+		b.WriteShort(7)
+		b.WriteBytes(c.data, 0, len(c.data))
+		b.ReadShort()
+		if err := c.read(b); err != c.want || b.GetOffset() != 2 {
+			t.Errorf("%s: err %v, offset %d; want %v, 2", c.name, err, b.GetOffset(), c.want)
+		}
+	}
+
+	//32位的边界值可以读取
+	b := NewBuffer()
+	b.WriteVarint(math.MaxInt32)
+	b.WriteVarint(math.MinInt32)
+	if v1, v2 := b.ReadZigzag32(), b.ReadZigzag32(); v1 != math.MaxInt32 || v2 != math.MinInt32 || b.Err() != nil {
+		t.Errorf("ReadZigzag32 = %d, %d, %v", v1, v2, b.Err())
+	}
+}
+
+func readUvarint(b *Buffer) error {
+	_, err := b.TryReadUvarint()
+	return err
+}
+
+func readZigzag32(b *Buffer) error {
+	_, err := b.TryReadZigzag32()
+	return err
+}
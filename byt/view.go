@@ -0,0 +1,256 @@
+/********************************************************/
+// 字节对象（标记、预读及共享视图）
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			buf.Mark()
+//			kind:=buf.ReadShort()
+//			buf.Reset()		//回到Mark的位置
+//			n,_:=buf.PeekLength()	//预读，不移动偏移位置
+//			body:=buf.Slice(n)	//与buf共享字节数组的子对象
+/********************************************************/
+
+package byt
+
+/**
+ * 标记当前偏移位置（之后可通过Reset回到该位置）
+ */
+func (b *Buffer) Mark() {
+	b.mark = b.offset
+	b.markbits = b.rbits
+}
+
+/**
+ * 将偏移位置恢复至Mark标记的位置（未标记时恢复至0）
+ */
+func (b *Buffer) Reset() {
+	if b.mark > b.top {
+		b.mark, b.markbits = b.top, 0
+	}
+	b.offset = b.mark
+	b.rbits = b.markbits
+}
+
+/**
+ * 获取接下来n个可读字节的子对象并将偏移位置后移n个字节
+ * 子对象与原对象共享字节数组（分块模式下跨越块边界时为副本），拥有各自的偏移位置与top值（读取失败时记录错误并返回一个空对象）
+ * @param n 子对象的长度
+ * @return 子对象
+ */
+func (b *Buffer) Slice(n int) *Buffer {
+	if b.err == nil {
+		s, err := b.TrySlice(n)
+		if err == nil {
+			return s
+		}
+		b.fail(err)
+	}
+	return b.view(b.byt[0:0:0])
+}
+
+/**
+ * 获取接下来n个可读字节的子对象并将偏移位置后移n个字节
+ * 子对象的容量限制为n，向子对象写入更多内容时会重新分配字节数组，不会覆盖原对象的内容
+ * @param n 子对象的长度
+ * @return 子对象，错误信息（ErrBadLength、ErrUnderflow）
+ */
+func (b *Buffer) TrySlice(n int) (*Buffer, error) {
+	data, err := b.next(n)
+	if err != nil {
+		return nil, err
+	}
+	s := b.view(data[:n:n])
+	s.top = n
+	return s, nil
+}
+
+/**
+ * 复制一个与原对象共享字节数组的对象（偏移位置、top值及标记与原对象相同，之后各自独立）
+ * 注意：向任一对象写入内容时，若未发生扩容，写入的内容对另一个对象可见
+ * @return 新的字节缓冲对象
+ */
+func (b *Buffer) Duplicate() *Buffer {
+	d := b.view(b.byt)
+	if b.chunks != nil {
+		d.chunks = b.chunks.clone()
+	}
+	d.top = b.top
+	d.offset = b.offset
+	d.mark = b.mark
+	d.wbits, d.rbits, d.markbits = b.wbits, b.rbits, b.markbits
+	return d
+}
+
+//创建一个使用指定字节数组的对象（编码模式及读取长度限制与原对象相同）
+func (b *Buffer) view(bt []byte) *Buffer {
+	return &Buffer{
+		byt:    bt,
+		order:  b.Order(),
+		limits: b.limits,
+	}
+}
+
+//执行读取后将偏移位置恢复
+func peek[T any](b *Buffer, read func() (T, error)) (T, error) {
+	_start, _bits := b.offset, b.rbits
+	b.tracePause()
+	v, err := read()
+	b.traceResume()
+	b.offset, b.rbits = _start, _bits
+	return v, err
+}
+
+/**
+ * 查看（读一个boolean布尔值，不移动偏移位置）
+ */
+func (b *Buffer) PeekBoolean() (bool, error) {
+	return peek(b, b.TryReadBoolean)
+}
+
+/**
+ * 查看（读取一个无符号的byte值（uint8），不移动偏移位置）
+ */
+func (b *Buffer) PeekUnsignedByt() (byte, error) {
+	return peek(b, b.TryReadUnsignedByt)
+}
+
+/**
+ * 查看（读取一个byte值（int8），不移动偏移位置）
+ */
+func (b *Buffer) PeekByt() (int8, error) {
+	return peek(b, b.TryReadByt)
+}
+
+/**
+ * 查看（读取一个Short值（int16），不移动偏移位置）
+ */
+func (b *Buffer) PeekShort() (int16, error) {
+	return peek(b, b.TryReadShort)
+}
+
+/**
+ * 查看（读取一个int值（int32），不移动偏移位置）
+ */
+func (b *Buffer) PeekInt() (int32, error) {
+	return peek(b, b.TryReadInt)
+}
+
+/**
+ * 查看（读取一个long值（int64），不移动偏移位置）
+ */
+func (b *Buffer) PeekLong() (int64, error) {
+	return peek(b, b.TryReadLong)
+}
+
+/**
+ * 查看（读取一个float值（float64），不移动偏移位置）
+ */
+func (b *Buffer) PeekFloat() (float64, error) {
+	return peek(b, b.TryReadFloat)
+}
+
+/**
+ * 查看（读取一个无符号的short值（uint16），不移动偏移位置）
+ */
+func (b *Buffer) PeekUnsignedShort() (uint16, error) {
+	return peek(b, b.TryReadUnsignedShort)
+}
+
+/**
+ * 查看（读取一个无符号的int值（uint32），不移动偏移位置）
+ */
+func (b *Buffer) PeekUnsignedInt() (uint32, error) {
+	return peek(b, b.TryReadUnsignedInt)
+}
+
+/**
+ * 查看（读取一个无符号的long值（uint64），不移动偏移位置）
+ */
+func (b *Buffer) PeekUnsignedLong() (uint64, error) {
+	return peek(b, b.TryReadUnsignedLong)
+}
+
+/**
+ * 查看（读取一个单精度float值（float32），不移动偏移位置）
+ */
+func (b *Buffer) PeekFloat32() (float32, error) {
+	return peek(b, b.TryReadFloat32)
+}
+
+/**
+ * 查看（读取一个complex64值，不移动偏移位置）
+ */
+func (b *Buffer) PeekComplex64() (complex64, error) {
+	return peek(b, b.TryReadComplex64)
+}
+
+/**
+ * 查看（读取一个complex128值，不移动偏移位置）
+ */
+func (b *Buffer) PeekComplex128() (complex128, error) {
+	return peek(b, b.TryReadComplex128)
+}
+
+/**
+ * 查看（读取一个128位无符号整数，不移动偏移位置）
+ */
+func (b *Buffer) PeekUint128() (Uint128, error) {
+	return peek(b, b.TryReadUint128)
+}
+
+/**
+ * 查看（读取一个UUID，不移动偏移位置）
+ */
+func (b *Buffer) PeekUUID() (UUID, error) {
+	return peek(b, b.TryReadUUID)
+}
+
+/**
+ * 查看（读取一个无符号变长整数，不移动偏移位置）
+ */
+func (b *Buffer) PeekUvarint() (uint64, error) {
+	return peek(b, b.TryReadUvarint)
+}
+
+/**
+ * 查看（读取一个zigzag编码的有符号变长整数，不移动偏移位置）
+ */
+func (b *Buffer) PeekVarint() (int64, error) {
+	return peek(b, b.TryReadVarint)
+}
+
+/**
+ * 查看（读取一个zigzag编码的32位有符号变长整数，不移动偏移位置）
+ */
+func (b *Buffer) PeekZigzag32() (int32, error) {
+	return peek(b, b.TryReadZigzag32)
+}
+
+/**
+ * 查看（读取一个长度值，不移动偏移位置）
+ */
+func (b *Buffer) PeekLength() (int, error) {
+	return peek(b, b.TryReadLength)
+}
+
+/**
+ * 查看（读取一个utf8字符串，不移动偏移位置）
+ */
+func (b *Buffer) PeekUTF8String() (string, error) {
+	return peek(b, b.TryReadUTF8String)
+}
+
+/**
+ * 查看（读取一个modified UTF-8字符串，不移动偏移位置）
+ */
+func (b *Buffer) PeekUTF() (string, error) {
+	return peek(b, b.TryReadUTF)
+}
+
+/**
+ * 查看（读取一个字节数组，不移动偏移位置）
+ */
+func (b *Buffer) PeekData() ([]byte, error) {
+	return peek(b, b.TryReadData)
+}
@@ -0,0 +1,212 @@
+package byt
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+//Reset回到Mark标记的位置（包括位读取的位置），未标记时回到0
+func TestMarkReset(t *testing.T) {
+	b := NewBuffer()
+	b.WriteBits(5, 3)
+	b.AlignByte()
+	b.WriteInt(42)
+	b.WriteUTF8String("bob")
+
+	b.ReadInt() //未标记
+	b.Reset()
+	if b.GetOffset() != 0 {
+		t.Fatalf("Reset without Mark: offset %d", b.GetOffset())
+	}
+
+	if b.ReadBits(2) != 2 {
+		t.Fatal("ReadBits(2)")
+	}
+	b.Mark()
+	_off, _bits := b.GetOffset(), b.RemainingBits()
+	b.ReadBits(1)
+	b.AlignByte()
+	if b.ReadInt() != 42 || b.ReadUTF8String() != "bob" {
+		t.Fatal("read after Mark")
+	}
+	b.Reset()
+	if b.GetOffset() != _off || b.RemainingBits() != _bits {
+		t.Errorf("Reset: offset %d, bits %d; want %d, %d", b.GetOffset(), b.RemainingBits(), _off, _bits)
+	}
+	if b.ReadBits(1) != 1 {
+		t.Error("bit after Reset")
+	}
+	b.AlignByte()
+	if b.ReadInt() != 42 {
+		t.Error("int after Reset")
+	}
+
+	//标记的位置超出top时回到top
+	b.Mark()
+	b.SetTop(b.GetOffset())
+	b.Reset()
+	if b.GetOffset() != b.GetTop() {
+		t.Errorf("Reset past top: offset %d, top %d", b.GetOffset(), b.GetTop())
+	}
+}
+
+//Peek读取的值与之后实际读取的值相同，偏移位置及位读取的位置不变
+func TestPeek(t *testing.T) {
+	b := NewBuffer()
+	b.WriteBits(1, 3)
+	b.AlignByte()
+	b.WriteBoolean(true)
+	b.WriteUnsignedByt(200)
+	b.WriteByt(-2)
+	b.WriteShort(-3)
+	b.WriteInt(-4)
+	b.WriteLong(-5)
+	b.WriteFloat(1.5)
+	b.WriteUnsignedShort(6)
+	b.WriteUnsignedInt(7)
+	b.WriteUnsignedLong(8)
+	b.WriteFloat32(2.5)
+	b.WriteComplex64(1 + 2i)
+	b.WriteComplex128(3 + 4i)
+	b.WriteUint128(Uint128{Hi: 1, Lo: 2})
+	b.WriteUUID(UUID{1, 2, 3})
+	b.WriteUvarint(300)
+	b.WriteVarint(-300)
+	b.WriteZigzag32(-7)
+	b.WriteLength(9)
+	b.WriteUTF8String("utf8")
+	b.WriteUTF("utf")
+	b.WriteData([]byte{1, 2})
+
+	type pair struct {
+		name       string
+		peek, read func() (interface{}, error)
+	}
+	//将PeekXxx、TryReadXxx转换为统一的形式
+	call := func(f interface{}) func() (interface{}, error) {
+		return func() (interface{}, error) {
+			out := reflect.ValueOf(f).Call(nil)
+			err, _ := out[1].Interface().(error)
+			return out[0].Interface(), err
+		}
+	}
+	var pairs []pair
+	add := func(name string, peek, read interface{}) {
+		pairs = append(pairs, pair{name, call(peek), call(read)})
+	}
+	add("Boolean", b.PeekBoolean, b.TryReadBoolean)
+	add("UnsignedByt", b.PeekUnsignedByt, b.TryReadUnsignedByt)
+	add("Byt", b.PeekByt, b.TryReadByt)
+	add("Short", b.PeekShort, b.TryReadShort)
+	add("Int", b.PeekInt, b.TryReadInt)
+	add("Long", b.PeekLong, b.TryReadLong)
+	add("Float", b.PeekFloat, b.TryReadFloat)
+	add("UnsignedShort", b.PeekUnsignedShort, b.TryReadUnsignedShort)
+	add("UnsignedInt", b.PeekUnsignedInt, b.TryReadUnsignedInt)
+	add("UnsignedLong", b.PeekUnsignedLong, b.TryReadUnsignedLong)
+	add("Float32", b.PeekFloat32, b.TryReadFloat32)
+	add("Complex64", b.PeekComplex64, b.TryReadComplex64)
+	add("Complex128", b.PeekComplex128, b.TryReadComplex128)
+	add("Uint128", b.PeekUint128, b.TryReadUint128)
+	add("UUID", b.PeekUUID, b.TryReadUUID)
+	add("Uvarint", b.PeekUvarint, b.TryReadUvarint)
+	add("Varint", b.PeekVarint, b.TryReadVarint)
+	add("Zigzag32", b.PeekZigzag32, b.TryReadZigzag32)
+	add("Length", b.PeekLength, b.TryReadLength)
+	add("UTF8String", b.PeekUTF8String, b.TryReadUTF8String)
+	add("UTF", b.PeekUTF, b.TryReadUTF)
+	add("Data", b.PeekData, b.TryReadData)
+
+	//从位读取的中间开始，Peek需要恢复位读取的位置
+	b.ReadBits(2)
+	_off, _bits := b.GetOffset(), b.RemainingBits()
+	if v, err := b.PeekBoolean(); err != nil || v != true {
+		t.Fatalf("PeekBoolean after ReadBits = %v, %v", v, err)
+	}
+	if b.GetOffset() != _off || b.RemainingBits() != _bits {
+		t.Fatalf("PeekBoolean moved bit cursor: offset %d, bits %d; want %d, %d", b.GetOffset(), b.RemainingBits(), _off, _bits)
+	}
+	if b.ReadBits(1) != 1 {
+		t.Fatal("bit after Peek")
+	}
+	b.AlignByte()
+
+	b.StartTrace()
+	for _, p := range pairs {
+		_off := b.GetOffset()
+		pv, perr := p.peek()
+		if b.GetOffset() != _off {
+			t.Errorf("Peek%s moved offset %d -> %d", p.name, _off, b.GetOffset())
+		}
+		rv, rerr := p.read()
+		if perr != nil || rerr != nil || !reflect.DeepEqual(pv, rv) {
+			t.Errorf("Peek%s = %v, %v; TryRead%s = %v, %v", p.name, pv, perr, p.name, rv, rerr)
+		}
+	}
+	if b.Remaining() != 0 {
+		t.Errorf("%d bytes left", b.Remaining())
+	}
+	//Peek不记录跟踪
+	if n := len(b.Trace()); n != len(pairs) {
+		t.Errorf("%d trace entries; want %d", n, len(pairs))
+	}
+
+	//读取失败时偏移位置同样不变
+	_off = b.GetOffset()
+	if _, err := b.PeekLong(); err != ErrUnderflow || b.GetOffset() != _off {
+		t.Errorf("PeekLong at end: %v, offset %d", err, b.GetOffset())
+	}
+}
+
+//Slice及Duplicate与原对象共享字节数组，拥有各自的偏移位置与top值
+func TestSliceDuplicate(t *testing.T) {
+	b := NewBuffer()
+	b.WriteShort(1)
+	b.WriteInt(2)
+	b.WriteInt(3)
+	b.ReadShort()
+
+	s := b.Slice(4)
+	if b.GetOffset() != 6 || s.GetOffset() != 0 || s.GetTop() != 4 {
+		t.Fatalf("Slice: parent offset %d, slice offset %d, top %d", b.GetOffset(), s.GetOffset(), s.GetTop())
+	}
+	b.GetByte()[5] = 9 //修改原对象，子对象可见
+	if v := s.ReadInt(); v != 9 || s.Remaining() != 0 {
+		t.Errorf("slice ReadInt = %d", v)
+	}
+	if b.ReadInt() != 3 || b.Remaining() != 0 {
+		t.Error("parent read after Slice")
+	}
+	//向子对象写入更多内容时重新分配，不覆盖原对象
+	s.WriteInt(-1)
+	if !bytes.Equal(b.GetByte()[6:10], []byte{0, 0, 0, 3}) {
+		t.Errorf("slice write overwrote parent: % x", b.GetByte()[:b.GetTop()])
+	}
+	if s := b.Slice(1); b.Err() != ErrUnderflow || s.GetTop() != 0 {
+		t.Errorf("Slice past end: %v, top %d", b.Err(), s.GetTop())
+	}
+	b.ClearErr()
+
+	b.SetOffet(2)
+	b.Mark()
+	d := b.Duplicate()
+	if d.GetOffset() != 2 || d.GetTop() != b.GetTop() || &d.GetByte()[0] != &b.GetByte()[0] {
+		t.Fatalf("Duplicate: offset %d, top %d", d.GetOffset(), d.GetTop())
+	}
+	if d.ReadInt() != 9 || b.GetOffset() != 2 {
+		t.Errorf("Duplicate read moved parent to %d", b.GetOffset())
+	}
+	d.SetTop(d.GetOffset()) //各自的top值
+	if b.GetTop() != 10 {
+		t.Errorf("parent top %d after Duplicate SetTop", b.GetTop())
+	}
+	d.WriteUnsignedByt(0xee) //未扩容时写入的内容对原对象可见
+	if b.GetByte()[6] != 0xee {
+		t.Errorf("Duplicate write not visible: % x", b.GetByte()[:b.GetTop()])
+	}
+	d.Reset() //标记与原对象相同
+	if d.GetOffset() != 2 {
+		t.Errorf("Duplicate Reset: offset %d", d.GetOffset())
+	}
+}
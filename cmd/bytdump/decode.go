@@ -0,0 +1,291 @@
+/********************************************************/
+// bytdump 按类型列表解码
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			fields,_:=parseSpec([]string{"cmd:int16","name:utf8","[]int32"})
+//			decode(os.Stdout,data,binary.BigEndian,fields)
+/********************************************************/
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Golang-master/byt"
+)
+
+/**
+ * 类型列表中的一项
+ */
+type field struct {
+	name   string
+	typ    string
+	repeat int  //重复次数
+	array  bool //带数量前缀的数组
+	skip   int  //skip:N跳过的字节数
+}
+
+//各类型的读取函数
+var readers = map[string]func(b *byt.Buffer) (interface{}, error){
+	"bool":       func(b *byt.Buffer) (interface{}, error) { return b.TryReadBoolean() },
+	"int8":       func(b *byt.Buffer) (interface{}, error) { return b.TryReadByt() },
+	"uint8":      func(b *byt.Buffer) (interface{}, error) { return b.TryReadUnsignedByt() },
+	"int16":      func(b *byt.Buffer) (interface{}, error) { return b.TryReadShort() },
+	"uint16":     func(b *byt.Buffer) (interface{}, error) { return b.TryReadUnsignedShort() },
+	"int32":      func(b *byt.Buffer) (interface{}, error) { return b.TryReadInt() },
+	"uint32":     func(b *byt.Buffer) (interface{}, error) { return b.TryReadUnsignedInt() },
+	"int64":      func(b *byt.Buffer) (interface{}, error) { return b.TryReadLong() },
+	"uint64":     func(b *byt.Buffer) (interface{}, error) { return b.TryReadUnsignedLong() },
+	"float32":    func(b *byt.Buffer) (interface{}, error) { return b.TryReadFloat32() },
+	"float64":    func(b *byt.Buffer) (interface{}, error) { return b.TryReadFloat() },
+	"complex64":  func(b *byt.Buffer) (interface{}, error) { return b.TryReadComplex64() },
+	"complex128": func(b *byt.Buffer) (interface{}, error) { return b.TryReadComplex128() },
+	"varint":     func(b *byt.Buffer) (interface{}, error) { return b.TryReadVarint() },
+	"uvarint":    func(b *byt.Buffer) (interface{}, error) { return b.TryReadUvarint() },
+	"zigzag32":   func(b *byt.Buffer) (interface{}, error) { return b.TryReadZigzag32() },
+	"length":     func(b *byt.Buffer) (interface{}, error) { return b.TryReadLength() },
+	"utf8":       func(b *byt.Buffer) (interface{}, error) { return b.TryReadUTF8String() },
+	"utf":        func(b *byt.Buffer) (interface{}, error) { return b.TryReadUTF() },
+	"data":       func(b *byt.Buffer) (interface{}, error) { return b.TryReadData() },
+	"uint128":    func(b *byt.Buffer) (interface{}, error) { return b.TryReadUint128() },
+	"uuid":       func(b *byt.Buffer) (interface{}, error) { return b.TryReadUUID() },
+	"value":      func(b *byt.Buffer) (interface{}, error) { return b.ReadValue() },
+}
+
+/**
+ * 解析类型列表
+ * @param items 各项（名称:类型、类型*N、[]类型、skip:N）
+ * @return 类型列表，错误信息
+ */
+func parseSpec(items []string) ([]field, error) {
+	fields := make([]field, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		f := field{repeat: 1}
+
+		if n, ok := strings.CutPrefix(item, "skip:"); ok {
+			skip, err := strconv.Atoi(n)
+			if err != nil || skip < 0 {
+				return nil, fmt.Errorf("无效的跳过长度%q", item)
+			}
+			f.typ, f.skip = "skip", skip
+			fields = append(fields, f)
+			continue
+		}
+
+		if i := strings.IndexByte(item, ':'); i >= 0 {
+			f.name, item = item[:i], item[i+1:]
+		}
+		if i := strings.IndexByte(item, '*'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("无效的重复次数%q", item)
+			}
+			f.repeat, item = n, item[:i]
+		}
+		if t, ok := strings.CutPrefix(item, "[]"); ok {
+			f.array, item = true, t
+		}
+		if _, ok := readers[item]; !ok {
+			return nil, fmt.Errorf("未知的类型%q（可用类型：%s）", item, typeNames())
+		}
+		f.typ = item
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+/**
+ * 读取类型列表文件（每行一项，也可以用逗号分隔，#之后为注释）
+ * @param name 文件名
+ * @return 类型列表，错误信息
+ */
+func parseSchemaFile(name string) ([]field, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var items []string
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		items = append(items, strings.Split(line, ",")...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return parseSpec(items)
+}
+
+/**
+ * 按类型列表依次解码并输出每一项
+ * @param w 输出目标
+ * @param data 原始字节
+ * @param order 编码模式
+ * @param fields 类型列表
+ * @return 是否全部解码成功
+ */
+func decode(w io.Writer, data []byte, order binary.ByteOrder, fields []field) bool {
+	if data == nil {
+		data = []byte{}
+	}
+	b := byt.NewBufferWithByte(data)
+	b.SetOrder(order)
+
+	tw := &table{w: w, data: data}
+	tw.header()
+	for _, f := range fields {
+		for r := 0; r < f.repeat; r++ {
+			name := f.name
+			if f.repeat > 1 {
+				name += "[" + strconv.Itoa(r) + "]"
+			}
+			if !tw.field(b, f, name) {
+				return false
+			}
+		}
+	}
+
+	if n := b.Remaining(); n > 0 {
+		fmt.Fprintf(w, "\n剩余%d字节未解码（偏移位置 %d）:\n", n, b.GetOffset())
+		hexDump(w, data, b.GetOffset(), len(data))
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////
+//内部函数
+
+//解码结果表格
+type table struct {
+	w    io.Writer
+	data []byte
+}
+
+func (t *table) header() {
+	fmt.Fprintf(t.w, "%-8s %-6s %-10s %-12s %-32s %s\n", "offset", "len", "type", "name", "value", "bytes")
+}
+
+//解码一项（数组时包括所有元素），失败时输出出错位置并返回false
+func (t *table) field(b *byt.Buffer, f field, name string) bool {
+	if f.typ == "skip" {
+		_start := b.GetOffset()
+		if f.skip > b.Remaining() {
+			t.fail(_start, "skip", name, byt.ErrUnderflow)
+			return false
+		}
+		b.SetOffet(_start + f.skip)
+		t.row(_start, b.GetOffset(), "skip", name, "")
+		return true
+	}
+	if !f.array {
+		return t.value(b, f.typ, name)
+	}
+
+	_start := b.GetOffset()
+	n, err := b.TryReadCount()
+	if err != nil {
+		t.fail(_start, "[]"+f.typ, name, err)
+		return false
+	}
+	t.row(_start, b.GetOffset(), "[]"+f.typ, name, "count="+strconv.Itoa(n))
+	for i := 0; i < n; i++ {
+		if !t.value(b, f.typ, name+"["+strconv.Itoa(i)+"]") {
+			return false
+		}
+	}
+	return true
+}
+
+//解码一个值
+func (t *table) value(b *byt.Buffer, typ string, name string) bool {
+	_start := b.GetOffset()
+	v, err := readers[typ](b)
+	if err != nil {
+		t.fail(_start, typ, name, err)
+		return false
+	}
+	t.row(_start, b.GetOffset(), typ, name, format(v))
+	return true
+}
+
+func (t *table) row(start, end int, typ, name, value string) {
+	_raw := t.data[start:end]
+	_more := ""
+	if len(_raw) > 16 {
+		_raw, _more = _raw[:16], " ..."
+	}
+	fmt.Fprintf(t.w, "%-8d %-6d %-10s %-12s %-32s % x%s\n", start, end-start, typ, name, value, _raw, _more)
+}
+
+//输出出错的位置（所在行的十六进制视图及指向出错字节的标记）
+func (t *table) fail(start int, typ, name string, err error) {
+	fmt.Fprintf(t.w, "%-8d %-6s %-10s %-12s [ERR]: %s\n", start, "-", typ, name, err.Error())
+	fmt.Fprintf(t.w, "\n解码失败：偏移位置 %d（0x%x），剩余 %d 字节\n", start, start, len(t.data)-start)
+	_line := start &^ 15
+	if _line >= len(t.data) && _line > 0 {
+		_line -= 16 //出错位置在末尾时标记在最后一行之后
+	}
+	hexDump(t.w, t.data, _line, minInt(_line+16, len(t.data)))
+	_col := 10 + (start-_line)*3
+	if start-_line >= 8 {
+		_col++
+	}
+	fmt.Fprintf(t.w, "%s^^\n", strings.Repeat(" ", _col))
+}
+
+//格式化解码结果
+func format(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return strconv.Quote(x)
+	case []byte:
+		if x == nil {
+			return "nil"
+		}
+		if len(x) > 12 {
+			return "[" + strconv.Itoa(len(x)) + "]" + hex.EncodeToString(x[:12]) + "..."
+		}
+		return "[" + strconv.Itoa(len(x)) + "]" + hex.EncodeToString(x)
+	case byt.UUID:
+		s := hex.EncodeToString(x[:])
+		return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+	case byt.Uint128:
+		return fmt.Sprintf("0x%016x%016x", x.Hi, x.Lo)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func typeNames() string {
+	names := make([]string, 0, len(readers))
+	for n := range readers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func minInt(a, c int) int {
+	if a < c {
+		return a
+	}
+	return c
+}
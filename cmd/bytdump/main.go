@@ -0,0 +1,164 @@
+/********************************************************/
+// bytdump 字节对象内容查看工具
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			bytdump packet.bin						//十六进制视图
+//			bytdump -in hex -spec int16,utf8,[]int32 dump.txt
+//			echo AAcAAAAq | bytdump -in base64 -order little -spec cmd:int16,hp:int32
+//			bytdump -schema player.spec packet.bin
+/********************************************************/
+
+// bytdump读取原始字节（文件或标准输入，也可以是十六进制/base64文本），
+// 输出带偏移位置及ASCII的十六进制视图；指定-spec或-schema时按类型依次解码，
+// 逐项列出偏移位置、长度、原始字节及解码结果，解码失败时指出出错的位置。
+//
+// 类型说明（与byt字段标签的编码方式同名）：
+//
+//	bool int8 uint8 int16 uint16 int32 uint32 int64 uint64
+//	float32 float64 complex64 complex128 varint uvarint zigzag32 length
+//	utf8 utf data uint128 uuid value（WriteValue写入的带类型标记的值）
+//	skip:N（跳过N个字节）
+//
+// 每一项可以写成 名称:类型，类型后加 *N 表示重复N次，类型前加 [] 表示带数量前缀的数组
+// （WriteArray / WriteSlice的格式）。schema文件每行一项，#之后为注释。
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var (
+	inFormat = flag.String("in", "raw", "输入格式：raw、hex或base64")
+	order    = flag.String("order", "big", "编码模式：big或little")
+	spec     = flag.String("spec", "", "逗号分隔的类型列表，例如 int32,utf8,data,varint")
+	schema   = flag.String("schema", "", "类型列表文件（每行一项）")
+	noDump   = flag.Bool("q", false, "不输出十六进制视图")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "用法: bytdump [-in raw|hex|base64] [-order big|little] [-spec 类型列表 | -schema 文件] [文件]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	data, err := readInput(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[Error]: bytdump: "+err.Error())
+		os.Exit(1)
+	}
+
+	var bo binary.ByteOrder
+	switch *order {
+	case "big":
+		bo = binary.BigEndian
+	case "little":
+		bo = binary.LittleEndian
+	default:
+		fmt.Fprintln(os.Stderr, "[Error]: bytdump: 未知的编码模式 "+*order)
+		os.Exit(2)
+	}
+
+	var fields []field
+	switch {
+	case *spec != "":
+		fields, err = parseSpec(strings.Split(*spec, ","))
+	case *schema != "":
+		fields, err = parseSchemaFile(*schema)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[Error]: bytdump: "+err.Error())
+		os.Exit(2)
+	}
+
+	if !*noDump || fields == nil {
+		hexDump(os.Stdout, data, 0, len(data))
+	}
+	if fields == nil {
+		return
+	}
+	if !*noDump {
+		fmt.Println()
+	}
+	if !decode(os.Stdout, data, bo, fields) {
+		os.Exit(1)
+	}
+}
+
+/**
+ * 读取输入内容
+ * @param name 文件名（为空或"-"时读取标准输入）
+ * @return 原始字节，错误信息
+ */
+func readInput(name string) ([]byte, error) {
+	var (
+		raw []byte
+		err error
+	)
+	if name == "" || name == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch *inFormat {
+	case "raw":
+		return raw, nil
+	case "hex":
+		//允许空白、0x前缀以及hexdump风格的分隔符
+		text := strings.NewReplacer("0x", "", "0X", "", ",", " ", ":", " ").Replace(string(raw))
+		return hex.DecodeString(strings.Join(strings.Fields(text), ""))
+	case "base64":
+		text := strings.Join(strings.Fields(string(raw)), "")
+		if data, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return data, nil
+		}
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(text, "="))
+	}
+	return nil, fmt.Errorf("未知的输入格式%s", *inFormat)
+}
+
+/**
+ * 输出十六进制视图（每行16字节：偏移位置、十六进制、ASCII）
+ * @param w 输出目标
+ * @param data 原始字节
+ * @param from 起始位置
+ * @param to 结束位置
+ */
+func hexDump(w io.Writer, data []byte, from, to int) {
+	for line := from &^ 15; line < to; line += 16 {
+		var hx, asc bytes.Buffer
+		for i := line; i < line+16; i++ {
+			if i == line+8 {
+				hx.WriteByte(' ')
+			}
+			if i < from || i >= to {
+				hx.WriteString("   ")
+				asc.WriteByte(' ')
+				continue
+			}
+			fmt.Fprintf(&hx, "%02x ", data[i])
+			if data[i] >= 0x20 && data[i] < 0x7f {
+				asc.WriteByte(data[i])
+			} else {
+				asc.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(w, "%08x  %s |%s|\n", line, hx.String(), asc.String())
+	}
+}
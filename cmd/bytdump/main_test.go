@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	cases := []struct {
+		items []string
+		want  []field
+		err   bool
+	}{
+		{[]string{"cmd:int16", " utf8 ", ""}, []field{{name: "cmd", typ: "int16", repeat: 1}, {typ: "utf8", repeat: 1}}, false},
+		{[]string{"pos:float32*3"}, []field{{name: "pos", typ: "float32", repeat: 3}}, false},
+		{[]string{"[]int32", "ids:[]uvarint*2"}, []field{{typ: "int32", repeat: 1, array: true}, {name: "ids", typ: "uvarint", repeat: 2, array: true}}, false},
+		{[]string{"skip:4", "skip:0"}, []field{{typ: "skip", repeat: 1, skip: 4}, {typ: "skip", repeat: 1}}, false},
+		{[]string{"int33"}, nil, true},
+		{[]string{"name:"}, nil, true},
+		{[]string{"int8*0"}, nil, true},
+		{[]string{"int8*x"}, nil, true},
+		{[]string{"skip:-1"}, nil, true},
+		{[]string{"skip:x"}, nil, true},
+		{[]string{"[]"}, nil, true},
+	}
+	for _, c := range cases {
+		got, err := parseSpec(c.items)
+		if c.err {
+			if err == nil {
+				t.Errorf("parseSpec(%q) = %+v; want error", c.items, got)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("parseSpec(%q) = %+v, %v; want %+v", c.items, got, err, c.want)
+		}
+	}
+}
+
+func TestReadInput(t *testing.T) {
+	want := []byte{0x00, 0x07, 0x00, 0x00, 0x00, 0x2a}
+	cases := []struct {
+		format, text string
+		err          bool
+	}{
+		{"raw", string(want), false},
+		{"hex", "0007 0000 002a\n", false},
+		{"hex", "0x00,0x07,0x00,0x00,0x00,0x2A", false},
+		{"hex", "00:07:00:00:00:2a", false},
+		{"hex", "000", true},
+		{"base64", "AAcAAAAq\n", false},
+		{"base64", "AAcA AAAq", false},
+		{"base64", "AAcAAAA", false}, //URL编码，无填充（最后一个字节不完整）
+		{"base64", "!!!!", true},
+		{"text", "", true},
+	}
+	dir := t.TempDir()
+	_format := *inFormat
+	defer func() { *inFormat = _format }()
+	for i, c := range cases {
+		name := filepath.Join(dir, "in")
+		if err := os.WriteFile(name, []byte(c.text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		*inFormat = c.format
+		got, err := readInput(name)
+		if c.err {
+			if err == nil {
+				t.Errorf("%d: readInput(%s %q) = % x; want error", i, c.format, c.text, got)
+			}
+			continue
+		}
+		_want := want
+		if c.text == "AAcAAAA" {
+			_want = want[:5]
+		}
+		if err != nil || string(got) != string(_want) {
+			t.Errorf("%d: readInput(%s %q) = % x, %v; want % x", i, c.format, c.text, got, err, _want)
+		}
+	}
+}
+
+//解码结果的各行（去掉行尾空白）
+func decodeLines(t *testing.T, data []byte, order binary.ByteOrder, spec string) ([]string, bool) {
+	fields, err := parseSpec(strings.Split(spec, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	ok := decode(&sb, data, order, fields)
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	return lines, ok
+}
+
+func TestDecode(t *testing.T) {
+	data := []byte{0x07, 0x00, 0x2a, 0x00, 0x00, 0x00, 0x83, 'h', 'i', 0xff}
+	for _, c := range []struct {
+		order binary.ByteOrder
+		want  []string
+	}{
+		{binary.LittleEndian, []string{
+			"0        2      int16      cmd          7                                07 00",
+			"2        4      int32      hp           42                               2a 00 00 00",
+			"6        1      skip                                                     83",
+			"7        1      uint8      [0]          104                              68",
+		}},
+		{binary.BigEndian, []string{
+			"0        2      int16      cmd          1792                             07 00",
+			"2        4      int32      hp           704643072                        2a 00 00 00",
+		}},
+	} {
+		lines, ok := decodeLines(t, data, c.order, "cmd:int16,hp:int32,skip:1,uint8*2")
+		if !ok {
+			t.Fatalf("decode failed:\n%s", strings.Join(lines, "\n"))
+		}
+		for _, w := range c.want {
+			if !containsLine(lines, w) {
+				t.Errorf("%v: %q not in\n%s", c.order, w, strings.Join(lines, "\n"))
+			}
+		}
+		//未解码的剩余字节
+		if last := lines[len(lines)-1]; !strings.HasPrefix(last, "00000000") || !strings.Contains(lines[len(lines)-2], "剩余1字节") {
+			t.Errorf("%v: remaining bytes not reported:\n%s", c.order, strings.Join(lines, "\n"))
+		}
+	}
+
+	//数组
+	lines, ok := decodeLines(t, []byte{0x83, 0x00, 0x01, 0x00, 0x02}, binary.BigEndian, "xs:[]int16")
+	if !ok || !containsLine(lines, "0        1      []int16    xs           count=2                          83") ||
+		!containsLine(lines, "3        2      int16      xs[1]        2                                00 02") {
+		t.Errorf("array:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+//解码失败时输出错误及指向出错字节的标记
+func TestDecodeFail(t *testing.T) {
+	data := make([]byte, 20)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	for _, c := range []struct {
+		spec string
+		at   int //出错的偏移位置
+	}{
+		{"skip:2,int32", -1},  //没有出错
+		{"skip:18,int32", 18}, //数据不完整
+		{"skip:9,skip:12", 9},
+		{"skip:3,int8,[]int64", 4},
+		{"skip:20,int8", 20}, //出错位置在末尾
+	} {
+		lines, ok := decodeLines(t, data, binary.BigEndian, c.spec)
+		if c.at < 0 {
+			if !ok {
+				t.Errorf("%s: failed:\n%s", c.spec, strings.Join(lines, "\n"))
+			}
+			continue
+		}
+		if ok {
+			t.Errorf("%s: succeeded:\n%s", c.spec, strings.Join(lines, "\n"))
+			continue
+		}
+		_out := strings.Join(lines, "\n")
+		if !strings.Contains(_out, "[ERR]: ") || !strings.Contains(_out, "解码失败：偏移位置 "+strconv.Itoa(c.at)) {
+			t.Errorf("%s: no error report:\n%s", c.spec, _out)
+			continue
+		}
+		//最后两行为出错字节所在行的十六进制视图及标记，标记指向出错字节（在末尾时指向最后一个字节之后）
+		dump, caret := lines[len(lines)-2], lines[len(lines)-1]
+		col := strings.Index(caret, "^^")
+		if col < 0 || strings.TrimSpace(caret) != "^^" {
+			t.Fatalf("%s: caret line %q", c.spec, caret)
+		}
+		line := c.at &^ 15
+		if line >= len(data) {
+			line -= 16
+		}
+		if !strings.HasPrefix(dump, fmt.Sprintf("%08x", line)) {
+			t.Errorf("%s: dump line %q", c.spec, dump)
+		}
+		if c.at < len(data) {
+			if got := dump[col : col+2]; got != fmt.Sprintf("%02x", c.at) {
+				t.Errorf("%s: caret at %q; want %s\n%s\n%s", c.spec, got, fmt.Sprintf("%02x", c.at), dump, caret)
+			}
+		} else if got := dump[col-3 : col-1]; got != fmt.Sprintf("%02x", c.at-1) {
+			t.Errorf("%s: caret after %q; want after %s\n%s\n%s", c.spec, got, fmt.Sprintf("%02x", c.at-1), dump, caret)
+		}
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,1019 @@
+/********************************************************/
+// bytgen 字节对象序列化代码生成工具
+// Author 		:Jella
+// Version 		:1.0.3(release)
+// Dependency		:none
+// Example		:
+//			//go:generate bytgen -type Player,Item
+//			或在结构体声明前添加 //byt:generate 注释后：
+//			//go:generate bytgen
+//			//go:generate bytgen -schema msg.schema		//同时保存带字段编号的结构体的消息结构
+//			bytgen -check msg.schema				//与保存的版本比较，不兼容时返回1
+/********************************************************/
+
+// bytgen为结构体生成MarshalByt(*byt.Buffer)及UnmarshalByt(*byt.Buffer)方法。
+// 生成的代码直接调用Buffer的Write*/Read*方法，不使用反射，
+// 写入的字节与byt.Marshal（以及手写的Write*调用）完全一致，字段标签的含义也与byt.Marshal相同。
+//
+// 字段标签带有tag=N的结构体按消息格式生成代码（参见byt.WriteField），-schema将它们的消息结构
+// 写入文件，-check将当前的消息结构与文件中的版本比较并列出差异，存在不兼容的修改时不生成代码并返回1。
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"Golang-master/byt"
+)
+
+var (
+	typeNames = flag.String("type", "", "逗号分隔的结构体名称（为空时处理带有//byt:generate注释的结构体）")
+	output    = flag.String("output", "", "输出文件名（默认为<源文件名>_byt.go）")
+	schema    = flag.String("schema", "", "写入消息结构的文件名")
+	check     = flag.String("check", "", "与之比较的消息结构文件名")
+)
+
+//生成代码所使用的byt包路径
+var bytPath = reflect.TypeOf(byt.Buffer{}).PkgPath()
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "用法: bytgen [-type T1,T2] [-output file] [-schema file] [-check file] [目录]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+
+	src, schemas, err := generate(dir, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "[Error]: bytgen: "+err.Error())
+		os.Exit(1)
+	}
+	if *check != "" {
+		ok, err := checkSchema(relTo(dir, *check), schemas)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "[Error]: bytgen: "+err.Error())
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+	}
+
+	out := *output
+	if out == "" {
+		base := os.Getenv("GOFILE")
+		if base == "" {
+			base = strings.ToLower(names0(names)) + ".go"
+		}
+		out = strings.TrimSuffix(base, ".go") + "_byt.go"
+	}
+	out = relTo(dir, out)
+	if err := os.WriteFile(out, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "[Error]: bytgen: "+err.Error())
+		os.Exit(1)
+	}
+
+	if *schema != "" {
+		var text strings.Builder
+		for i, s := range schemas {
+			if i > 0 {
+				text.WriteString("\n")
+			}
+			text.WriteString(s.String())
+		}
+		if err := os.WriteFile(relTo(dir, *schema), []byte(text.String()), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "[Error]: bytgen: "+err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+//相对路径按目录dir解析
+func relTo(dir, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(dir, name)
+}
+
+/**
+ * 将消息结构与文件中保存的版本比较并输出差异
+ * @param name 文件名
+ * @param schemas 当前的消息结构
+ * @return 是否兼容，错误信息
+ */
+func checkSchema(name string, schemas []*byt.Schema) (bool, error) {
+	text, err := os.ReadFile(name)
+	if err != nil {
+		return false, err
+	}
+	old, err := byt.ParseSchema(string(text))
+	if err != nil {
+		return false, err
+	}
+	ok := true
+	for _, s := range schemas {
+		for _, o := range old {
+			if o.Name != s.Name {
+				continue
+			}
+			for _, c := range byt.DiffSchema(o, s) {
+				fmt.Println(s.Name + ": " + c.String())
+				if c.Breaking {
+					ok = false
+				}
+			}
+		}
+	}
+	return ok, nil
+}
+
+func names0(names []string) string {
+	if len(names) > 0 {
+		return names[0]
+	}
+	return "byt"
+}
+
+/**
+ * 解析目录中的包并为指定的结构体生成代码
+ * @param dir 包所在目录
+ * @param names 结构体名称（为空时使用带有//byt:generate注释的结构体）
+ * @return 格式化后的源代码，带字段编号的结构体的消息结构，错误信息
+ */
+func generate(dir string, names []string) ([]byte, []*byt.Schema, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		n := fi.Name()
+		return !strings.HasSuffix(n, "_test.go") && !strings.HasSuffix(n, "_byt.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, nil, fmt.Errorf("目录%s中应当只有一个包", dir)
+	}
+
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {}, //生成的方法尚不存在时引用它们的代码会报错，忽略即可
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+
+	if len(names) == 0 {
+		names = annotated(files)
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("没有需要生成代码的结构体（使用-type或//byt:generate注释）")
+	}
+
+	g := &generator{pkg: pkg, imports: map[string]string{bytPath: "byt"}}
+	for _, name := range names {
+		obj := pkg.Scope().Lookup(name)
+		if obj == nil {
+			return nil, nil, fmt.Errorf("找不到类型%s", name)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s不是命名类型", name)
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			return nil, nil, fmt.Errorf("%s不是结构体", name)
+		}
+		if err := g.genType(name, named); err != nil {
+			return nil, nil, err
+		}
+	}
+	src, err := g.source()
+	return src, g.schemas, err
+}
+
+//带有//byt:generate注释的结构体
+func annotated(files []*ast.File) []string {
+	var names []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if _, ok := ts.Type.(*ast.StructType); !ok {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gd.Specs) == 1 {
+					doc = gd.Doc
+				}
+				if hasDirective(doc) {
+					names = append(names, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+func hasDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == "//byt:generate" {
+			return true
+		}
+	}
+	return false
+}
+
+////////////////////////////////////////////////////////////////////////
+//代码生成
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]string //包路径 => 包名
+	body    bytes.Buffer
+	indent  int
+	tmp     int
+	schemas []*byt.Schema //带字段编号的结构体的消息结构
+}
+
+//输出一行代码
+func (g *generator) p(format string, args ...interface{}) {
+	if strings.HasPrefix(format, "}") {
+		g.indent--
+	}
+	g.body.WriteString(strings.Repeat("\t", g.indent))
+	fmt.Fprintf(&g.body, format, args...)
+	g.body.WriteString("\n")
+	if strings.HasSuffix(format, "{") {
+		g.indent++
+	}
+}
+
+//生成一个不重复的临时变量名
+func (g *generator) name(prefix string) string {
+	g.tmp++
+	return fmt.Sprintf("%s%d", prefix, g.tmp)
+}
+
+//类型的源代码表示
+func (g *generator) typeStr(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func (g *generator) source() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString("// Code generated by bytgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	out.WriteString("import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("格式化生成的代码失败: %v\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+func (g *generator) genType(name string, named *types.Named) error {
+	fields, tagged, err := messageFields(named.Underlying().(*types.Struct))
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if tagged {
+		s := &byt.Schema{Name: name}
+		for _, f := range fields {
+			s.Fields = append(s.Fields, byt.SchemaField{Tag: f.tag, Name: f.name, Wire: fieldWire(f.typ, f.wire), Type: typeName(f.typ, f.wire), Default: f.def})
+		}
+		g.schemas = append(g.schemas, s)
+	}
+
+	g.tmp = 0
+	g.p("// MarshalByt 将%s写入b（与byt.Marshal写入的字节一致）", name)
+	g.p("func (v *%s) MarshalByt(b *byt.Buffer) error {", name)
+	if err := g.encode("v", named, ""); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	g.p("return nil")
+	g.p("}")
+	g.p("")
+
+	g.tmp = 0
+	g.p("// UnmarshalByt 从b中读取%s（与byt.Unmarshal读取的方式一致，出错时偏移位置恢复至读取前的位置）", name)
+	g.p("func (v *%s) UnmarshalByt(b *byt.Buffer) (err error) {", name)
+	g.p("defer func(offset int) {")
+	g.p("if err != nil {")
+	g.p("b.SetOffet(offset)")
+	g.p("}")
+	g.p("}(b.GetOffset())")
+	if err := g.decode("v", named, ""); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	g.p("return nil")
+	g.p("}")
+	g.p("")
+	return nil
+}
+
+//是否为byt包中的指定类型
+func isBytType(t types.Type, name string) bool {
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil {
+		return false
+	}
+	return n.Obj().Pkg().Path() == bytPath && n.Obj().Name() == name
+}
+
+func isByte(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Kind() == types.Uint8
+}
+
+//结构体中需要序列化的字段（与byt.Marshal的规则一致）
+type field struct {
+	name string
+	typ  types.Type
+	wire string
+	tag  int    //字段编号（tag=N，没有时为0）
+	def  string //默认值（default=V）
+}
+
+func structFields(s *types.Struct) []field {
+	var fields []field
+	for i := 0; i < s.NumFields(); i++ {
+		f := s.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		tag := reflect.StructTag(s.Tag(i)).Get("byt")
+		if tag == "-" {
+			continue
+		}
+		fd := field{name: f.Name(), typ: f.Type(), wire: tag}
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			fd.wire = tag[:j]
+			for _, opt := range strings.Split(tag[j+1:], ",") {
+				if n, ok := strings.CutPrefix(opt, "tag="); ok {
+					if fd.tag, _ = strconv.Atoi(n); fd.tag <= 0 {
+						fd.tag = -1
+					}
+				} else if d, ok := strings.CutPrefix(opt, "default="); ok {
+					fd.def = d
+				}
+			}
+		}
+		fields = append(fields, fd)
+	}
+	return fields
+}
+
+//类型编码后是否至少占用一个字节（用于在分配切片前检查剩余长度）
+func hasBytes(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		return u.Len() > 0 && hasBytes(u.Elem())
+	case *types.Struct:
+		if _, tagged, _ := messageFields(u); tagged {
+			return true //结束标记
+		}
+		for _, f := range structFields(u) {
+			if hasBytes(f.typ) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////
+//写
+
+func (g *generator) encode(expr string, t types.Type, wire string) error {
+	switch {
+	case isBytType(t, "Uint128"):
+		g.p("b.WriteUint128(%s)", expr)
+		return nil
+	case isBytType(t, "UUID"):
+		g.p("b.WriteUUID(%s)", expr)
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		g.p("b.WriteBoolean(%s != nil)", expr)
+		g.p("if %s != nil {", expr)
+		if err := g.encode("(*"+expr+")", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		return nil
+
+	case *types.Slice:
+		if isByte(u.Elem()) && (wire == "" || wire == "data") {
+			//nil与空数组相同（与WriteData一致）
+			if g.typeStr(t) == "[]byte" {
+				g.p("b.WriteData(%s)", expr)
+			} else {
+				g.p("b.WriteData([]byte(%s))", expr)
+			}
+			return nil
+		}
+		e := g.name("e")
+		g.p("if %s == nil {", expr)
+		g.p("b.WriteLength(0)")
+		g.p("} else {")
+		g.p("b.WriteLength(len(%s) + 1)", expr)
+		g.p("for _, %s := range %s {", e, expr)
+		if err := g.encode(e, u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("}")
+		return nil
+
+	case *types.Array:
+		i := g.name("i")
+		g.p("for %s := range %s {", i, expr)
+		if err := g.encode(expr+"["+i+"]", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		return nil
+
+	case *types.Map:
+		keys, i, k := g.name("keys"), g.name("i"), g.name("k")
+		g.p("if %s == nil {", expr)
+		g.p("b.WriteLength(0)")
+		g.p("} else {")
+		g.p("b.WriteLength(len(%s) + 1)", expr)
+		g.p("%s := make([]%s, 0, len(%s))", keys, g.typeStr(u.Key()), expr)
+		g.p("for %s := range %s {", k, expr)
+		g.p("%s = append(%s, %s)", keys, keys, k)
+		g.p("}")
+		g.p("for _, %s := range byt.SortKeys(b.Order(), len(%s), func(b *byt.Buffer, %s int) {", i, keys, i)
+		if err := g.encode(keys+"["+i+"]", u.Key(), ""); err != nil {
+			return err
+		}
+		g.p("}) {")
+		if err := g.encode(keys+"["+i+"]", u.Key(), ""); err != nil {
+			return err
+		}
+		if err := g.encode(expr+"["+keys+"["+i+"]]", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("}")
+		return nil
+
+	case *types.Struct:
+		fields, tagged, err := messageFields(u)
+		if err != nil {
+			return err
+		}
+		if tagged {
+			return g.encodeMessage(expr, fields)
+		}
+		for _, f := range fields {
+			if err := g.encode(expr+"."+f.name, f.typ, f.wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *types.Basic:
+		return g.encodeLeaf(expr, t, u, wire)
+	}
+	return fmt.Errorf("不支持的类型%s", t)
+}
+
+//编码方式 => 写入方法及参数类型
+var writers = map[string][2]string{
+	"bool":       {"WriteBoolean", "bool"},
+	"int8":       {"WriteByt", "int8"},
+	"uint8":      {"WriteUnsignedByt", "uint8"},
+	"int16":      {"WriteShort", "int16"},
+	"uint16":     {"WriteUnsignedShort", "uint16"},
+	"int32":      {"WriteInt", "int32"},
+	"uint32":     {"WriteUnsignedInt", "uint32"},
+	"int64":      {"WriteLong", "int64"},
+	"uint64":     {"WriteUnsignedLong", "uint64"},
+	"varint":     {"WriteVarint", "int64"},
+	"uvarint":    {"WriteUvarint", "uint64"},
+	"length":     {"WriteLength", "int"},
+	"float32":    {"WriteFloat32", "float32"},
+	"float64":    {"WriteFloat", "float64"},
+	"complex64":  {"WriteComplex64", "complex64"},
+	"complex128": {"WriteComplex128", "complex128"},
+	"utf8":       {"WriteUTF8String", "string"},
+	"utf":        {"WriteUTF", "string"},
+	"data":       {"WriteData", "[]byte"},
+}
+
+//编码方式 => 读取方法
+var readers = map[string]string{
+	"bool":       "TryReadBoolean",
+	"int8":       "TryReadByt",
+	"uint8":      "TryReadUnsignedByt",
+	"int16":      "TryReadShort",
+	"uint16":     "TryReadUnsignedShort",
+	"int32":      "TryReadInt",
+	"uint32":     "TryReadUnsignedInt",
+	"int64":      "TryReadLong",
+	"uint64":     "TryReadUnsignedLong",
+	"varint":     "TryReadVarint",
+	"uvarint":    "TryReadUvarint",
+	"length":     "TryReadLength",
+	"float32":    "TryReadFloat32",
+	"float64":    "TryReadFloat",
+	"complex64":  "TryReadComplex64",
+	"complex128": "TryReadComplex128",
+	"utf8":       "TryReadUTF8String",
+	"utf":        "TryReadUTF",
+	"data":       "TryReadData",
+}
+
+//默认编码方式（与byt.Marshal一致）
+func defaultWire(b *types.Basic) string {
+	switch b.Kind() {
+	case types.Int, types.Int64:
+		return "int64"
+	case types.Uint, types.Uint64, types.Uintptr:
+		return "uint64"
+	case types.String:
+		return "utf8"
+	case types.Bool, types.Int8, types.Uint8, types.Int16, types.Uint16, types.Int32, types.Uint32,
+		types.Float32, types.Float64, types.Complex64, types.Complex128:
+		return b.Name()
+	}
+	return ""
+}
+
+//检查编码方式与字段类型是否匹配
+func checkWire(b *types.Basic, wire string) bool {
+	info := b.Info()
+	switch writers[wire][1] {
+	case "bool":
+		return info&types.IsBoolean != 0
+	case "float32", "float64":
+		return info&types.IsFloat != 0
+	case "complex64", "complex128":
+		return info&types.IsComplex != 0
+	case "string", "[]byte":
+		return info&types.IsString != 0
+	case "":
+		return false
+	}
+	return info&types.IsInteger != 0
+}
+
+func (g *generator) encodeLeaf(expr string, t types.Type, b *types.Basic, wire string) error {
+	if wire == "" {
+		wire = defaultWire(b)
+	}
+	if !checkWire(b, wire) {
+		return fmt.Errorf("类型%s不支持编码方式%s", b, wire)
+	}
+	w := writers[wire]
+	if wire == "data" {
+		w = [2]string{"WriteDataString", "string"} //字符串直接写入，不转换为[]byte
+	}
+	if g.typeStr(t) == w[1] {
+		g.p("b.%s(%s)", w[0], expr)
+	} else {
+		g.p("b.%s(%s(%s))", w[0], w[1], expr)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//读
+
+func (g *generator) ret() {
+	g.p("if err != nil {")
+	g.p("return err")
+	g.p("}")
+}
+
+func (g *generator) decode(target string, t types.Type, wire string) error {
+	switch {
+	case isBytType(t, "Uint128"), isBytType(t, "UUID"):
+		x := g.name("x")
+		g.p("{")
+		g.p("%s, err := b.TryRead%s()", x, t.(*types.Named).Obj().Name())
+		g.ret()
+		g.p("%s = %s", target, x)
+		g.p("}")
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		ok := g.name("ok")
+		g.p("{")
+		g.p("%s, err := b.TryReadBoolean()", ok)
+		g.ret()
+		g.p("if !%s {", ok)
+		g.p("%s = nil", target)
+		g.p("} else {")
+		g.p("if %s == nil {", target)
+		g.p("%s = new(%s)", target, g.typeStr(u.Elem()))
+		g.p("}")
+		if err := g.decode("(*"+target+")", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("}")
+		return nil
+
+	case *types.Slice:
+		n, s := g.name("n"), g.name("s")
+		g.p("{")
+		if isByte(u.Elem()) && (wire == "" || wire == "data") {
+			//字节数组按LimitData检查长度（与Unmarshal相同），长度值0为之前的版本写入的nil
+			g.p("%s, err := b.PeekLength()", n)
+			g.ret()
+			g.p("if %s == 0 {", n)
+			g.p("b.TryReadLength()")
+			g.p("%s = nil", target)
+			g.p("} else {")
+			g.p("%s, err := b.TryReadData()", s)
+			g.ret()
+			g.p("%s = %s", target, s)
+			g.p("}")
+			g.p("}")
+			return nil
+		}
+		g.p("%s, err := b.TryReadCount()", n)
+		g.ret()
+		g.p("if %s < 0 {", n)
+		g.p("%s = nil", target)
+		g.p("} else {")
+		if hasBytes(u.Elem()) {
+			g.p("if %s > b.Remaining() {", n)
+			g.p("return byt.ErrUnderflow")
+			g.p("}")
+		}
+		g.p("%s := make(%s, %s)", s, g.typeStr(t), n)
+		i := g.name("i")
+		g.p("for %s := range %s {", i, s)
+		if err := g.decode(s+"["+i+"]", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		g.p("%s = %s", target, s)
+		g.p("}")
+		g.p("}")
+		return nil
+
+	case *types.Array:
+		i := g.name("i")
+		g.p("for %s := range %s {", i, target)
+		if err := g.decode(target+"["+i+"]", u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("}")
+		return nil
+
+	case *types.Map:
+		n, m, i, k, e := g.name("n"), g.name("m"), g.name("i"), g.name("k"), g.name("e")
+		g.p("{")
+		g.p("%s, err := b.TryReadCount()", n)
+		g.ret()
+		g.p("if %s < 0 {", n)
+		g.p("%s = nil", target)
+		g.p("} else {")
+		if hasBytes(u.Key()) || hasBytes(u.Elem()) {
+			g.p("if %s > b.Remaining() {", n)
+			g.p("return byt.ErrUnderflow")
+			g.p("}")
+		}
+		g.p("%s := make(%s, %s)", m, g.typeStr(t), n)
+		g.p("for %s := 0; %s < %s; %s++ {", i, i, n, i)
+		g.p("var %s %s", k, g.typeStr(u.Key()))
+		if err := g.decode(k, u.Key(), ""); err != nil {
+			return err
+		}
+		g.p("var %s %s", e, g.typeStr(u.Elem()))
+		if err := g.decode(e, u.Elem(), wire); err != nil {
+			return err
+		}
+		g.p("%s[%s] = %s", m, k, e)
+		g.p("}")
+		g.p("%s = %s", target, m)
+		g.p("}")
+		g.p("}")
+		return nil
+
+	case *types.Struct:
+		fields, tagged, err := messageFields(u)
+		if err != nil {
+			return err
+		}
+		if tagged {
+			return g.decodeMessage(target, t, fields)
+		}
+		for _, f := range fields {
+			if err := g.decode(target+"."+f.name, f.typ, f.wire); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case *types.Basic:
+		return g.decodeLeaf(target, t, u, wire)
+	}
+	return fmt.Errorf("不支持的类型%s", t)
+}
+
+//整数类型的位数（int、uint及uintptr与平台相关，返回0）
+func intSize(name string) int {
+	switch name {
+	case "int8", "uint8":
+		return 8
+	case "int16", "uint16":
+		return 16
+	case "int32", "uint32":
+		return 32
+	case "int64", "uint64":
+		return 64
+	}
+	return 0
+}
+
+func (g *generator) decodeLeaf(target string, t types.Type, b *types.Basic, wire string) error {
+	if wire == "" {
+		wire = defaultWire(b)
+	}
+	if !checkWire(b, wire) {
+		return fmt.Errorf("类型%s不支持编码方式%s", b, wire)
+	}
+	w := writers[wire][1]
+	T := g.typeStr(t)
+	x := g.name("x")
+
+	g.p("{")
+	g.p("%s, err := b.%s()", x, readers[wire])
+	g.ret()
+
+	if b.Info()&types.IsInteger != 0 && T != w {
+		//整数溢出检查（与byt.Unmarshal一致）
+		wSigned := !strings.HasPrefix(w, "u")
+		fSigned := b.Info()&types.IsUnsigned == 0
+		ws, fs := intSize(w), intSize(b.Name())
+		cond := ""
+		switch {
+		case wSigned == fSigned && (fs == 0 || ws == 0 || fs < ws):
+			cond = fmt.Sprintf("%s(%s(%s)) != %s", w, T, x, x)
+		case wSigned && !fSigned:
+			cond = fmt.Sprintf("%s < 0 || %s(%s(%s)) != %s", x, w, T, x, x)
+		case !wSigned && fSigned:
+			cond = fmt.Sprintf("%s(%s) < 0 || %s(%s(%s)) != %s", T, x, w, T, x, x)
+		}
+		if cond != "" {
+			g.p("if %s {", cond)
+			g.p("return byt.ErrOverflow")
+			g.p("}")
+		}
+	}
+
+	if T == w {
+		g.p("%s = %s", target, x)
+	} else {
+		g.p("%s = %s(%s)", target, T, x)
+	}
+	g.p("}")
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////
+//消息（带字段编号的结构体）
+
+//结构体的字段及是否带有字段编号（与byt.Marshal的规则一致）
+func messageFields(s *types.Struct) ([]field, bool, error) {
+	fields := structFields(s)
+	tagged := false
+	for _, f := range fields {
+		if f.tag != 0 {
+			tagged = true
+		}
+	}
+	if !tagged {
+		return fields, false, nil
+	}
+	seen := make(map[int]bool, len(fields))
+	for _, f := range fields {
+		if f.tag <= 0 || f.tag > 1<<29-1 {
+			return nil, false, fmt.Errorf("%s缺少字段编号或编号不合法", f.name)
+		}
+		if seen[f.tag] {
+			return nil, false, fmt.Errorf("%s的字段编号%d重复", f.name, f.tag)
+		}
+		seen[f.tag] = true
+		if f.def != "" {
+			if _, err := defaultLiteral(f.typ, f.def); err != nil {
+				return nil, false, fmt.Errorf("%s的默认值%q不合法", f.name, f.def)
+			}
+		}
+	}
+	return fields, true, nil
+}
+
+//字段的编码类型
+func fieldWire(t types.Type, wire string) byt.Wire {
+	if isBytType(t, "Uint128") || isBytType(t, "UUID") {
+		return byt.WireBytes
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return byt.WireBytes
+	}
+	if wire == "" {
+		wire = defaultWire(b)
+	}
+	return byt.WireOf(wire)
+}
+
+//编码类型在生成的代码中的名称
+func wireConst(w byt.Wire) string {
+	return [...]string{"byt.WireFixed8", "byt.WireFixed16", "byt.WireFixed32", "byt.WireFixed64", "byt.WireVarint", "byt.WireBytes"}[w]
+}
+
+//内容的编码方式（与byt.SchemaOf一致）
+func typeName(t types.Type, wire string) string {
+	switch {
+	case isBytType(t, "Uint128"):
+		return "uint128"
+	case isBytType(t, "UUID"):
+		return "uuid"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		return "*" + typeName(u.Elem(), wire)
+	case *types.Slice:
+		if isByte(u.Elem()) && (wire == "" || wire == "data") {
+			return "data"
+		}
+		return "[]" + typeName(u.Elem(), wire)
+	case *types.Array:
+		return "[" + strconv.FormatInt(u.Len(), 10) + "]" + typeName(u.Elem(), wire)
+	case *types.Map:
+		return "map[" + typeName(u.Key(), "") + "]" + typeName(u.Elem(), wire)
+	case *types.Struct:
+		if n, ok := t.(*types.Named); ok {
+			return n.Obj().Name()
+		}
+		return "struct"
+	case *types.Basic:
+		if wire == "" {
+			wire = defaultWire(u)
+		}
+		return wire
+	}
+	return t.String()
+}
+
+//default=指定的默认值在生成的代码中的字面量
+func defaultLiteral(t types.Type, def string) (string, error) {
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "", fmt.Errorf("类型%s不支持默认值", t)
+	}
+	bits := intSize(b.Name())
+	if bits == 0 {
+		bits = 64
+	}
+	info := b.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		x, err := strconv.ParseBool(def)
+		return strconv.FormatBool(x), err
+	case info&types.IsUnsigned != 0:
+		x, err := strconv.ParseUint(def, 0, bits)
+		return strconv.FormatUint(x, 10), err
+	case info&types.IsInteger != 0:
+		x, err := strconv.ParseInt(def, 0, bits)
+		return strconv.FormatInt(x, 10), err
+	case info&types.IsFloat != 0:
+		if b.Kind() == types.Float32 {
+			bits = 32
+		}
+		x, err := strconv.ParseFloat(def, bits)
+		if err == nil && (math.IsInf(x, 0) || math.IsNaN(x)) {
+			err = strconv.ErrRange
+		}
+		return strconv.FormatFloat(x, 'g', -1, bits), err
+	case info&types.IsString != 0:
+		return strconv.Quote(def), nil
+	}
+	return "", fmt.Errorf("类型%s不支持默认值", t)
+}
+
+func (g *generator) encodeMessage(expr string, fields []field) error {
+	for _, f := range fields {
+		w := fieldWire(f.typ, f.wire)
+		if w != byt.WireBytes {
+			g.p("b.WriteFieldKey(%d, %s)", f.tag, wireConst(w))
+			if err := g.encode(expr+"."+f.name, f.typ, f.wire); err != nil {
+				return err
+			}
+			continue
+		}
+		s := g.name("s")
+		g.p("%s := b.BeginField(%d)", s, f.tag)
+		if err := g.encode(expr+"."+f.name, f.typ, f.wire); err != nil {
+			return err
+		}
+		g.p("b.EndField(%s)", s)
+	}
+	g.p("b.WriteMessageEnd()")
+	return nil
+}
+
+//先将所有字段设为默认值，再读取消息中出现的字段（内容从回调参数b中读取）
+func (g *generator) decodeMessage(target string, t types.Type, fields []field) error {
+	var inits []string
+	for _, f := range fields {
+		if f.def != "" {
+			lit, _ := defaultLiteral(f.typ, f.def)
+			inits = append(inits, f.name+": "+lit)
+		}
+	}
+	lhs := target
+	if lhs == "v" {
+		lhs = "*v"
+	}
+	g.p("%s = %s{%s}", lhs, g.typeStr(t), strings.Join(inits, ", "))
+
+	g.p("if err := b.TryReadMessage(func(tag int, wire byt.Wire, b *byt.Buffer) error {")
+	g.p("switch tag {")
+	for _, f := range fields {
+		g.p("case %d:", f.tag)
+		g.p("if wire != %s {", wireConst(fieldWire(f.typ, f.wire)))
+		g.p("return byt.ErrBadWire")
+		g.p("}")
+		if err := g.decode(target+"."+f.name, f.typ, f.wire); err != nil {
+			return err
+		}
+	}
+	g.p("}")
+	g.p("return nil")
+	g.p("}); err != nil {")
+	g.p("return err")
+	g.p("}")
+	return nil
+}
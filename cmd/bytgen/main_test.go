@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//重新生成example/msg的代码及消息结构，并与提交的文件比较
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("..", "..", "example", "msg")
+	src, schemas, err := generate(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "msg_byt.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("msg_byt.go已过期，请在example/msg中重新运行go generate\n%s", firstDiff(string(src), string(want)))
+	}
+
+	var text strings.Builder
+	for i, s := range schemas {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		text.WriteString(s.String())
+	}
+	want, err = os.ReadFile(filepath.Join(dir, "msg.schema"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text.String() != string(want) {
+		t.Errorf("msg.schema已过期\n%s", firstDiff(text.String(), string(want)))
+	}
+}
+
+//第一处不同的行
+func firstDiff(got, want string) string {
+	g, w := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(g) || i < len(w); i++ {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			return "line " + strconv.Itoa(i+1) + ":\n\tgot:  " + gl + "\n\twant: " + wl
+		}
+	}
+	return ""
+}
+
+//data编码的字符串字段直接写入，不转换为[]byte
+func TestGenerateDataString(t *testing.T) {
+	dir := t.TempDir()
+	src := "package p\n\n//byt:generate\ntype Note struct {\n\tText string `byt:\"data\"`\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, _, err := generate(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("b.WriteDataString(v.Text)")) || bytes.Contains(out, []byte("[]byte(v.Text)")) {
+		t.Errorf("generated:\n%s", out)
+	}
+}
@@ -14,7 +14,7 @@ import (
 
 func main() {
 
-	_s := make(chan os.Signal)
+	_s := make(chan os.Signal, 1)
 	signal.Notify(_s, os.Interrupt)
 
 	//创建websocket client连接配置及连接对象
@@ -43,13 +43,13 @@ func main() {
 		go cli.Reci(func(data []byte) {
 			_buf := byt.NewBufferWithByte(data)
 			sary := strings.Split(_buf.ReadUTF8String(), "|")
+			if _buf.Err() != nil || len(sary) < 2 {
+				return
+			}
 			fmt.Println(sary[0] + " -> " + sary[1])
-			_buf.Kill()
-			_buf = nil
 		})
 
-		//开启一条心的线程进行消息发送
-		buf := byt.NewBuffer()
+		//开启一条新的线程进行消息发送
 		rand.Seed(time.Now().Unix())
 		numstr := ""
 		go func() {
@@ -58,9 +58,10 @@ func main() {
 				numstr = strconv.Itoa(rand.Intn(100000000000))
 				numstr += " - " + numstr
 
-				buf.Zero()
+				//从对象池获取，发送完毕后由cli自动放回对象池
+				buf := byt.Acquire(64)
 				buf.WriteUTF8String("hello server!|" + numstr)
-				cli.Send(buf.GetByte())
+				cli.SendBuffer(buf)
 				break
 			}
 		}()
@@ -0,0 +1,43 @@
+package msg
+
+//go:generate bytgen -schema msg.schema
+
+/**
+ * 道具
+ */
+//byt:generate
+type Item struct {
+	Id    int32
+	Count uint16 `byt:"varint"`
+}
+
+/**
+ * 玩家信息
+ */
+//byt:generate
+type Player struct {
+	Id     int64
+	Name   string
+	Level  int32  `byt:"int16"`
+	Gold   uint64 `byt:"uvarint"`
+	Pos    [2]float32
+	Items  []Item
+	Equip  *Item
+	Attrs  map[string]int32 `byt:"varint"`
+	Avatar []byte
+	Cache  string `byt:"-"`
+}
+
+/**
+ * 玩家资料（带字段编号，新旧版本的客户端可以互相读取）
+ */
+//byt:generate
+type Profile struct {
+	Id     int64            `byt:",tag=1"`
+	Name   string           `byt:",tag=2"`
+	Level  int32            `byt:"varint,tag=3,default=1"`
+	Equip  *Item            `byt:",tag=4"`
+	Titles []string         `byt:",tag=5"`
+	Stats  map[string]int32 `byt:"varint,tag=6"`
+	Vip    bool             `byt:",tag=7"`
+}
@@ -0,0 +1,464 @@
+// Code generated by bytgen. DO NOT EDIT.
+
+package msg
+
+import (
+	"Golang-master/byt"
+)
+
+// MarshalByt 将Item写入b（与byt.Marshal写入的字节一致）
+func (v *Item) MarshalByt(b *byt.Buffer) error {
+	b.WriteInt(v.Id)
+	b.WriteVarint(int64(v.Count))
+	return nil
+}
+
+// UnmarshalByt 从b中读取Item（与byt.Unmarshal读取的方式一致，出错时偏移位置恢复至读取前的位置）
+func (v *Item) UnmarshalByt(b *byt.Buffer) (err error) {
+	defer func(offset int) {
+		if err != nil {
+			b.SetOffet(offset)
+		}
+	}(b.GetOffset())
+	{
+		x1, err := b.TryReadInt()
+		if err != nil {
+			return err
+		}
+		v.Id = x1
+	}
+	{
+		x2, err := b.TryReadVarint()
+		if err != nil {
+			return err
+		}
+		if x2 < 0 || int64(uint16(x2)) != x2 {
+			return byt.ErrOverflow
+		}
+		v.Count = uint16(x2)
+	}
+	return nil
+}
+
+// MarshalByt 将Player写入b（与byt.Marshal写入的字节一致）
+func (v *Player) MarshalByt(b *byt.Buffer) error {
+	b.WriteLong(v.Id)
+	b.WriteUTF8String(v.Name)
+	b.WriteShort(int16(v.Level))
+	b.WriteUvarint(v.Gold)
+	for i1 := range v.Pos {
+		b.WriteFloat32(v.Pos[i1])
+	}
+	if v.Items == nil {
+		b.WriteLength(0)
+	} else {
+		b.WriteLength(len(v.Items) + 1)
+		for _, e2 := range v.Items {
+			b.WriteInt(e2.Id)
+			b.WriteVarint(int64(e2.Count))
+		}
+	}
+	b.WriteBoolean(v.Equip != nil)
+	if v.Equip != nil {
+		b.WriteInt((*v.Equip).Id)
+		b.WriteVarint(int64((*v.Equip).Count))
+	}
+	if v.Attrs == nil {
+		b.WriteLength(0)
+	} else {
+		b.WriteLength(len(v.Attrs) + 1)
+		keys3 := make([]string, 0, len(v.Attrs))
+		for k5 := range v.Attrs {
+			keys3 = append(keys3, k5)
+		}
+		for _, i4 := range byt.SortKeys(b.Order(), len(keys3), func(b *byt.Buffer, i4 int) {
+			b.WriteUTF8String(keys3[i4])
+		}) {
+			b.WriteUTF8String(keys3[i4])
+			b.WriteVarint(int64(v.Attrs[keys3[i4]]))
+		}
+	}
+	b.WriteData(v.Avatar)
+	return nil
+}
+
+// UnmarshalByt 从b中读取Player（与byt.Unmarshal读取的方式一致，出错时偏移位置恢复至读取前的位置）
+func (v *Player) UnmarshalByt(b *byt.Buffer) (err error) {
+	defer func(offset int) {
+		if err != nil {
+			b.SetOffet(offset)
+		}
+	}(b.GetOffset())
+	{
+		x1, err := b.TryReadLong()
+		if err != nil {
+			return err
+		}
+		v.Id = x1
+	}
+	{
+		x2, err := b.TryReadUTF8String()
+		if err != nil {
+			return err
+		}
+		v.Name = x2
+	}
+	{
+		x3, err := b.TryReadShort()
+		if err != nil {
+			return err
+		}
+		v.Level = int32(x3)
+	}
+	{
+		x4, err := b.TryReadUvarint()
+		if err != nil {
+			return err
+		}
+		v.Gold = x4
+	}
+	for i5 := range v.Pos {
+		{
+			x6, err := b.TryReadFloat32()
+			if err != nil {
+				return err
+			}
+			v.Pos[i5] = x6
+		}
+	}
+	{
+		n7, err := b.TryReadCount()
+		if err != nil {
+			return err
+		}
+		if n7 < 0 {
+			v.Items = nil
+		} else {
+			if n7 > b.Remaining() {
+				return byt.ErrUnderflow
+			}
+			s8 := make([]Item, n7)
+			for i9 := range s8 {
+				{
+					x10, err := b.TryReadInt()
+					if err != nil {
+						return err
+					}
+					s8[i9].Id = x10
+				}
+				{
+					x11, err := b.TryReadVarint()
+					if err != nil {
+						return err
+					}
+					if x11 < 0 || int64(uint16(x11)) != x11 {
+						return byt.ErrOverflow
+					}
+					s8[i9].Count = uint16(x11)
+				}
+			}
+			v.Items = s8
+		}
+	}
+	{
+		ok12, err := b.TryReadBoolean()
+		if err != nil {
+			return err
+		}
+		if !ok12 {
+			v.Equip = nil
+		} else {
+			if v.Equip == nil {
+				v.Equip = new(Item)
+			}
+			{
+				x13, err := b.TryReadInt()
+				if err != nil {
+					return err
+				}
+				(*v.Equip).Id = x13
+			}
+			{
+				x14, err := b.TryReadVarint()
+				if err != nil {
+					return err
+				}
+				if x14 < 0 || int64(uint16(x14)) != x14 {
+					return byt.ErrOverflow
+				}
+				(*v.Equip).Count = uint16(x14)
+			}
+		}
+	}
+	{
+		n15, err := b.TryReadCount()
+		if err != nil {
+			return err
+		}
+		if n15 < 0 {
+			v.Attrs = nil
+		} else {
+			if n15 > b.Remaining() {
+				return byt.ErrUnderflow
+			}
+			m16 := make(map[string]int32, n15)
+			for i17 := 0; i17 < n15; i17++ {
+				var k18 string
+				{
+					x20, err := b.TryReadUTF8String()
+					if err != nil {
+						return err
+					}
+					k18 = x20
+				}
+				var e19 int32
+				{
+					x21, err := b.TryReadVarint()
+					if err != nil {
+						return err
+					}
+					if int64(int32(x21)) != x21 {
+						return byt.ErrOverflow
+					}
+					e19 = int32(x21)
+				}
+				m16[k18] = e19
+			}
+			v.Attrs = m16
+		}
+	}
+	{
+		n22, err := b.PeekLength()
+		if err != nil {
+			return err
+		}
+		if n22 == 0 {
+			b.TryReadLength()
+			v.Avatar = nil
+		} else {
+			s23, err := b.TryReadData()
+			if err != nil {
+				return err
+			}
+			v.Avatar = s23
+		}
+	}
+	return nil
+}
+
+// MarshalByt 将Profile写入b（与byt.Marshal写入的字节一致）
+func (v *Profile) MarshalByt(b *byt.Buffer) error {
+	b.WriteFieldKey(1, byt.WireFixed64)
+	b.WriteLong(v.Id)
+	s1 := b.BeginField(2)
+	b.WriteUTF8String(v.Name)
+	b.EndField(s1)
+	b.WriteFieldKey(3, byt.WireVarint)
+	b.WriteVarint(int64(v.Level))
+	s2 := b.BeginField(4)
+	b.WriteBoolean(v.Equip != nil)
+	if v.Equip != nil {
+		b.WriteInt((*v.Equip).Id)
+		b.WriteVarint(int64((*v.Equip).Count))
+	}
+	b.EndField(s2)
+	s3 := b.BeginField(5)
+	if v.Titles == nil {
+		b.WriteLength(0)
+	} else {
+		b.WriteLength(len(v.Titles) + 1)
+		for _, e4 := range v.Titles {
+			b.WriteUTF8String(e4)
+		}
+	}
+	b.EndField(s3)
+	s5 := b.BeginField(6)
+	if v.Stats == nil {
+		b.WriteLength(0)
+	} else {
+		b.WriteLength(len(v.Stats) + 1)
+		keys6 := make([]string, 0, len(v.Stats))
+		for k8 := range v.Stats {
+			keys6 = append(keys6, k8)
+		}
+		for _, i7 := range byt.SortKeys(b.Order(), len(keys6), func(b *byt.Buffer, i7 int) {
+			b.WriteUTF8String(keys6[i7])
+		}) {
+			b.WriteUTF8String(keys6[i7])
+			b.WriteVarint(int64(v.Stats[keys6[i7]]))
+		}
+	}
+	b.EndField(s5)
+	b.WriteFieldKey(7, byt.WireFixed8)
+	b.WriteBoolean(v.Vip)
+	b.WriteMessageEnd()
+	return nil
+}
+
+// UnmarshalByt 从b中读取Profile（与byt.Unmarshal读取的方式一致，出错时偏移位置恢复至读取前的位置）
+func (v *Profile) UnmarshalByt(b *byt.Buffer) (err error) {
+	defer func(offset int) {
+		if err != nil {
+			b.SetOffet(offset)
+		}
+	}(b.GetOffset())
+	*v = Profile{Level: 1}
+	if err := b.TryReadMessage(func(tag int, wire byt.Wire, b *byt.Buffer) error {
+		switch tag {
+		case 1:
+			if wire != byt.WireFixed64 {
+				return byt.ErrBadWire
+			}
+			{
+				x1, err := b.TryReadLong()
+				if err != nil {
+					return err
+				}
+				v.Id = x1
+			}
+		case 2:
+			if wire != byt.WireBytes {
+				return byt.ErrBadWire
+			}
+			{
+				x2, err := b.TryReadUTF8String()
+				if err != nil {
+					return err
+				}
+				v.Name = x2
+			}
+		case 3:
+			if wire != byt.WireVarint {
+				return byt.ErrBadWire
+			}
+			{
+				x3, err := b.TryReadVarint()
+				if err != nil {
+					return err
+				}
+				if int64(int32(x3)) != x3 {
+					return byt.ErrOverflow
+				}
+				v.Level = int32(x3)
+			}
+		case 4:
+			if wire != byt.WireBytes {
+				return byt.ErrBadWire
+			}
+			{
+				ok4, err := b.TryReadBoolean()
+				if err != nil {
+					return err
+				}
+				if !ok4 {
+					v.Equip = nil
+				} else {
+					if v.Equip == nil {
+						v.Equip = new(Item)
+					}
+					{
+						x5, err := b.TryReadInt()
+						if err != nil {
+							return err
+						}
+						(*v.Equip).Id = x5
+					}
+					{
+						x6, err := b.TryReadVarint()
+						if err != nil {
+							return err
+						}
+						if x6 < 0 || int64(uint16(x6)) != x6 {
+							return byt.ErrOverflow
+						}
+						(*v.Equip).Count = uint16(x6)
+					}
+				}
+			}
+		case 5:
+			if wire != byt.WireBytes {
+				return byt.ErrBadWire
+			}
+			{
+				n7, err := b.TryReadCount()
+				if err != nil {
+					return err
+				}
+				if n7 < 0 {
+					v.Titles = nil
+				} else {
+					if n7 > b.Remaining() {
+						return byt.ErrUnderflow
+					}
+					s8 := make([]string, n7)
+					for i9 := range s8 {
+						{
+							x10, err := b.TryReadUTF8String()
+							if err != nil {
+								return err
+							}
+							s8[i9] = x10
+						}
+					}
+					v.Titles = s8
+				}
+			}
+		case 6:
+			if wire != byt.WireBytes {
+				return byt.ErrBadWire
+			}
+			{
+				n11, err := b.TryReadCount()
+				if err != nil {
+					return err
+				}
+				if n11 < 0 {
+					v.Stats = nil
+				} else {
+					if n11 > b.Remaining() {
+						return byt.ErrUnderflow
+					}
+					m12 := make(map[string]int32, n11)
+					for i13 := 0; i13 < n11; i13++ {
+						var k14 string
+						{
+							x16, err := b.TryReadUTF8String()
+							if err != nil {
+								return err
+							}
+							k14 = x16
+						}
+						var e15 int32
+						{
+							x17, err := b.TryReadVarint()
+							if err != nil {
+								return err
+							}
+							if int64(int32(x17)) != x17 {
+								return byt.ErrOverflow
+							}
+							e15 = int32(x17)
+						}
+						m12[k14] = e15
+					}
+					v.Stats = m12
+				}
+			}
+		case 7:
+			if wire != byt.WireFixed8 {
+				return byt.ErrBadWire
+			}
+			{
+				x18, err := b.TryReadBoolean()
+				if err != nil {
+					return err
+				}
+				v.Vip = x18
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return nil
+}
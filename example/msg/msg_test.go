@@ -0,0 +1,282 @@
+package msg
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"Golang-master/byt"
+)
+
+//与Player、Profile结构相同但没有生成的方法（按反射编码）
+type reflectPlayer Player
+type reflectProfile Profile
+
+func testPlayer() Player {
+	return Player{
+		Id:     10001,
+		Name:   "玩家",
+		Level:  42,
+		Gold:   1 << 40,
+		Pos:    [2]float32{1.5, -2.25},
+		Items:  []Item{{Id: 1, Count: 3}, {Id: 2, Count: 300}},
+		Equip:  &Item{Id: 7, Count: 1},
+		Attrs:  map[string]int32{"atk": 12, "def": -5},
+		Avatar: []byte{0x89, 'P', 'N', 'G'},
+		Cache:  "不写入",
+	}
+}
+
+func testProfile() Profile {
+	return Profile{
+		Id:     10001,
+		Name:   "玩家",
+		Level:  42,
+		Equip:  &Item{Id: 7, Count: 1},
+		Titles: []string{"勇者", "王"},
+		Stats:  map[string]int32{"atk": 12, "def": -5},
+		Vip:    true,
+	}
+}
+
+//手写的Write*调用（Attrs、Stats的键长度相同，按字典序写入）
+func handPlayer(b *byt.Buffer, p *Player) {
+	b.WriteLong(p.Id)
+	b.WriteUTF8String(p.Name)
+	b.WriteShort(int16(p.Level))
+	b.WriteUvarint(p.Gold)
+	b.WriteFloat32(p.Pos[0])
+	b.WriteFloat32(p.Pos[1])
+	b.WriteLength(len(p.Items) + 1)
+	for _, it := range p.Items {
+		b.WriteInt(it.Id)
+		b.WriteVarint(int64(it.Count))
+	}
+	b.WriteBoolean(true)
+	b.WriteInt(p.Equip.Id)
+	b.WriteVarint(int64(p.Equip.Count))
+	b.WriteLength(len(p.Attrs) + 1)
+	for _, k := range []string{"atk", "def"} {
+		b.WriteUTF8String(k)
+		b.WriteVarint(int64(p.Attrs[k]))
+	}
+	b.WriteData(p.Avatar)
+}
+
+func handProfile(b *byt.Buffer, p *Profile) {
+	b.WriteField(1, byt.WireFixed64, func() { b.WriteLong(p.Id) })
+	b.WriteField(2, byt.WireBytes, func() { b.WriteUTF8String(p.Name) })
+	b.WriteField(3, byt.WireVarint, func() { b.WriteVarint(int64(p.Level)) })
+	b.WriteField(4, byt.WireBytes, func() {
+		b.WriteBoolean(true)
+		b.WriteInt(p.Equip.Id)
+		b.WriteVarint(int64(p.Equip.Count))
+	})
+	b.WriteField(5, byt.WireBytes, func() {
+		b.WriteLength(len(p.Titles) + 1)
+		for _, s := range p.Titles {
+			b.WriteUTF8String(s)
+		}
+	})
+	b.WriteField(6, byt.WireBytes, func() {
+		b.WriteLength(len(p.Stats) + 1)
+		for _, k := range []string{"atk", "def"} {
+			b.WriteUTF8String(k)
+			b.WriteVarint(int64(p.Stats[k]))
+		}
+	})
+	b.WriteField(7, byt.WireFixed8, func() { b.WriteBoolean(p.Vip) })
+	b.WriteMessageEnd()
+}
+
+//生成的代码、反射及手写的Write*调用写入的字节相同
+func TestEncodingsAgree(t *testing.T) {
+	player, profile := testPlayer(), testProfile()
+	nilAvatar := testPlayer()
+	nilAvatar.Avatar = nil
+	cases := []struct {
+		name    string
+		gen     func(b *byt.Buffer) error
+		reflect interface{}
+		hand    func(b *byt.Buffer)
+	}{
+		{"Player", player.MarshalByt, reflectPlayer(player), func(b *byt.Buffer) { handPlayer(b, &player) }},
+		{"Player(nil Avatar)", nilAvatar.MarshalByt, reflectPlayer(nilAvatar), func(b *byt.Buffer) { handPlayer(b, &nilAvatar) }},
+		{"Profile", profile.MarshalByt, reflectProfile(profile), func(b *byt.Buffer) { handProfile(b, &profile) }},
+	}
+	for _, c := range cases {
+		gb := byt.NewBuffer()
+		if err := c.gen(gb); err != nil {
+			t.Fatalf("%s.MarshalByt: %v", c.name, err)
+		}
+		rb, err := byt.Marshal(c.reflect)
+		if err != nil {
+			t.Fatalf("byt.Marshal(%s): %v", c.name, err)
+		}
+		hb := byt.NewBuffer()
+		c.hand(hb)
+		gen, hand := gb.GetByte()[:gb.GetTop()], hb.GetByte()[:hb.GetTop()]
+		if !bytes.Equal(gen, rb) {
+			t.Errorf("%s: MarshalByt\n% x\nbyt.Marshal\n% x", c.name, gen, rb)
+		}
+		if !bytes.Equal(gen, hand) {
+			t.Errorf("%s: MarshalByt\n% x\nWrite*\n% x", c.name, gen, hand)
+		}
+	}
+}
+
+//生成的代码与反射读取的结果相同
+func TestDecodingsAgree(t *testing.T) {
+	player := testPlayer()
+	data, err := byt.Marshal(&player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gen Player
+	if err := gen.UnmarshalByt(byt.NewBufferWithByte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var ref reflectPlayer
+	if err := byt.Unmarshal(data, &ref); err != nil {
+		t.Fatal(err)
+	}
+	player.Cache = ""
+	if !reflect.DeepEqual(gen, player) || !reflect.DeepEqual(Player(ref), player) {
+		t.Errorf("decoded\n%+v\n%+v\nwant\n%+v", gen, Player(ref), player)
+	}
+
+	profile := testProfile()
+	data, err = byt.Marshal(&profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var genp Profile
+	if err := genp.UnmarshalByt(byt.NewBufferWithByte(data)); err != nil {
+		t.Fatal(err)
+	}
+	var refp reflectProfile
+	if err := byt.Unmarshal(data, &refp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(genp, profile) || !reflect.DeepEqual(Profile(refp), profile) {
+		t.Errorf("decoded\n%+v\n%+v\nwant\n%+v", genp, Profile(refp), profile)
+	}
+}
+
+//字节数组按MaxData而不是MaxCount限制（与反射相同）
+func TestAvatarLimit(t *testing.T) {
+	player := testPlayer()
+	player.Avatar = make([]byte, 1<<20)
+	data, err := byt.Marshal(&player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := byt.NewBufferWithByte(data)
+	b.SetLimits(byt.Limits{MaxData: 8 << 20})
+	var gen Player
+	if err := gen.UnmarshalByt(b); err != nil {
+		t.Errorf("UnmarshalByt: %v", err)
+	}
+	b = byt.NewBufferWithByte(data)
+	b.SetLimits(byt.Limits{MaxData: 8 << 20})
+	var ref reflectPlayer
+	if err := b.ReadObject(&ref); err != nil {
+		t.Errorf("ReadObject: %v", err)
+	}
+	if len(gen.Avatar) != 1<<20 || len(ref.Avatar) != 1<<20 {
+		t.Errorf("Avatar length = %d, %d", len(gen.Avatar), len(ref.Avatar))
+	}
+
+	b = byt.NewBufferWithByte(data)
+	b.SetLimits(byt.Limits{MaxData: 1 << 10})
+	if err := gen.UnmarshalByt(b); err == nil {
+		t.Error("UnmarshalByt over MaxData succeeded")
+	}
+}
+
+//新版本（增加了各种编码类型的字段8 ~ 13）写入的Profile
+func handProfileV2(b *byt.Buffer, p *Profile) {
+	p2 := byt.NewBuffer()
+	handProfile(p2, p)
+	b.WriteBytes(p2.GetByte(), 0, p2.GetTop()-1) //去掉结束标记
+	b.WriteField(8, byt.WireFixed8, func() { b.WriteBoolean(true) })
+	b.WriteField(9, byt.WireFixed16, func() { b.WriteShort(-1) })
+	b.WriteField(10, byt.WireFixed32, func() { b.WriteFloat32(2.5) })
+	b.WriteField(11, byt.WireFixed64, func() { b.WriteLong(-1) })
+	b.WriteField(12, byt.WireVarint, func() { b.WriteVarint(-1 << 50) })
+	b.WriteField(13, byt.WireBytes, func() {
+		b.WriteField(1, byt.WireBytes, func() { b.WriteUTF8String("嵌套") })
+		b.WriteMessageEnd()
+	})
+	b.WriteMessageEnd()
+}
+
+//生成的代码及反射读取其他版本写入的Profile：跳过不认识的字段，没有出现的字段为默认值
+func TestProfileVersions(t *testing.T) {
+	profile := testProfile()
+	b := byt.NewBuffer()
+	handProfileV2(b, &profile)
+	b.WriteInt(-1)
+	newer := b.GetByte()[:b.GetTop()]
+
+	//旧版本（只有字段1、2）写入的内容
+	b = byt.NewBuffer()
+	b.WriteField(1, byt.WireFixed64, func() { b.WriteLong(profile.Id) })
+	b.WriteField(2, byt.WireBytes, func() { b.WriteUTF8String(profile.Name) })
+	b.WriteMessageEnd()
+	b.WriteInt(-1)
+	older := b.GetByte()[:b.GetTop()]
+	oldWant := Profile{Id: profile.Id, Name: profile.Name, Level: 1}
+
+	for _, c := range []struct {
+		name string
+		data []byte
+		want Profile
+	}{{"newer", newer, profile}, {"older", older, oldWant}} {
+		gb := byt.NewBufferWithByte(c.data)
+		gen := testProfile() //读取前的值不影响结果
+		gen.Level = 5
+		if err := gen.UnmarshalByt(gb); err != nil {
+			t.Fatalf("%s: UnmarshalByt: %v", c.name, err)
+		}
+		rb := byt.NewBufferWithByte(c.data)
+		var ref reflectProfile
+		if err := rb.ReadObject(&ref); err != nil {
+			t.Fatalf("%s: ReadObject: %v", c.name, err)
+		}
+		if !reflect.DeepEqual(gen, c.want) || !reflect.DeepEqual(Profile(ref), c.want) {
+			t.Errorf("%s: decoded\n%+v\n%+v\nwant\n%+v", c.name, gen, Profile(ref), c.want)
+		}
+		if gb.ReadInt() != -1 || rb.ReadInt() != -1 {
+			t.Errorf("%s: offset after message", c.name)
+		}
+	}
+}
+
+//读取失败时生成的UnmarshalByt将偏移位置恢复至读取前的位置（与ReadObject相同）
+func TestUnmarshalRestoresOffset(t *testing.T) {
+	player, profile := testPlayer(), testProfile()
+	cases := []struct {
+		name string
+		src  interface{}
+		dst  interface{ UnmarshalByt(*byt.Buffer) error }
+	}{
+		{"Player", &player, &Player{}},
+		{"Profile", &profile, &Profile{}},
+	}
+	for _, c := range cases {
+		data, err := byt.Marshal(c.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 0; n < len(data); n++ {
+			b := byt.NewBuffer()
+			b.WriteShort(7)
+			b.WriteBytes(data, 0, n)
+			b.ReadShort()
+			if err := c.dst.UnmarshalByt(b); err == nil || b.GetOffset() != 2 {
+				t.Errorf("%s truncated to %d: err %v, offset %d; want 2", c.name, n, err, b.GetOffset())
+			}
+		}
+	}
+}
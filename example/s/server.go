@@ -25,8 +25,9 @@ func msgHandler(s *ws.Session, b []byte) {
 	}
 	buf := byt.NewBufferWithByte(b)
 	str := buf.ReadUTF8String()
-	buf.Kill()
-	buf = nil
+	if buf.Err() != nil {
+		return
+	}
 
 	sary := strings.Split(str, "|")
 	if len(sary) < 2 {
@@ -35,10 +36,9 @@ func msgHandler(s *ws.Session, b []byte) {
 	fmt.Println(sary[0] + " -> " + sary[1])
 
 	if !s.IsClosed() {
-		_buf := byt.NewBuffer()
+		//从对象池获取，发送完毕后由Session自动放回对象池
+		_buf := byt.Acquire(64)
 		_buf.WriteUTF8String("hello client!|" + sary[1])
-		s.SendMessage(_buf.GetByte())
-		_buf.Kill()
-		_buf = nil
+		s.SendBuffer(_buf)
 	}
 }
@@ -2,7 +2,7 @@
 // WebSocket Session对象
 // Author 		:Jella
 // Version 		:1.0.2(release)
-// Dependency		:github.com/gorilla/websocket
+// Dependency		:github.com/gorilla/websocket, Golang-master/byt
 /********************************************************/
 
 package ws
@@ -12,27 +12,57 @@ import (
 	"strconv"
 	"sync"
 
+	"Golang-master/byt"
+
 	"github.com/gorilla/websocket"
 )
 
+//待发送的消息（buf不为nil时，发送完毕后将其放回对象池）
+type outMessage struct {
+	data []byte
+	buf  *byt.Buffer
+}
+
+//发送完毕（或放弃发送）后释放对象池中的字节缓冲对象
+func (m outMessage) done() {
+	if m.buf != nil {
+		byt.Release(m.buf)
+	}
+}
+
 //写线程
 func (session *Session) write() {
 	var (
-		data []byte
-		err  error
+		msg outMessage
+		err error
 	)
 	for {
 		select {
-		case data = <-session.out:
+		case msg = <-session.out:
 		case <-session.cls:
 			goto ERR
 		}
-		if err = session.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+		err = session.ws.WriteMessage(websocket.TextMessage, msg.data)
+		msg.done()
+		if err != nil {
 			goto ERR
 		}
 	}
 ERR:
 	session.Close()
+	session.drain()
+}
+
+//释放发送队列中未发送的消息（连接关闭后调用）
+func (session *Session) drain() {
+	for {
+		select {
+		case msg := <-session.out:
+			msg.done()
+		default:
+			return
+		}
+	}
 }
 
 //读线程（由Session对象内部线程操作）
@@ -96,7 +126,7 @@ func createWsSession(wsc *websocket.Conn, len int) (session *Session, err error)
 		ws:            wsc,
 		RemoteAddress: wsc.RemoteAddr().String(),
 		in:            make(chan []byte, len),
-		out:           make(chan []byte, len),
+		out:           make(chan outMessage, len),
 		cls:           make(chan byte, 1),
 	}
 
@@ -120,7 +150,7 @@ type Session struct {
 
 	//读、写相关
 	in  chan []byte
-	out chan []byte
+	out chan outMessage
 
 	//关闭连接相关
 	cls     chan byte
@@ -164,14 +194,38 @@ func (session *Session) IsClosed() bool {
  * @param data 数据
  */
 func (session *Session) SendMessage(data []byte) {
-	if len(data) > config.BufferLen {
+	session.send(outMessage{data: data})
+}
+
+/**
+ * 发送字节缓冲对象中已写入的内容（0 ~ top）
+ * 对象应由byt.Acquire获取，发送完毕后自动调用byt.Release放回对象池，调用后不可再使用该对象
+ * @param buf 字节缓冲对象
+ */
+func (session *Session) SendBuffer(buf *byt.Buffer) {
+	if buf == nil {
+		return
+	}
+	session.send(outMessage{data: buf.GetByte()[:buf.GetTop()], buf: buf})
+}
+
+func (session *Session) send(msg outMessage) {
+	if len(msg.data) > config.BufferLen {
 		fmt.Println("[Error]: server send data length overflow. data length > " + strconv.Itoa(config.BufferLen) + "byte.")
+		msg.done()
 		return
 	}
 
 	select {
-	case session.out <- data:
+	case session.out <- msg:
+		//写线程可能已经退出，此时由发送方释放队列中的消息
+		select {
+		case <-session.cls:
+			session.drain()
+		default:
+		}
 	case <-session.cls:
+		msg.done()
 		session.Close()
 	}
 }
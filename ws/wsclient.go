@@ -2,7 +2,7 @@
 // WebSocket Client连接对象
 // Author 		:Jella
 // Version 		:1.0.2(release)
-// Dependency	:github.com/gorilla/websocket
+// Dependency	:github.com/gorilla/websocket, Golang-master/byt
 /********************************************************/
 package ws
 
@@ -14,6 +14,8 @@ import (
 	"strconv"
 	"sync"
 
+	"Golang-master/byt"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -33,7 +35,7 @@ type WSClient struct {
 	cfg       WSClient_CONFIG
 	conn      *websocket.Conn
 	in        chan []byte
-	out       chan []byte
+	out       chan outMessage
 	cls       chan byte
 	mutex     sync.Mutex
 	clsFunc   wsClientClose
@@ -64,7 +66,7 @@ func (client *WSClient) Connect(conf WSClient_CONFIG) error {
 	client.IsConnect = true
 
 	client.in = make(chan []byte, conf.BufferLen)
-	client.out = make(chan []byte, conf.BufferLen)
+	client.out = make(chan outMessage, conf.BufferLen)
 	client.cls = make(chan byte, 1)
 
 	go client.sendMessage()
@@ -78,23 +80,22 @@ func (client *WSClient) Connect(conf WSClient_CONFIG) error {
  * @param data 数据内容
  */
 func (client *WSClient) Send(data []byte) {
-	if !client.IsConnect {
-		return
-	}
-
 	if data == nil {
 		return
 	}
-	if len(data) > client.cfg.BufferLen {
-		fmt.Println("[Error]: 数据长度溢出，无法进行发送.")
-		return
-	}
+	client.send(outMessage{data: data})
+}
 
-	select {
-	case client.out <- data:
-	case <-client.cls:
-		client.Close()
+/**
+ * 发送字节缓冲对象中已写入的内容（0 ~ top）
+ * 对象应由byt.Acquire获取，发送完毕后自动调用byt.Release放回对象池，调用后不可再使用该对象
+ * @param buf 字节缓冲对象
+ */
+func (client *WSClient) SendBuffer(buf *byt.Buffer) {
+	if buf == nil {
+		return
 	}
+	client.send(outMessage{data: buf.GetByte()[:buf.GetTop()], buf: buf})
 }
 
 /**
@@ -156,24 +157,64 @@ func (client *WSClient) Close() {
 //////////////////////////////////////////////////////////
 //内部实现
 
+func (client *WSClient) send(msg outMessage) {
+	if !client.IsConnect {
+		msg.done()
+		return
+	}
+	if len(msg.data) > client.cfg.BufferLen {
+		fmt.Println("[Error]: 数据长度溢出，无法进行发送.")
+		msg.done()
+		return
+	}
+
+	select {
+	case client.out <- msg:
+		//写线程可能已经退出，此时由发送方释放队列中的消息
+		select {
+		case <-client.cls:
+			client.drain()
+		default:
+		}
+	case <-client.cls:
+		msg.done()
+		client.Close()
+	}
+}
+
+//释放发送队列中未发送的消息（连接关闭后调用）
+func (client *WSClient) drain() {
+	for {
+		select {
+		case msg := <-client.out:
+			msg.done()
+		default:
+			return
+		}
+	}
+}
+
 func (client *WSClient) sendMessage() {
 	var (
-		data []byte
-		err  error
+		msg outMessage
+		err error
 	)
 	for {
 		select {
-		case data = <-client.out:
+		case msg = <-client.out:
 		case <-client.cls:
 			goto ERR
 		}
-		if err = client.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		err = client.conn.WriteMessage(websocket.TextMessage, msg.data)
+		msg.done()
+		if err != nil {
 			fmt.Println(err)
 			goto ERR
 		}
 	}
 ERR:
 	client.Close()
+	client.drain()
 }
 
 func (client *WSClient) reciMessage() {